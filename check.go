@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-by-example/internal/exercises"
+)
+
+// exercisesRoot mirrors examplesRoot with a practice exercise for most topics
+const exercisesRoot = "exercises"
+
+// progressFile is where check records attempts, passes and hints shown
+const progressFile = ".exercise-progress.json"
+
+// findExercises resolves queries to exercises using the same fuzzy matching
+// as the run command
+func findExercises(list []exercises.Exercise, queries []string) ([]exercises.Exercise, error) {
+	byPath := make(map[string]exercises.Exercise)
+	candidates := make([]Example, len(list))
+	for i, ex := range list {
+		byPath[ex.Path] = ex
+		candidates[i] = Example{Name: ex.Name, Path: ex.Path, Dir: ex.Dir}
+	}
+
+	var selected []exercises.Exercise
+	for _, query := range queries {
+		match, err := findExample(candidates, query)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, byPath[match.Path])
+	}
+	return selected, nil
+}
+
+func checkCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	list := fs.Bool("list", false, "List exercises and their status without running tests")
+	verbose := fs.Bool("v", false, "Show test output for passing exercises too")
+	progressPath := fs.String("progress", progressFile, "Progress file")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Per-exercise timeout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	all, err := exercises.Load(exercisesRoot)
+	if err != nil {
+		return err
+	}
+	progress, err := exercises.LoadProgress(*progressPath)
+	if err != nil {
+		return err
+	}
+
+	if *list {
+		for _, ex := range all {
+			status := "todo"
+			if s := progress.Status(ex.Path); s.Passed {
+				status = "passed"
+			} else if s.Attempts > 0 {
+				status = fmt.Sprintf("tried %dx", s.Attempts)
+			}
+			fmt.Printf("%-12s %-50s %s\n", status, ex.Path, ex.Title)
+		}
+		fmt.Printf("\nProgress: %d/%d exercises passed\n", progress.Passed(all), len(all))
+		return nil
+	}
+
+	selected := all
+	if fs.NArg() > 0 {
+		if selected, err = findExercises(all, fs.Args()); err != nil {
+			return err
+		}
+	}
+
+	for _, ex := range selected {
+		fmt.Printf("=== CHECK %s: %s\n", ex.Path, ex.Title)
+		res, err := exercises.Check(ctx, ex, *timeout)
+		if err != nil {
+			fmt.Printf("--- ERROR %s: %v\n%s", ex.Path, err, indent(res.Output))
+			continue
+		}
+
+		hint, n := progress.Record(ex, res, time.Now())
+		attempts := progress.Status(ex.Path).Attempts
+		if res.Passed {
+			fmt.Printf("--- PASS %s (attempt %d)\n", ex.Path, attempts)
+			if *verbose {
+				fmt.Print(indent(res.Output))
+			}
+			continue
+		}
+
+		fmt.Printf("--- FAIL %s (attempt %d)\n%s", ex.Path, attempts, indent(res.Output))
+		if hint != "" {
+			fmt.Printf("Hint %d/%d: %s\n", n, len(ex.Hints), hint)
+		}
+	}
+
+	if err := progress.Save(*progressPath); err != nil {
+		return err
+	}
+	fmt.Printf("\nProgress: %d/%d exercises passed\n", progress.Passed(all), len(all))
+	return nil
+}
+
+// indent prefixes every line of s with four spaces
+func indent(s string) string {
+	s = strings.TrimRight(s, "\n")
+	if s == "" {
+		return ""
+	}
+	return "    " + strings.ReplaceAll(s, "\n", "\n    ") + "\n"
+}
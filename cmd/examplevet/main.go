@@ -0,0 +1,24 @@
+// Command examplevet runs the analyzers in internal/vet, which flag the
+// concurrency and API misuse patterns the examples warn about, together
+// with the checks of go vet:
+//
+//	go run ./cmd/examplevet ./...
+//
+// It also works as a go vet tool:
+//
+//	go build -o examplevet ./cmd/examplevet
+//	go vet -vettool=$(pwd)/examplevet ./...
+//
+// Findings listed in vet.Suppressions, which examples show on purpose, are
+// left out.
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/multichecker"
+
+	"go-by-example/internal/vet"
+)
+
+func main() {
+	multichecker.Main(append(vet.Suppress(vet.Standard, vet.Suppressions), vet.Analyzers...)...)
+}
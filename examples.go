@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// examplesRoot is the directory, relative to the repository root, that holds
+// every runnable example program.
+const examplesRoot = "examples"
+
+// Example describes a single runnable example program
+type Example struct {
+	Name string // Topic name, e.g. "15-worker-pools"
+	Path string // Slash-separated path below examplesRoot, e.g. "01-basics/15-worker-pools"
+	Dir  string // Directory on disk containing main.go
+}
+
+// discoverExamples walks root and returns every directory that contains a
+// main.go, sorted by path so the numbering of the topics is preserved.
+func discoverExamples(root string) ([]Example, error) {
+	var examples []Example
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == "testdata" {
+			return filepath.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(path, "main.go")); err != nil {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		examples = append(examples, Example{
+			Name: d.Name(),
+			Path: filepath.ToSlash(rel),
+			Dir:  path,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(examples, func(i, j int) bool {
+		return examples[i].Path < examples[j].Path
+	})
+	return examples, nil
+}
+
+// Title returns a human readable title, e.g. "Worker Pools" for
+// "15-worker-pools"
+func (ex Example) Title() string {
+	words := strings.Split(trimOrdinal(ex.Name), "-")
+	for i, w := range words {
+		if w == "" || (i > 0 && (w == "and" || w == "or" || w == "of")) {
+			continue
+		}
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// normalizeName lowercases s and folds spaces and underscores into dashes so
+// "control flow" and "control-flow" match the same topic.
+func normalizeName(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return strings.NewReplacer(" ", "-", "_", "-").Replace(s)
+}
+
+// trimOrdinal drops the leading "15-" style ordinal from a topic name.
+func trimOrdinal(name string) string {
+	if i := strings.IndexByte(name, '-'); i > 0 && name[0] >= '0' && name[0] <= '9' {
+		return name[i+1:]
+	}
+	return name
+}
+
+// isSubsequence reports whether every byte of sub appears in s in order.
+func isSubsequence(sub, s string) bool {
+	i := 0
+	for j := 0; j < len(s) && i < len(sub); j++ {
+		if s[j] == sub[i] {
+			i++
+		}
+	}
+	return i == len(sub)
+}
+
+// matchExamples returns the examples that best match query. Matching is tried
+// in tiers from strictest to loosest and the first tier with any hits wins:
+//
+//  1. exact path or topic name ("01-basics/15-worker-pools", "15-worker-pools")
+//  2. topic name without its ordinal ("worker-pools")
+//  3. substring of the path ("worker", "channels")
+//  4. subsequence of the path ("wrkpl")
+func matchExamples(examples []Example, query string) []Example {
+	q := normalizeName(query)
+	if q == "" {
+		return nil
+	}
+
+	tiers := []func(ex Example) bool{
+		func(ex Example) bool {
+			return normalizeName(ex.Path) == q || normalizeName(ex.Name) == q
+		},
+		func(ex Example) bool {
+			return trimOrdinal(normalizeName(ex.Name)) == q
+		},
+		func(ex Example) bool {
+			return strings.Contains(normalizeName(ex.Path), q)
+		},
+		func(ex Example) bool {
+			return isSubsequence(q, normalizeName(ex.Path))
+		},
+	}
+
+	for _, match := range tiers {
+		var found []Example
+		for _, ex := range examples {
+			if match(ex) {
+				found = append(found, ex)
+			}
+		}
+		if len(found) > 0 {
+			return found
+		}
+	}
+	return nil
+}
+
+// findExample resolves query to exactly one example or explains why it can't.
+func findExample(examples []Example, query string) (Example, error) {
+	found := matchExamples(examples, query)
+	switch len(found) {
+	case 0:
+		return Example{}, fmt.Errorf("no example matches %q (see \"list\")", query)
+	case 1:
+		return found[0], nil
+	}
+
+	names := make([]string, len(found))
+	for i, ex := range found {
+		names[i] = ex.Path
+	}
+	return Example{}, fmt.Errorf("%q is ambiguous, it matches:\n  %s", query, strings.Join(names, "\n  "))
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"testing"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/internal/testlog"
+)
+
+func TestMainNoLeaks(t *testing.T) {
+	leaktest.Check(t)
+	testlog.Discard(t)
+	main()
+}
@@ -1,8 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"sync/atomic"
 	"time"
+
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/group"
+	"go-by-example/pkg/supervisor"
 )
 
 /**
@@ -22,77 +29,236 @@ import (
  * - Server request handling
  * - Asynchronous operations
  * - Event handling
+ *
+ * Starting a goroutine is the easy part; knowing when it is done, what went
+ * wrong and how to stop it is the hard part. Sleeping in main for "long
+ * enough" is a guess that is either too short or wastes time. Every example
+ * here starts its goroutines in a group.Group (pkg/group) instead: Wait
+ * returns as soon as all of them have returned, with their errors joined,
+ * the first error cancels the others, and a panic becomes an error instead
+ * of crashing the program.
+ *
+ * Goroutines that should run for the life of the program, rather than
+ * finish, are better off under a supervisor.Supervisor (pkg/supervisor),
+ * which restarts them when they fail.
+ *
+ * Work is simulated with a clock.Clock so the tests can drive it with a
+ * clock.Fake instead of sleeping.
  */
 
 /**
  * worker simulates a task that takes some time to complete
+ * @param ctx: cancelling it stops the task early
+ * @param clk: clock that measures the task
  * @param id: identifier for the worker
  * @param duration: how long the task takes
+ * @return: ctx.Err() if the task was cancelled
  */
-func worker(id int, duration time.Duration) {
+func worker(ctx context.Context, clk clock.Clock, id int, duration time.Duration) error {
 	log.Printf("Worker %d: Starting\n", id)
-	time.Sleep(duration)
-	log.Printf("Worker %d: Done\n", id)
+	timer := clk.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		log.Printf("Worker %d: Done\n", id)
+		return nil
+	case <-ctx.Done():
+		log.Printf("Worker %d: Cancelled\n", id)
+		return ctx.Err()
+	}
 }
 
 /**
  * counter demonstrates a simple concurrent counter
+ * @param clk: clock used to pause between counts
  * @param name: identifier for the counter
  * @param n: number of iterations
  */
-func counter(name string, n int) {
+func counter(clk clock.Clock, name string, n int) {
 	for i := 1; i <= n; i++ {
 		log.Printf("Counter %s: %d\n", name, i)
-		time.Sleep(100 * time.Millisecond)
+		clk.Sleep(100 * time.Millisecond)
+	}
+}
+
+/**
+ * firstError runs a slow worker next to a task that fails; the failure
+ * cancels the worker instead of letting it finish for nothing
+ * @param ctx: parent context of the group
+ * @param clk: clock that measures the work
+ * @return: the error of the failing task
+ */
+func firstError(ctx context.Context, clk clock.Clock) error {
+	g := group.New(ctx, group.Options{})
+	g.Go(func(ctx context.Context) error {
+		return worker(ctx, clk, 3, time.Second)
+	})
+	g.Go(func(ctx context.Context) error {
+		clk.Sleep(200 * time.Millisecond)
+		return errors.New("task 4 failed")
+	})
+	return g.Wait()
+}
+
+/**
+ * limited runs tasks in a group that lets only limit of them run at once
+ * @param ctx: parent context of the group
+ * @param clk: clock that measures the work
+ * @param tasks: number of tasks, each taking 100ms
+ * @param limit: tasks running at once
+ * @return: the most tasks seen running at once
+ */
+func limited(ctx context.Context, clk clock.Clock, tasks, limit int) (int, error) {
+	g := group.New(ctx, group.Options{Limit: limit})
+	var running, peak atomic.Int32
+	for range tasks {
+		// Go waits here while limit tasks are running
+		g.Go(func(ctx context.Context) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			clk.Sleep(100 * time.Millisecond)
+			return nil
+		})
+	}
+	err := g.Wait()
+	return int(peak.Load()), err
+}
+
+/**
+ * logged returns supervisor options that log every start, restart and stop
+ * @param strategy: which children restart along with a failed one
+ * @return: options for supervisor.New
+ */
+func logged(strategy supervisor.Strategy) supervisor.Options {
+	return supervisor.Options{
+		Strategy:    strategy,
+		MaxRestarts: 2,
+		Period:      time.Minute,
+		OnStart:     func(child string) { log.Printf("Started %s\n", child) },
+		OnRestart:   func(child string, err error) { log.Printf("Restarting %s after: %v\n", child, err) },
+		OnTerminate: func(child string, err error) { log.Printf("Stopped %s\n", child) },
+	}
+}
+
+/**
+ * supervise runs a supervision tree until ctx is done: a metrics exporter,
+ * and a nested supervisor for a queue and the worker reading from it. The
+ * queue crashes once, and rest-for-one restarts the worker along with it.
+ * @param ctx: cancelling it shuts the tree down, last child first
+ * @param recovered: closed once the worker runs again after the crash
+ * @return: nil after a clean shutdown
+ */
+func supervise(ctx context.Context, recovered chan<- struct{}) error {
+	untilDone := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
 	}
+	var queueRuns, workerRuns atomic.Int32
+
+	workers := supervisor.New(logged(supervisor.RestForOne),
+		supervisor.Child{Name: "queue", Run: func(ctx context.Context) error {
+			if queueRuns.Add(1) == 1 {
+				return errors.New("queue connection lost")
+			}
+			return untilDone(ctx)
+		}},
+		supervisor.Child{Name: "worker", Run: func(ctx context.Context) error {
+			if workerRuns.Add(1) == 2 {
+				close(recovered)
+			}
+			return untilDone(ctx)
+		}},
+	)
+	root := supervisor.New(logged(supervisor.OneForOne),
+		supervisor.Child{Name: "metrics", Run: untilDone},
+		supervisor.Child{Name: "workers", Run: workers.Run},
+	)
+	return root.Run(ctx)
+}
+
+/**
+ * giveUp supervises a child that fails every time it runs
+ * @param ctx: parent context of the supervisor
+ * @return: the error of the supervisor once it stopped restarting the child
+ */
+func giveUp(ctx context.Context) error {
+	sup := supervisor.New(logged(supervisor.OneForOne),
+		supervisor.Child{Name: "flaky", Run: func(context.Context) error {
+			return errors.New("disk full")
+		}},
+	)
+	return sup.Run(ctx)
 }
 
 func main() {
 	log.Println("=== Goroutines Examples ===")
+	clk := clock.New()
+	ctx := context.Background()
 
 	/**
 	 * 1. Basic goroutine usage
-	 * Shows how to start concurrent execution
+	 * Shows how to start concurrent execution. Wait returns when both
+	 * workers are done, after 2 seconds, not after a guessed sleep.
 	 */
 	log.Println("\n1. Basic goroutine usage")
 	// Start two goroutines which will run concurrently more like async
-	go worker(1, 2*time.Second)
-	go worker(2, 1*time.Second)
-	time.Sleep(3 * time.Second) // Wait for workers to finish
+	g := group.New(ctx, group.Options{})
+	g.Go(func(ctx context.Context) error { return worker(ctx, clk, 1, 2*time.Second) })
+	g.Go(func(ctx context.Context) error { return worker(ctx, clk, 2, 1*time.Second) })
+	if err := g.Wait(); err != nil {
+		log.Printf("Error: %v\n", err)
+	}
 
 	/**
 	 * 2. Multiple goroutines
-	 * func(id int) { ... }: This is an anonymous function that takes an integer parameter id.
-	 * (i): This is the value of i that will be passed to the anonymous function.
-	 * go func(id int) { ... }(i): This is the actual goroutine invocation, function call with i as the argument.
-	 * The anonymous function is executed in a new goroutine, and the value of i is captured at the time the goroutine is created.
+	 * func(ctx context.Context) error { ... }: This is an anonymous function that g.Go runs in a new goroutine.
+	 * Since Go 1.22 every iteration of the loop has its own i, so the function can use it directly;
+	 * before, all the goroutines shared one i and it had to be passed as a parameter: go func(id int) { ... }(i).
 	 */
 	log.Println("\n2. Multiple goroutines")
+	g = group.New(ctx, group.Options{})
 	for i := 1; i <= 3; i++ {
-		go func(id int) {
-			log.Printf("Goroutine %d executing\n", id)
-		}(i)
+		g.Go(func(context.Context) error {
+			log.Printf("Goroutine %d executing\n", i)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		log.Printf("Error: %v\n", err)
 	}
-	time.Sleep(time.Second)
 
 	/**
 	 * 3. Concurrent counters
 	 * Shows independent execution of goroutines
 	 */
 	log.Println("\n3. Concurrent counters")
-	go counter("A", 3)
-	go counter("B", 3)
-	time.Sleep(time.Second)
+	g = group.New(ctx, group.Options{})
+	for _, name := range []string{"A", "B"} {
+		g.Go(func(context.Context) error {
+			counter(clk, name, 3)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		log.Printf("Error: %v\n", err)
+	}
 
 	/**
 	 * 4. Anonymous goroutine
 	 * Demonstrates using anonymous functions as goroutines
 	 */
 	log.Println("\n4. Anonymous goroutine")
-	go func() {
+	g = group.New(ctx, group.Options{})
+	g.Go(func(context.Context) error {
 		log.Println("Executing anonymous goroutine")
-	}()
-	time.Sleep(time.Second)
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		log.Printf("Error: %v\n", err)
+	}
 
 	/**
 	 * 5. Goroutine with closure
@@ -100,10 +266,81 @@ func main() {
 	 */
 	log.Println("\n5. Goroutine with closure")
 	message := "Hello from closure"
-	go func() {
+	g = group.New(ctx, group.Options{})
+	g.Go(func(context.Context) error {
 		log.Println(message)
-	}()
-	time.Sleep(time.Second)
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		log.Printf("Error: %v\n", err)
+	}
+
+	/**
+	 * 6. First error cancels the others
+	 * The group's context is cancelled as soon as one function fails, so
+	 * the others can stop early. Wait returns the failure.
+	 */
+	log.Println("\n6. First error cancels the others")
+	if err := firstError(ctx, clk); err != nil {
+		log.Printf("Wait returned: %v\n", err)
+	}
+
+	/**
+	 * 7. Concurrency limit
+	 * A limit caps how many functions run at once; Go waits for a free slot
+	 */
+	log.Println("\n7. Concurrency limit")
+	peak, err := limited(ctx, clk, 6, 2)
+	if err != nil {
+		log.Printf("Error: %v\n", err)
+	}
+	log.Printf("Ran 6 tasks, at most %d at once\n", peak)
+
+	/**
+	 * 8. Panics become errors
+	 * A panic in a goroutine crashes the whole program, unless something
+	 * recovers it in that same goroutine. The group does, and Wait returns
+	 * a *group.PanicError with the value and the stack trace.
+	 */
+	log.Println("\n8. Panics become errors")
+	g = group.New(ctx, group.Options{})
+	g.Go(func(context.Context) error {
+		var counts map[string]int
+		counts["oops"]++ // Writing to a nil map panics
+		return nil
+	})
+	var panicErr *group.PanicError
+	if err := g.Wait(); errors.As(err, &panicErr) {
+		log.Printf("Recovered: %v\n", panicErr.Value)
+	}
+
+	/**
+	 * 9. Supervised goroutines
+	 * Supervisors start their children in order, restart them when they
+	 * fail and stop them in reverse order. A supervisor is a child like
+	 * any other, so they nest into trees.
+	 */
+	log.Println("\n9. Supervised goroutines")
+	treeCtx, shutdown := context.WithCancel(ctx)
+	recovered := make(chan struct{})
+	stopped := make(chan error)
+	go func() { stopped <- supervise(treeCtx, recovered) }()
+	<-recovered
+	shutdown()
+	if err := <-stopped; err != nil {
+		log.Printf("Error: %v\n", err)
+	}
+
+	/**
+	 * 10. Restart intensity
+	 * A child that keeps failing isn't restarted forever: after
+	 * MaxRestarts within Period, the supervisor stops and returns an
+	 * error, which its own supervisor would see as a failure.
+	 */
+	log.Println("\n10. Restart intensity")
+	if err := giveUp(ctx); err != nil {
+		log.Printf("Supervisor gave up: %v\n", err)
+	}
 
 	log.Println("Main: All done")
 }
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/internal/testlog"
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/supervisor"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+func TestWorker(t *testing.T) {
+	t.Run("Done", func(t *testing.T) {
+		lines := testlog.Capture(t)
+		clk := clock.NewFake(epoch)
+		done := make(chan error)
+		go func() { done <- worker(context.Background(), clk, 1, time.Second) }()
+
+		testlog.Expect(t, lines, "Worker 1: Starting")
+		clk.BlockUntil(1)
+		clk.Advance(time.Second)
+		testlog.Expect(t, lines, "Worker 1: Done")
+		if err := <-done; err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		lines := testlog.Capture(t)
+		clk := clock.NewFake(epoch)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- worker(ctx, clk, 2, time.Second) }()
+
+		testlog.Expect(t, lines, "Worker 2: Starting")
+		cancel()
+		testlog.Expect(t, lines, "Worker 2: Cancelled")
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want %v", err, context.Canceled)
+		}
+		if n := clk.Waiters(); n != 0 {
+			t.Errorf("got %d timers, want 0", n)
+		}
+	})
+}
+
+func TestFirstError(t *testing.T) {
+	lines := testlog.Capture(t)
+	clk := clock.NewFake(epoch)
+	done := make(chan error)
+	go func() { done <- firstError(context.Background(), clk) }()
+
+	testlog.Expect(t, lines, "Worker 3: Starting")
+	clk.BlockUntil(2) // The worker and the failing task
+	clk.Advance(200 * time.Millisecond)
+	testlog.Expect(t, lines, "Worker 3: Cancelled")
+	if err := <-done; err == nil || err.Error() != "task 4 failed" {
+		t.Errorf("got %v, want task 4 failed", err)
+	}
+}
+
+func TestLimited(t *testing.T) {
+	tests := []struct {
+		name     string
+		tasks    int
+		limit    int
+		expected int
+	}{
+		{name: "more tasks than the limit", tasks: 5, limit: 2, expected: 2},
+		{name: "fewer tasks than the limit", tasks: 2, limit: 3, expected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(epoch)
+			peak := make(chan int)
+			go func() {
+				n, _ := limited(context.Background(), clk, tt.tasks, tt.limit)
+				peak <- n
+			}()
+
+			// Each round, as many tasks as the limit allows sleep together
+			for left := tt.tasks; left > 0; left -= tt.limit {
+				clk.BlockUntil(min(left, tt.limit))
+				clk.Advance(100 * time.Millisecond)
+			}
+			if got := <-peak; got != tt.expected {
+				t.Errorf("got %d at once, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMainNoLeaks(t *testing.T) {
+	leaktest.Check(t)
+	testlog.Discard(t)
+	main()
+}
+
+// logLines collects the lines logged until done returns
+func logLines(lines <-chan string, done func()) []string {
+	done()
+	var got []string
+	for len(lines) > 0 {
+		got = append(got, <-lines)
+	}
+	slices.Sort(got)
+	return got
+}
+
+func TestSupervise(t *testing.T) {
+	lines := testlog.Capture(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	recovered := make(chan struct{})
+
+	got := logLines(lines, func() {
+		stopped := make(chan error)
+		go func() { stopped <- supervise(ctx, recovered) }()
+		<-recovered
+		cancel()
+		if err := <-stopped; err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+	})
+
+	want := []string{
+		"Restarting queue after: queue connection lost",
+		"Restarting worker after: queue connection lost",
+		"Started metrics", "Started queue", "Started queue", "Started worker", "Started worker", "Started workers",
+		"Stopped metrics", "Stopped queue", "Stopped queue", "Stopped worker", "Stopped worker", "Stopped workers",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestGiveUp(t *testing.T) {
+	lines := testlog.Capture(t)
+	var err error
+	got := logLines(lines, func() { err = giveUp(context.Background()) })
+
+	if !errors.Is(err, supervisor.ErrTooManyRestarts) {
+		t.Errorf("got %v, want %v", err, supervisor.ErrTooManyRestarts)
+	}
+	want := []string{
+		"Restarting flaky after: disk full", "Restarting flaky after: disk full",
+		"Started flaky", "Started flaky", "Started flaky",
+		"Stopped flaky", "Stopped flaky", "Stopped flaky",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
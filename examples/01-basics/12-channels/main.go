@@ -1,8 +1,14 @@
 package main
 
 import (
+	"context"
 	"log"
 	"time"
+
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/pipeline"
+	"go-by-example/pkg/pubsub"
+	"go-by-example/pkg/ringbuf"
 )
 
 /**
@@ -24,6 +30,14 @@ import (
  * - Event handling
  * - Resource management
  * - Timeout handling with select
+ *
+ * Waiting is done through a clock.Clock so the tests can drive producer and
+ * worker with a clock.Fake instead of sleeping.
+ *
+ * The producer and consumers here are written by hand for chan int.
+ * pkg/pipeline has the same patterns as generic stages that work for any
+ * type, close their output when their input is done and stop on context
+ * cancellation, so no goroutine is left blocked on a channel.
  */
 
 /*
@@ -31,14 +45,15 @@ import (
 * producer generates data and sends it to a channel
 * ch chan<- int: This parameter is a channel of type int that is used for sending data. The chan<- syntax indicates that this channel is only for sending data (write-only).
 * count int: This is an integer parameter that specifies how many items the producer should generate and send to the channel.
+* clk clock.Clock: The clock used to pause between items (clock.New() in main, a fake clock in tests).
 
 defer close(ch): The defer keyword is used to ensure that the close(ch) function is called when the producer function completes. This closes the channel ch, signaling to any receiving goroutines that no more data will be sent on this channel.
 */
-func producer(ch chan<- int, count int) {
+func producer(clk clock.Clock, ch chan<- int, count int) {
 	defer close(ch) // Close channel when done
 	for i := 1; i <= count; i++ {
-		ch <- i                            // ch <- i: Sends the current value of i to the channel ch.
-		time.Sleep(100 * time.Millisecond) //Pauses the execution for 100 milliseconds before the next iteration. This simulates some delay in producing each item.
+		ch <- i                           // ch <- i: Sends the current value of i to the channel ch.
+		clk.Sleep(100 * time.Millisecond) //Pauses the execution for 100 milliseconds before the next iteration. This simulates some delay in producing each item.
 	}
 }
 
@@ -73,11 +88,12 @@ func ping(ping chan<- string, pong <-chan string) {
 
 /**
  * worker demonstrates using the select statement for handling multiple channel operations.
+ * @param clk: clock used for the idle timeout
  * @param dataCh: channel for receiving data
  * @param quitCh: channel for receiving quit signal
  * select is used to handle multiple channels in a non-blocking way
  */
-func worker(dataCh <-chan int, quitCh <-chan bool) {
+func worker(clk clock.Clock, dataCh <-chan int, quitCh <-chan bool) {
 	for {
 		select {
 		//The worker waits to receive an integer from the dataCh channel.
@@ -88,15 +104,91 @@ func worker(dataCh <-chan int, quitCh <-chan bool) {
 		case <-quitCh:
 			log.Println("Worker received quit signal")
 			return
-		case <-time.After(500 * time.Millisecond):
+		case <-clk.After(500 * time.Millisecond):
 			log.Println("Worker timed out waiting for data")
 			return
 		}
 	}
 }
 
+/**
+ * evenSquares builds a pipeline: keep the even values, square them on
+ * several workers, and group the results into batches
+ * @param ctx: cancelling it stops every stage
+ * @param values: the input values
+ * @param batchSize: values per batch
+ * @return: channel of batches, closed once all values went through
+ */
+func evenSquares(ctx context.Context, values []int, batchSize int) <-chan []int {
+	source := pipeline.From(ctx, values...)
+	even := pipeline.Filter(ctx, source, func(n int) bool { return n%2 == 0 })
+	squares := pipeline.FanOut(ctx, even, 3, func(n int) int { return n * n })
+	return pipeline.Batch(ctx, squares, pipeline.BatchOptions{Size: batchSize})
+}
+
+/**
+ * broadcast publishes each topic once to three subscribers: one for
+ * everything, one for European orders and a slow one for all orders that
+ * only keeps the latest message
+ * @param topics: topics to publish, in order
+ * @return: topics received per subscription pattern, and the broker stats
+ */
+func broadcast(topics []string) (map[string][]string, pubsub.Stats) {
+	broker := pubsub.New[string]()
+	subs := []*pubsub.Subscription[string]{}
+	for _, sub := range []struct {
+		pattern string
+		opts    pubsub.SubOptions
+	}{
+		{">", pubsub.SubOptions{Buffer: 10}},
+		{"orders.eu.*", pubsub.SubOptions{Buffer: 10}},
+		{"orders.>", pubsub.SubOptions{Buffer: 1, Policy: pubsub.DropOldest}},
+	} {
+		s, _ := broker.Subscribe(sub.pattern, sub.opts)
+		subs = append(subs, s)
+	}
+
+	for _, topic := range topics {
+		broker.Publish(context.Background(), topic, topic)
+	}
+	// Closing lets the subscribers read what is buffered, then ends them
+	broker.Close()
+
+	received := make(map[string][]string)
+	for _, s := range subs {
+		received[s.Pattern()] = []string{}
+		for msg := range s.C() {
+			received[s.Pattern()] = append(received[s.Pattern()], msg.Value)
+		}
+	}
+	return received, broker.Stats()
+}
+
+/**
+ * latestReadings sends every reading to a ring buffer that keeps the newest
+ * ones, like a telemetry producer that must never wait for a slow consumer
+ * @param readings: values to send, before anything is received
+ * @param capacity: readings the buffer holds
+ * @return: readings left for the consumer, and the buffer stats
+ */
+func latestReadings(readings []int, capacity int) ([]int, ringbuf.Stats) {
+	buf := ringbuf.New[int](ringbuf.Options{Capacity: capacity, Policy: ringbuf.DropOldest})
+	for _, r := range readings {
+		buf.Send(context.Background(), r) // Never blocks with DropOldest
+	}
+	stats := buf.Stats()
+	buf.Close()
+
+	var kept []int
+	for r := range buf.All(context.Background()) {
+		kept = append(kept, r)
+	}
+	return kept, stats
+}
+
 func main() {
 	log.Println("=== Channel Examples ===")
+	clk := clock.New()
 
 	/**
 	 * 1. Basic channel usage
@@ -126,9 +218,9 @@ func main() {
 	 */
 	log.Println("\n3. Producer-Consumer pattern")
 	dataCh := make(chan int, 3)
-	go producer(dataCh, 5)
+	go producer(clk, dataCh, 5)
 	go consumer(dataCh, 1)
-	time.Sleep(time.Second)
+	clk.Sleep(time.Second)
 
 	/**
 	 * 4. Multiple consumers
@@ -136,11 +228,11 @@ func main() {
 	 */
 	log.Println("\n4. Multiple consumers")
 	workCh := make(chan int, 5)
-	go producer(workCh, 6)
+	go producer(clk, workCh, 6)
 	for i := 1; i <= 2; i++ {
 		go consumer(workCh, i)
 	}
-	time.Sleep(time.Second)
+	clk.Sleep(time.Second)
 
 	/**
 	 * 5. Bidirectional communication
@@ -163,7 +255,7 @@ func main() {
 	dataCh = make(chan int)
 	quitCh := make(chan bool)
 
-	go worker(dataCh, quitCh)
+	go worker(clk, dataCh, quitCh)
 
 	// Send some data
 	dataCh <- 1
@@ -171,7 +263,7 @@ func main() {
 
 	// Send quit signal
 	quitCh <- true
-	time.Sleep(100 * time.Millisecond)
+	clk.Sleep(100 * time.Millisecond)
 
 	/**
 	 * 7. Select with default
@@ -192,17 +284,65 @@ func main() {
 	 */
 	log.Println("\n8. Select with timeout")
 	resultCh := make(chan string)
+	abandoned := make(chan struct{})
 	go func() {
-		time.Sleep(2 * time.Second)
-		resultCh <- "Done"
+		select {
+		case <-clk.After(2 * time.Second): // Slow operation
+		case <-abandoned:
+			return
+		}
+		// Nobody may be receiving anymore, so don't block on the send forever
+		select {
+		case resultCh <- "Done":
+		case <-abandoned:
+		}
 	}()
 
 	select {
 	case result := <-resultCh:
 		log.Printf("Received result: %s\n", result)
-	case <-time.After(1 * time.Second):
+	case <-clk.After(1 * time.Second):
 		log.Println("Operation timed out")
 	}
+	close(abandoned) // Lets the sender give up instead of leaking
+
+	/**
+	 * 9. Pipeline stages
+	 * Generic stages from pkg/pipeline, connected by channels. Fan-out
+	 * means the squares may come out in any order, so they are summed.
+	 */
+	log.Println("\n9. Pipeline stages")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sum := 0
+	for batch := range evenSquares(ctx, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 2) {
+		log.Printf("Batch of %d squares\n", len(batch))
+		for _, n := range batch {
+			sum += n
+		}
+	}
+	log.Printf("Sum of even squares: %d\n", sum)
+
+	/**
+	 * 10. Publish/subscribe
+	 * A broker copies each message to every subscription whose pattern
+	 * matches the topic: "*" matches one segment, ">" everything below.
+	 */
+	log.Println("\n10. Publish/subscribe")
+	received, stats := broadcast([]string{"orders.eu.created", "orders.us.created", "users.signup", "orders.eu.shipped"})
+	for _, pattern := range []string{">", "orders.eu.*", "orders.>"} {
+		log.Printf("%s received %v\n", pattern, received[pattern])
+	}
+	log.Printf("Published %d, delivered %d, dropped %d\n", stats.Published, stats.Delivered, stats.Dropped)
+
+	/**
+	 * 11. Ring buffer
+	 * A full buffered channel can only make the sender wait. A ring
+	 * buffer can overwrite the oldest value instead, and count the loss.
+	 */
+	log.Println("\n11. Ring buffer")
+	kept, bufStats := latestReadings([]int{10, 20, 30, 40, 50, 60}, 4)
+	log.Printf("Kept %v, dropped %d, high-water mark %d of %d\n", kept, bufStats.Dropped, bufStats.HighWater, bufStats.Cap)
 
 	log.Println("Main: All done")
 }
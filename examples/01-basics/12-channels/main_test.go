@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/internal/testlog"
+	"go-by-example/pkg/clock"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+func TestProducer(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	ch := make(chan int)
+	go producer(clk, ch, 3)
+
+	for want := 1; want <= 3; want++ {
+		if got := <-ch; got != want {
+			t.Fatalf("got %d, want %d", got, want)
+		}
+		clk.BlockUntil(1) // Pause between items
+		clk.Advance(100 * time.Millisecond)
+	}
+
+	if _, ok := <-ch; ok {
+		t.Error("producer did not close the channel")
+	}
+}
+
+func TestWorker(t *testing.T) {
+	t.Run("Quit signal", func(t *testing.T) {
+		lines := testlog.Capture(t)
+		clk := clock.NewFake(epoch)
+		dataCh := make(chan int)
+		quitCh := make(chan bool)
+		go worker(clk, dataCh, quitCh)
+
+		dataCh <- 1
+		testlog.Expect(t, lines, "Worker received: 1")
+		dataCh <- 2
+		testlog.Expect(t, lines, "Worker received: 2")
+		quitCh <- true
+		testlog.Expect(t, lines, "Worker received quit signal")
+	})
+
+	t.Run("Idle timeout", func(t *testing.T) {
+		lines := testlog.Capture(t)
+		clk := clock.NewFake(epoch)
+		go worker(clk, make(chan int), make(chan bool))
+
+		clk.BlockUntil(1)
+		clk.Advance(499 * time.Millisecond)
+		testlog.ExpectNone(t, lines)
+		clk.Advance(time.Millisecond)
+		testlog.Expect(t, lines, "Worker timed out waiting for data")
+	})
+}
+
+func TestEvenSquares(t *testing.T) {
+	var got []int
+	for batch := range evenSquares(context.Background(), []int{1, 2, 3, 4, 5, 6, 7}, 2) {
+		if len(batch) > 2 {
+			t.Errorf("got batch %v, want at most 2 values", batch)
+		}
+		got = append(got, batch...)
+	}
+	slices.Sort(got)
+	if want := []int{4, 16, 36}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	received, stats := broadcast([]string{"orders.eu.created", "orders.us.created", "users.signup", "orders.eu.shipped"})
+
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{pattern: ">", expected: []string{"orders.eu.created", "orders.us.created", "users.signup", "orders.eu.shipped"}},
+		{pattern: "orders.eu.*", expected: []string{"orders.eu.created", "orders.eu.shipped"}},
+		{pattern: "orders.>", expected: []string{"orders.eu.shipped"}}, // Buffer of 1, drops the oldest
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := received[tt.pattern]; !slices.Equal(got, tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	if stats.Published != 4 || stats.Delivered != 9 || stats.Dropped != 2 {
+		t.Errorf("got %+v, want 4 published, 9 delivered and 2 dropped", stats)
+	}
+}
+
+func TestLatestReadings(t *testing.T) {
+	tests := []struct {
+		name     string
+		readings []int
+		expected []int
+		dropped  uint64
+	}{
+		{name: "fits", readings: []int{1, 2}, expected: []int{1, 2}, dropped: 0},
+		{name: "overflows", readings: []int{1, 2, 3, 4, 5}, expected: []int{3, 4, 5}, dropped: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, stats := latestReadings(tt.readings, 3)
+			if !slices.Equal(kept, tt.expected) {
+				t.Errorf("got %v, want %v", kept, tt.expected)
+			}
+			if stats.Dropped != tt.dropped || stats.HighWater != len(tt.expected) {
+				t.Errorf("got %+v, want %d dropped and high-water %d", stats, tt.dropped, len(tt.expected))
+			}
+		})
+	}
+}
+
+func TestMainNoLeaks(t *testing.T) {
+	leaktest.Check(t)
+	testlog.Discard(t)
+	main()
+}
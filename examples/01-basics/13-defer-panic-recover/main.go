@@ -57,7 +57,7 @@ func panicAndRecover() {
 
 	log.Println("Before panic")
 	panic("something went wrong")
-	log.Println("After panic") // This line never executes
+	log.Println("After panic") // This line would never execute
 }
 
 /**
@@ -3,6 +3,8 @@ package main
 import (
 	"log"
 	"time"
+
+	"go-by-example/pkg/clock"
 )
 
 /**
@@ -21,36 +23,42 @@ import (
  * - Operation timeouts
  * - Rate limiting
  * - Heartbeat signals
+ *
+ * Every function takes a clock.Clock instead of calling the time package
+ * directly. main passes the real clock; the tests pass a clock.Fake and
+ * advance it by hand, so they check ticks and timeouts without waiting.
  */
 
 /**
  * delayedOperation demonstrates basic timer usage
  * Shows how to execute code after a delay
+ * @param clk: clock used to create the timer
  */
-func delayedOperation() {
-	// This line creates a new timer that will send a signal on its channel after 2 seconds. The time.NewTimer function is used to create a timer that will fire once after the specified duration.
-	timer := time.NewTimer(2 * time.Second)
+func delayedOperation(clk clock.Clock) {
+	// This line creates a new timer that will send a signal on its channel after 2 seconds. clk.NewTimer (time.NewTimer on the real clock) is used to create a timer that will fire once after the specified duration.
+	timer := clk.NewTimer(2 * time.Second)
 	defer timer.Stop()
 
 	log.Println("Starting delayed operation")
 	//This line blocks the execution of the function until the timer fires. The <-timer.C expression waits for a value to be sent on the timer's channel, which happens after the specified delay (2 seconds in this case).
-	<-timer.C
+	<-timer.C()
 	log.Println("Delayed operation executed")
 }
 
 /**
  * periodicTask demonstrates ticker usage
  * Shows how to perform recurring tasks. A ticker is a mechanism that sends a signal on its channel at regular intervals, defined by the duration.
+ * @param clk: clock used to create the ticker
  * @param duration: interval between ticks
  * @param count: number of ticks to process
  */
-func periodicTask(duration time.Duration, count int) {
-	ticker := time.NewTicker(duration)
+func periodicTask(clk clock.Clock, duration time.Duration, count int) {
+	ticker := clk.NewTicker(duration)
 	defer ticker.Stop()
 
 	for i := 1; i <= count; i++ {
 		//<-ticker.C: This line blocks the execution until a signal is received on the ticker's channel, which happens at each interval defined by duration.
-		<-ticker.C
+		<-ticker.C()
 		log.Printf("Tick %d: Executing periodic task\n", i)
 	}
 }
@@ -58,21 +66,22 @@ func periodicTask(duration time.Duration, count int) {
 /**
  * timeoutOperation demonstrates timeout pattern
  * Shows how to limit operation duration
+ * @param clk: clock used for the operation and the timeout
  * @param timeout: maximum duration to wait
  */
-func timeoutOperation(timeout time.Duration) {
-	done := make(chan bool)
+func timeoutOperation(clk clock.Clock, timeout time.Duration) {
+	done := make(chan bool, 1) // Buffered so the goroutine can still exit after a timeout
 
 	go func() {
 		// Simulate long operation
-		time.Sleep(2 * time.Second)
+		clk.Sleep(2 * time.Second)
 		done <- true
 	}()
 
 	select {
 	case <-done:
 		log.Println("Operation completed successfully")
-	case <-time.After(timeout):
+	case <-clk.After(timeout):
 		log.Println("Operation timed out")
 	}
 }
@@ -80,71 +89,74 @@ func timeoutOperation(timeout time.Duration) {
 /**
  * timerReset demonstrates timer reset functionality
  * Shows how to reuse timers
+ * @param clk: clock used to create the timer
  */
-func timerReset() {
-	timer := time.NewTimer(1 * time.Second)
+func timerReset(clk clock.Clock) {
+	timer := clk.NewTimer(1 * time.Second)
 	defer timer.Stop()
 
 	log.Println("Starting timer")
 	timer.Reset(500 * time.Millisecond) // Reset to shorter duration
-	<-timer.C
+	log.Println("Timer reset to 500ms")
+	<-timer.C()
 	log.Println("Timer fired after reset")
 }
 
 func main() {
 	log.Println("=== Timers, Tickers, and Timeouts Examples ===")
+	clk := clock.New()
 
 	/**
 	 * 1. Basic timer usage
 	 * Shows one-time delayed execution
 	 */
 	log.Println("\n1. Basic timer usage")
-	delayedOperation()
+	delayedOperation(clk)
 
 	/**
 	 * 2. Periodic ticker
 	 * Demonstrates recurring tasks
 	 */
 	log.Println("\n2. Periodic ticker")
-	periodicTask(500*time.Millisecond, 3)
+	periodicTask(clk, 500*time.Millisecond, 3)
 
 	/**
 	 * 3. Operation timeout
 	 * Shows how to limit operation duration
 	 */
 	log.Println("\n3. Operation timeout")
-	timeoutOperation(1 * time.Second)
+	timeoutOperation(clk, 1*time.Second)
 
 	/**
 	 * 4. Timer reset
 	 * Demonstrates timer reuse
 	 */
 	log.Println("\n4. Timer reset")
-	timerReset()
+	timerReset(clk)
 
 	/**
 	 * 5. Multiple tickers
 	 * Shows handling multiple time sources
 	 */
 	log.Println("\n5. Multiple tickers")
-	ticker1 := time.NewTicker(500 * time.Millisecond)
-	ticker2 := time.NewTicker(800 * time.Millisecond)
+	ticker1 := clk.NewTicker(500 * time.Millisecond)
+	ticker2 := clk.NewTicker(800 * time.Millisecond)
 	defer ticker1.Stop()
 	defer ticker2.Stop()
 
 	done := make(chan bool)
 	go func() {
-		time.Sleep(2 * time.Second)
+		clk.Sleep(2 * time.Second)
 		done <- true
 	}()
 
 	count := 0
 	for {
 		select {
-		case <-ticker1.C:
+		case <-ticker1.C():
 			log.Println("Ticker 1 fired")
 			count++
-		case <-ticker2.C:
+		case <-ticker2.C():
 			log.Println("Ticker 2 fired")
 			count++
 		case <-done:
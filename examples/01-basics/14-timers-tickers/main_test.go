@@ -0,0 +1,119 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/internal/testlog"
+	"go-by-example/pkg/clock"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+func TestDelayedOperation(t *testing.T) {
+	lines := testlog.Capture(t)
+	clk := clock.NewFake(epoch)
+
+	done := make(chan struct{})
+	go func() {
+		delayedOperation(clk)
+		close(done)
+	}()
+
+	testlog.Expect(t, lines, "Starting delayed operation")
+	clk.Advance(1999 * time.Millisecond)
+	testlog.ExpectNone(t, lines)
+	clk.Advance(time.Millisecond)
+	testlog.Expect(t, lines, "Delayed operation executed")
+	<-done
+}
+
+func TestPeriodicTask(t *testing.T) {
+	lines := testlog.Capture(t)
+	clk := clock.NewFake(epoch)
+
+	done := make(chan struct{})
+	go func() {
+		periodicTask(clk, 500*time.Millisecond, 3)
+		close(done)
+	}()
+
+	clk.BlockUntil(1)
+	for _, want := range []string{
+		"Tick 1: Executing periodic task",
+		"Tick 2: Executing periodic task",
+		"Tick 3: Executing periodic task",
+	} {
+		clk.Advance(500 * time.Millisecond)
+		testlog.Expect(t, lines, want)
+	}
+	<-done
+
+	if clk.Waiters() != 0 {
+		t.Errorf("ticker was not stopped, %d waiters left", clk.Waiters())
+	}
+}
+
+func TestTimeoutOperation(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		advance  time.Duration
+		expected string
+	}{
+		{
+			name:     "Timeout fires first",
+			timeout:  1 * time.Second,
+			advance:  1 * time.Second,
+			expected: "Operation timed out",
+		},
+		{
+			name:     "Operation finishes first",
+			timeout:  3 * time.Second,
+			advance:  2 * time.Second,
+			expected: "Operation completed successfully",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := testlog.Capture(t)
+			clk := clock.NewFake(epoch)
+
+			done := make(chan struct{})
+			go func() {
+				timeoutOperation(clk, tt.timeout)
+				close(done)
+			}()
+
+			clk.BlockUntil(2) // The simulated operation and the timeout
+			clk.Advance(tt.advance)
+			testlog.Expect(t, lines, tt.expected)
+			<-done
+		})
+	}
+}
+
+func TestTimerReset(t *testing.T) {
+	lines := testlog.Capture(t)
+	clk := clock.NewFake(epoch)
+
+	done := make(chan struct{})
+	go func() {
+		timerReset(clk)
+		close(done)
+	}()
+
+	testlog.Expect(t, lines, "Starting timer")
+	testlog.Expect(t, lines, "Timer reset to 500ms")
+	clk.Advance(500 * time.Millisecond)
+	testlog.Expect(t, lines, "Timer fired after reset")
+	<-done
+}
+
+func TestMainNoLeaks(t *testing.T) {
+	leaktest.Check(t)
+	testlog.Discard(t)
+	main()
+}
@@ -1,21 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
-	"sync"
-	"sync/atomic"
 	"time"
+
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/pool"
+	"go-by-example/pkg/ratelimit"
 )
 
 /**
- * Worker Pools, WaitGroups, and Rate Limiting in Go demonstrate concurrent task processing patterns.
+ * Worker Pools and Rate Limiting in Go demonstrate concurrent task processing patterns.
  *
  * Key concepts:
  * - Worker Pool: Group of goroutines processing tasks concurrently
- * - WaitGroup: Synchronization for multiple goroutines
- * - Rate Limiting: Controlling the speed of operations
+ * - Rate Limiting: Controlling the speed of operations, with room for bursts
  * - Task Distribution: Fan-out pattern
  * - Result Collection: Fan-in pattern
+ * - Autoscaling: Growing and shrinking the pool with the load
+ * - Latency Percentiles: p50/p90/p99 of queue wait and processing time
+ * - Cancellation: Stopping workers that still have queued tasks
  *
  * Common use cases:
  * - Parallel processing
@@ -23,207 +30,258 @@ import (
  * - API rate limiting
  * - Resource management
  * - Load balancing
+ *
+ * The channels, goroutines and atomic counters behind a worker pool live in
+ * pkg/pool. A pool.Pool takes a func(context.Context, In) (Out, error), runs
+ * it on workers fed from a bounded queue and reports one Result per task,
+ * so this example only has to say what a task does. Every pool keeps its
+ * own statistics, so separate runs are never mixed together.
+ *
+ * Rate limiting uses pkg/ratelimit. One token bucket is shared by all
+ * workers, so it limits the pool as a whole rather than each worker, and
+ * lets a short burst through before settling to the steady rate.
+ *
+ * The examples in 15b-task-processing go further with pkg/pool, one
+ * feature at a time.
+ *
+ * Tasks wait and measure time through a clock.Clock, so tests can check
+ * rate limiting and statistics with a clock.Fake.
  */
 
 /**
  * Task represents a unit of work
  */
 type Task struct {
-	ID     int
-	Result int
+	ID int
 }
 
+// errInvalidTask is returned for tasks that fail validation
+var errInvalidTask = errors.New("invalid task")
+
 /**
- * WorkerStats tracks worker pool statistics
+ * process returns the function the workers run for each task
+ * @param clk: clock used to simulate processing time
+ * @param delay: how long each task takes
+ * @param compute: the work itself, applied to the task ID
+ * @return: a pool.Func that stops early if the pool is cancelled
  */
-type WorkerStats struct {
-	tasksProcessed uint64 // Atomic counter for processed tasks
-	totalTime      int64  // Atomic counter for total processing time (nanoseconds)
+func process(clk clock.Clock, delay time.Duration, compute func(int) int) pool.Func[Task, int] {
+	return func(ctx context.Context, task Task) (int, error) {
+		select {
+		case <-clk.After(delay):
+			return compute(task.ID), nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
 }
 
 /**
- * worker processes tasks from the task queue
- * @param id: worker identifier
- * @param tasks: channel for receiving tasks
- * @param results: channel for sending results
- * @param stats: pointer to WorkerStats for tracking statistics
- * @param wg: WaitGroup for synchronization
+ * rateLimited makes every call to fn wait for the limiter first.
+ * All workers share the limiter, so it caps the pool as a whole.
+ * @param limiter: limiter that grants one task per event
+ * @param fn: the function to rate limit
  */
-func worker(id int, tasks <-chan Task, results chan<- Task, stats *WorkerStats, wg *sync.WaitGroup) {
-	defer wg.Done()
-
-	for task := range tasks {
-		start := time.Now()
-
-		// Simulate processing time
-		time.Sleep(100 * time.Millisecond)
-		task.Result = task.ID * 2 // Simple computation
-
-		// Update atomic counters
-		atomic.AddUint64(&stats.tasksProcessed, 1)
-		atomic.AddInt64(&stats.totalTime, time.Since(start).Nanoseconds())
-
-		log.Printf("Worker %d processed task %d (Total: %d)\n",
-			id,
-			task.ID,
-			atomic.LoadUint64(&stats.tasksProcessed))
-
-		results <- task
+func rateLimited(limiter *ratelimit.Limiter, fn pool.Func[Task, int]) pool.Func[Task, int] {
+	return func(ctx context.Context, task Task) (int, error) {
+		if err := limiter.Wait(ctx); err != nil { // Wait for rate limit
+			return 0, err
+		}
+		return fn(ctx, task)
 	}
 }
 
 /**
- * rateLimitedWorker demonstrates rate limiting with time.Ticker
- * @param id: worker identifier
- * @param tasks: channel for receiving tasks
- * @param results: channel for sending results
- * @param rate: maximum operations per second
- * @param stats: pointer to WorkerStats for tracking statistics
- * @param wg: WaitGroup for synchronization
+ * submitTasks queues tasks 1..n and then closes the pool
+ * @param ctx: stops submitting early when done
+ * @param p: pool to submit to
+ * @param n: number of tasks
  */
-func rateLimitedWorker(id int, tasks <-chan Task, results chan<- Task, rate int, stats *WorkerStats, wg *sync.WaitGroup) {
-	defer wg.Done()
-
-	// Create rate limiter
-	limiter := time.NewTicker(time.Second / time.Duration(rate))
-	defer limiter.Stop()
-
-	for task := range tasks {
-		<-limiter.C // Wait for rate limit
-		start := time.Now()
-
-		// Process task
-		time.Sleep(50 * time.Millisecond)
-		task.Result = task.ID * 2
+func submitTasks(ctx context.Context, p *pool.Pool[Task, int], n int) {
+	defer p.Close()
+	for i := 1; i <= n; i++ {
+		if err := p.Submit(ctx, Task{ID: i}); err != nil {
+			log.Printf("Submit task %d: %v\n", i, err)
+			return
+		}
+	}
+}
 
-		// Update atomic counters
-		atomic.AddUint64(&stats.tasksProcessed, 1)
-		atomic.AddInt64(&stats.totalTime, time.Since(start).Nanoseconds())
+func double(n int) int { return n * 2 }
 
-		log.Printf("Rate-limited worker %d processed task %d (Total: %d)\n",
-			id,
-			task.ID,
-			atomic.LoadUint64(&stats.tasksProcessed))
+// ms formats a duration as fractional milliseconds
+func ms(d time.Duration) string {
+	return fmt.Sprintf("%.2f ms", float64(d)/float64(time.Millisecond))
+}
 
-		results <- task
+/**
+ * logMetrics prints latency percentiles of a pool, overall and per worker
+ * @param m: the pool's metrics
+ */
+func logMetrics(m pool.Metrics) {
+	log.Printf("Queue wait - p50: %s, p90: %s, p99: %s, max: %s\n",
+		ms(m.Wait.Quantile(0.5)), ms(m.Wait.Quantile(0.9)), ms(m.Wait.Quantile(0.99)), ms(m.Wait.Max))
+	log.Printf("Processing - p50: %s, p90: %s, p99: %s, max: %s\n",
+		ms(m.Run.Quantile(0.5)), ms(m.Run.Quantile(0.9)), ms(m.Run.Quantile(0.99)), ms(m.Run.Max))
+	log.Printf("Throughput: %.1f tasks/s\n", m.Throughput)
+	for _, w := range m.Workers {
+		log.Printf("  Worker %d: %d tasks, p50 processing %s\n", w.ID, w.Completed+w.Failed, ms(w.Run.Quantile(0.5)))
 	}
 }
 
 func main() {
-	log.Println("=== Worker Pools, WaitGroups, and Rate Limiting Examples ===")
+	log.Println("=== Worker Pools and Rate Limiting Examples ===")
+	clk := clock.New()
+	ctx := context.Background()
 
 	/**
-	 * 1. Basic Worker Pool with Atomic Counters
+	 * 1. Basic Worker Pool
+	 * Workers share a bounded queue; the pool counts tasks and busy time
 	 */
-	log.Println("\n1. Basic Worker Pool with Atomic Counters")
+	log.Println("\n1. Basic Worker Pool")
 	numWorkers := 3
 	numTasks := 10
 
-	tasks := make(chan Task, numTasks)
-	results := make(chan Task, numTasks)
-	var wg sync.WaitGroup
-	stats := &WorkerStats{}
+	basic := pool.New(ctx, process(clk, 100*time.Millisecond, double), pool.Options{
+		Workers:   numWorkers,
+		QueueSize: numTasks,
+		Clock:     clk,
+	})
+	go submitTasks(ctx, basic, numTasks)
 
-	// Start workers
-	for i := 1; i <= numWorkers; i++ {
-		wg.Add(1)
-		go worker(i, tasks, results, stats, &wg)
+	// Process results as they arrive (fan-in)
+	for res := range basic.Results() {
+		log.Printf("Worker %d processed task %d: %d\n", res.Worker, res.Input.ID, res.Value)
 	}
-
-	// Send tasks
-	go func() {
-		for i := 1; i <= numTasks; i++ {
-			tasks <- Task{ID: i}
-		}
-		close(tasks)
-	}()
-
-	// Collect results
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
-
-	// Process results and print statistics
-	for result := range results {
-		log.Printf("Got result for task %d: %d\n", result.ID, result.Result)
+	if err := basic.Wait(); err != nil {
+		log.Printf("Pool failed: %v\n", err)
 	}
 
-	avgTime := float64(atomic.LoadInt64(&stats.totalTime)) / float64(atomic.LoadUint64(&stats.tasksProcessed))
+	stats := basic.Stats()
+	avgTime := stats.Busy / time.Duration(stats.Completed)
 	log.Printf("Statistics - Tasks Processed: %d, Average Time: %.2f ms\n",
-		atomic.LoadUint64(&stats.tasksProcessed),
-		avgTime/1e6)
+		stats.Completed,
+		float64(avgTime)/float64(time.Millisecond))
+	logMetrics(basic.Metrics())
 
 	/**
 	 * 2. Rate Limited Worker Pool
-	 * Demonstrates controlled processing speed
+	 * Demonstrates controlled processing speed. The first few tasks go
+	 * through at once, then the rest follow five per second.
 	 */
 	log.Println("\n2. Rate Limited Worker Pool")
-	rateLimitedTasks := make(chan Task, numTasks)
-	rateLimitedResults := make(chan Task, numTasks)
-	var rateLimitedWg sync.WaitGroup
-
-	// Start rate-limited workers
-	operationsPerSecond := 2
-	for i := 1; i <= numWorkers; i++ {
-		rateLimitedWg.Add(1)
-		go rateLimitedWorker(i, rateLimitedTasks, rateLimitedResults, operationsPerSecond, stats, &rateLimitedWg)
+	operationsPerSecond := 5.0
+	burst := 3
+	limiter := ratelimit.NewTokenBucket(clk, operationsPerSecond, burst)
+
+	limited := pool.New(ctx, rateLimited(limiter, process(clk, 50*time.Millisecond, double)), pool.Options{
+		Workers:   numWorkers,
+		QueueSize: numTasks,
+		Clock:     clk,
+	})
+	go submitTasks(ctx, limited, numTasks)
+
+	for res := range limited.Results() {
+		log.Printf("Rate-limited worker %d processed task %d: %d\n", res.Worker, res.Input.ID, res.Value)
 	}
-
-	// Send tasks
-	go func() {
-		for i := 1; i <= numTasks; i++ {
-			rateLimitedTasks <- Task{ID: i}
-		}
-		close(rateLimitedTasks)
-	}()
-
-	// Collect results
-	go func() {
-		rateLimitedWg.Wait()
-		close(rateLimitedResults)
-	}()
-
-	// Process results
-	for result := range rateLimitedResults {
-		log.Printf("Got rate-limited result for task %d: %d\n", result.ID, result.Result)
+	if err := limited.Wait(); err != nil {
+		log.Printf("Pool failed: %v\n", err)
 	}
 
+	// Waiting for the limiter counts as processing time, and tasks queue
+	// up behind it
+	logMetrics(limited.Metrics())
+
 	/**
 	 * 3. Dynamic Worker Pool
-	 * Shows how to adjust pool size based on load
+	 * Shows how to adjust pool size based on load. The pool starts with one
+	 * worker and adds one per check, up to four, while more than two tasks
+	 * are waiting or tasks wait too long on average. Workers that then sit
+	 * idle are removed again, down to the one we started with.
 	 */
 	log.Println("\n3. Dynamic Worker Pool")
-	dynamicTasks := make(chan Task, numTasks)
-	dynamicResults := make(chan Task, numTasks)
-	var dynamicWg sync.WaitGroup
-
-	// Start initial workers
-	initialWorkers := 2
-	for i := 1; i <= initialWorkers; i++ {
-		dynamicWg.Add(1)
-		go worker(i, dynamicTasks, dynamicResults, stats, &dynamicWg)
+	scaled := make(chan pool.ScaleEvent, 2*numTasks)
+	dynamic := pool.New(ctx, process(clk, 100*time.Millisecond, double), pool.Options{
+		Workers:   1,
+		QueueSize: numTasks,
+		Clock:     clk,
+		Autoscale: &pool.Autoscale{
+			Max:         4,
+			QueueDepth:  2,
+			MaxWait:     200 * time.Millisecond,
+			Interval:    50 * time.Millisecond,
+			IdleTimeout: 300 * time.Millisecond,
+			OnScale: func(ev pool.ScaleEvent) {
+				log.Printf("Pool %v\n", ev)
+				scaled <- ev
+			},
+		},
+	})
+
+	// Send a burst of tasks, but keep the pool open so it can shrink again
+	for i := 1; i <= numTasks; i++ {
+		if err := dynamic.Submit(ctx, Task{ID: i}); err != nil {
+			log.Printf("Submit task %d: %v\n", i, err)
+		}
+	}
+	for range numTasks {
+		res := <-dynamic.Results()
+		log.Printf("Worker %d processed dynamic task %d: %d\n", res.Worker, res.Input.ID, res.Value)
 	}
 
-	// Add more workers based on load
-	go func() {
-		if len(dynamicTasks) > 5 { // High load threshold
-			dynamicWg.Add(1)
-			go worker(initialWorkers+1, dynamicTasks, dynamicResults, stats, &dynamicWg)
+	// Wait until the idle workers have been removed
+	for dynamic.Stats().Workers > 1 {
+		<-scaled
+	}
+	log.Printf("Back to %d worker after the burst\n", dynamic.Stats().Workers)
+	dynamic.Close()
+	if err := dynamic.Wait(); err != nil {
+		log.Printf("Pool failed: %v\n", err)
+	}
+
+	/**
+	 * 4. Errors and Cancellation
+	 * Each task reports its own error, and cancelling the context stops
+	 * the workers even though tasks are still queued
+	 */
+	log.Println("\n4. Errors and Cancellation")
+	cancelCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	work := process(clk, 100*time.Millisecond, double)
+	checked := pool.New(cancelCtx, func(ctx context.Context, task Task) (int, error) {
+		switch task.ID {
+		case 2:
+			return 0, fmt.Errorf("task %d: %w", task.ID, errInvalidTask)
+		case 3:
+			cancel() // As if the user pressed Ctrl+C while task 3 runs
 		}
-	}()
+		return work(ctx, task)
+	}, pool.Options{Workers: 1, QueueSize: 6, Clock: clk})
 
-	// Send and process tasks
-	for i := 1; i <= 5; i++ {
-		dynamicTasks <- Task{ID: i}
+	// Queue everything up front; the queue has room for all six tasks
+	for i := 1; i <= 6; i++ {
+		if err := checked.Submit(cancelCtx, Task{ID: i}); err != nil {
+			log.Printf("Submit task %d: %v\n", i, err)
+		}
 	}
-	close(dynamicTasks)
-	dynamicWg.Wait()
-	close(dynamicResults)
-
-	for result := range dynamicResults {
-		log.Printf("Got dynamic result for task %d: %d\n", result.ID, result.Result)
+	checked.Close()
+
+	for res := range checked.Results() {
+		switch {
+		case res.Err != nil && res.Worker == 0:
+			log.Printf("Task %d skipped: %v\n", res.Input.ID, res.Err)
+		case res.Err != nil:
+			log.Printf("Task %d failed: %v\n", res.Input.ID, res.Err)
+		default:
+			log.Printf("Task %d: %d\n", res.Input.ID, res.Value)
+		}
+	}
+	if err := checked.Wait(); err != nil {
+		log.Printf("Pool stopped early: %v\n", err)
 	}
+	stats = checked.Stats()
+	log.Printf("Statistics - Completed: %d, Failed: %d, Canceled: %d\n", stats.Completed, stats.Failed, stats.Canceled)
 
 	log.Println("Main: All done")
 }
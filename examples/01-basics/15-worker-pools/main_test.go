@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/internal/testlog"
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/pool"
+	"go-by-example/pkg/ratelimit"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+func TestProcess(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	fn := process(clk, 100*time.Millisecond, double)
+
+	done := make(chan int)
+	go func() {
+		out, err := fn(context.Background(), Task{ID: 21})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- out
+	}()
+
+	clk.BlockUntil(1) // Simulated processing time
+	clk.Advance(99 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("task finished before its processing time")
+	default:
+	}
+	clk.Advance(time.Millisecond)
+	if got := <-done; got != 42 {
+		t.Errorf("got %d, want 42", got)
+	}
+
+	// A cancelled context stops the task without advancing the clock
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := fn(ctx, Task{ID: 1}); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestRateLimitedPool(t *testing.T) {
+	testlog.Capture(t)
+	clk := clock.NewFake(epoch)
+	limiter := ratelimit.NewTokenBucket(clk, 2, 1) // One task every 500ms
+
+	numTasks := 3
+	p := pool.New(context.Background(), rateLimited(limiter, process(clk, 50*time.Millisecond, double)), pool.Options{
+		Workers:   3,
+		QueueSize: numTasks,
+		Clock:     clk,
+	})
+	go submitTasks(context.Background(), p, numTasks)
+
+	for i := range numTasks {
+		// Nothing is processed until the shared limiter allows it every
+		// 500ms, however many workers are idle
+		start := epoch.Add(time.Duration(i) * 500 * time.Millisecond)
+		if wait := start.Sub(clk.Now()); wait > 0 {
+			clk.Advance(wait - time.Millisecond)
+			select {
+			case res := <-p.Results():
+				t.Fatalf("task %d processed before the rate limit allowed it", res.Input.ID)
+			default:
+			}
+			clk.Advance(time.Millisecond)
+		}
+
+		clk.BlockUntil(numTasks - i) // Processing time plus the workers still waiting
+		clk.Advance(50 * time.Millisecond)
+		if res := <-p.Results(); res.Err != nil || res.Value != res.Input.ID*2 {
+			t.Fatalf("got %+v, want task result %d", res, res.Input.ID*2)
+		}
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := p.Stats()
+	if stats.Completed != uint64(numTasks) {
+		t.Errorf("got %d tasks processed, want %d", stats.Completed, numTasks)
+	}
+	if want := time.Duration(numTasks) * 50 * time.Millisecond; stats.Busy < want {
+		t.Errorf("got busy time %v, want at least %v", stats.Busy, want)
+	}
+}
+
+func TestSubmitTasks(t *testing.T) {
+	logs := testlog.Capture(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := pool.New(context.Background(), func(ctx context.Context, task Task) (int, error) {
+		return task.ID, nil
+	}, pool.Options{Workers: 1})
+	submitTasks(ctx, p, 3)
+
+	// The pool is closed even though submitting stopped at the first task
+	for res := range p.Results() {
+		t.Errorf("got result for task %d, want none", res.Input.ID)
+	}
+	testlog.Expect(t, logs, "Submit task 1: context canceled")
+}
+
+func TestLogMetrics(t *testing.T) {
+	logs := testlog.Capture(t)
+	clk := clock.NewFake(epoch)
+
+	p := pool.New(context.Background(), process(clk, 100*time.Millisecond, double), pool.Options{
+		Workers:   1,
+		QueueSize: 2,
+		Clock:     clk,
+	})
+	p.Submit(context.Background(), Task{ID: 1})
+	p.Submit(context.Background(), Task{ID: 2})
+	p.Close()
+	for range 2 {
+		clk.BlockUntil(1)
+		clk.Advance(100 * time.Millisecond)
+		<-p.Results()
+	}
+
+	// Task 2 waits for task 1, so half the tasks wait 100ms
+	logMetrics(p.Metrics())
+	testlog.Expect(t, logs, "Queue wait - p50: 0.00 ms, p90: 100.00 ms, p99: 100.00 ms, max: 100.00 ms")
+	testlog.Expect(t, logs, "Processing - p50: 100.00 ms, p90: 100.00 ms, p99: 100.00 ms, max: 100.00 ms")
+	testlog.Expect(t, logs, "Throughput: 10.0 tasks/s")
+	testlog.Expect(t, logs, "  Worker 1: 2 tasks, p50 processing 100.00 ms")
+	testlog.ExpectNone(t, logs)
+}
+
+func TestMainNoLeaks(t *testing.T) {
+	leaktest.Check(t)
+	testlog.Discard(t)
+	main()
+}
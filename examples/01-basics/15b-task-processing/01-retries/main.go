@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/pool"
+)
+
+/**
+ * Some tasks fail only for a moment: a service is overloaded, a
+ * connection drops. pkg/pool can run them again with exponential backoff,
+ * and keeps the tasks that fail for good as dead letters instead of
+ * dropping them.
+ *
+ * Key concepts:
+ * - Retries: Running failed tasks again with exponential backoff
+ * - Jitter: Spreading retries out so they don't all hit at once
+ * - Retryable errors: Only retrying failures that may go away
+ * - Dead Letters: Keeping tasks that failed for good for later inspection
+ *
+ * Common use cases:
+ * - Calling flaky or overloaded services
+ * - Batch jobs that must not stop at the first bad record
+ * - Inspecting and replaying failed tasks
+ *
+ * Tasks and backoff wait through a clock.Clock, so tests can run them
+ * with a clock.Fake.
+ */
+
+// Task represents a unit of work
+type Task struct {
+	ID int
+}
+
+// errInvalidTask is returned for tasks that fail validation
+var errInvalidTask = errors.New("invalid task")
+
+// errTemporary is returned by tasks that may succeed when tried again
+var errTemporary = errors.New("temporary failure")
+
+/**
+ * process returns the function the workers run for each task
+ * @param clk: clock used to simulate processing time
+ * @param delay: how long each task takes
+ * @return: a pool.Func that doubles the task ID, and stops early if the pool is cancelled
+ */
+func process(clk clock.Clock, delay time.Duration) pool.Func[Task, int] {
+	return func(ctx context.Context, task Task) (int, error) {
+		select {
+		case <-clk.After(delay):
+			return task.ID * 2, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+/**
+ * submitTasks queues tasks 1..n and then closes the pool
+ * @param ctx: stops submitting early when done
+ * @param p: pool to submit to
+ * @param n: number of tasks
+ */
+func submitTasks(ctx context.Context, p *pool.Pool[Task, int], n int) {
+	defer p.Close()
+	for i := 1; i <= n; i++ {
+		if err := p.Submit(ctx, Task{ID: i}); err != nil {
+			log.Printf("Submit task %d: %v\n", i, err)
+			return
+		}
+	}
+}
+
+/**
+ * flaky wraps fn so that task n fails with errTemporary on its first n-1
+ * attempts, like a call to an overloaded service. Tasks with an ID above
+ * maxID fail validation, which no retry can fix.
+ * @param maxID: highest valid task ID
+ * @param fn: the function to run once an attempt gets through
+ */
+func flaky(maxID int, fn pool.Func[Task, int]) pool.Func[Task, int] {
+	return func(ctx context.Context, task Task) (int, error) {
+		if task.ID > maxID {
+			return 0, fmt.Errorf("task %d: %w", task.ID, errInvalidTask)
+		}
+		if attempt := pool.AttemptFromContext(ctx); attempt < task.ID {
+			return 0, fmt.Errorf("task %d attempt %d: %w", task.ID, attempt, errTemporary)
+		}
+		return fn(ctx, task)
+	}
+}
+
+func main() {
+	log.Println("=== Retries Examples ===")
+	clk := clock.New()
+	ctx := context.Background()
+
+	/**
+	 * 1. Retries and Dead Letters
+	 * Temporary failures are retried up to three attempts in total, waiting
+	 * 50ms, then 100ms, with some jitter. Tasks that still fail, or fail
+	 * with an error that isn't worth retrying, end up as dead letters.
+	 */
+	log.Println("\n1. Retries and Dead Letters")
+	retrying := pool.New(ctx, flaky(4, process(clk, 10*time.Millisecond)), pool.Options{
+		Workers:   2,
+		QueueSize: 5,
+		Clock:     clk,
+		Retry: &pool.RetryPolicy{
+			MaxAttempts: 3,
+			Initial:     50 * time.Millisecond,
+			Jitter:      0.2,
+			Retryable:   pool.RetryIs(errTemporary),
+		},
+	})
+	go submitTasks(ctx, retrying, 5)
+
+	for res := range retrying.Results() {
+		if res.Err != nil {
+			log.Printf("Task %d failed: %v\n", res.Input.ID, res.Err)
+			continue
+		}
+		log.Printf("Task %d: %d after %d attempts\n", res.Input.ID, res.Value, len(res.Attempts))
+	}
+	if err := retrying.Wait(); err != nil {
+		log.Printf("Pool failed: %v\n", err)
+	}
+
+	/**
+	 * 2. Inspecting Dead Letters
+	 * Every dead letter keeps the error of each attempt, so it is clear
+	 * why the task gave up
+	 */
+	log.Println("\n2. Inspecting Dead Letters")
+	for _, dead := range retrying.DeadLetters().List() {
+		log.Printf("Dead letter: task %d after %d attempts\n", dead.Input.ID, len(dead.Attempts))
+		for _, attempt := range dead.Attempts {
+			log.Printf("Dead letter: task %d attempt %d: %v\n", dead.Input.ID, attempt.Number, attempt.Err)
+		}
+	}
+	stats := retrying.Stats()
+	log.Printf("Statistics - Completed: %d, Failed: %d, Retried: %d, Dead: %d\n", stats.Completed, stats.Failed, stats.Retried, stats.Dead)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/internal/testlog"
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/pool"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+func TestProcess(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	done := make(chan int)
+	go func() {
+		out, err := process(clk, 10*time.Millisecond)(context.Background(), Task{ID: 21})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- out
+	}()
+
+	clk.BlockUntil(1)
+	clk.Advance(10 * time.Millisecond)
+	if got := <-done; got != 42 {
+		t.Errorf("got %d, want 42", got)
+	}
+}
+
+func TestFlaky(t *testing.T) {
+	fn := flaky(3, func(ctx context.Context, task Task) (int, error) { return task.ID, nil })
+	p := pool.New(context.Background(), fn, pool.Options{
+		Workers:   1,
+		QueueSize: 4,
+		Retry:     &pool.RetryPolicy{MaxAttempts: 2, Retryable: pool.RetryIs(errTemporary)},
+	})
+	go submitTasks(context.Background(), p, 4)
+
+	tests := []struct {
+		name     string
+		attempts int
+		err      error
+	}{
+		{name: "task 1 succeeds at once", attempts: 1},
+		{name: "task 2 succeeds on retry", attempts: 2},
+		{name: "task 3 runs out of attempts", attempts: 2, err: errTemporary},
+		{name: "task 4 is not retried", attempts: 1, err: errInvalidTask},
+	}
+	for _, tt := range tests {
+		res := <-p.Results()
+		t.Run(tt.name, func(t *testing.T) {
+			if len(res.Attempts) != tt.attempts {
+				t.Errorf("got %d attempts, want %d", len(res.Attempts), tt.attempts)
+			}
+			if tt.err == nil && res.Err != nil || !errors.Is(res.Err, tt.err) {
+				t.Errorf("got error %v, want %v", res.Err, tt.err)
+			}
+		})
+	}
+
+	if got := p.DeadLetters().Len(); got != 2 {
+		t.Errorf("got %d dead letters, want 2", got)
+	}
+}
+
+func TestMainNoLeaks(t *testing.T) {
+	leaktest.Check(t)
+	testlog.Discard(t)
+	main()
+}
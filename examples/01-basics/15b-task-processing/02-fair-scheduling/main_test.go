@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/internal/testlog"
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/pool"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+func TestFairSchedule(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	fair := pool.NewFairQueue(classify, pool.FairOptions{Aging: time.Second, Clock: clk})
+	push := func(task Task) {
+		fair.TryPush(pool.Entry[Task]{Value: task, Enqueued: clk.Now()})
+	}
+
+	push(Task{ID: 1, Tenant: "batch"})
+	push(Task{ID: 2, Tenant: "batch"})
+	clk.Advance(3 * time.Second) // Both batch tasks age to priority 3
+	push(Task{ID: 3, Tenant: "web", Priority: 2})
+	push(Task{ID: 4, Tenant: "web", Priority: 4})
+
+	p := pool.NewWithQueue(context.Background(), func(ctx context.Context, task Task) (int, error) {
+		return task.ID, nil
+	}, fair, pool.Options{Workers: 1, Clock: clk})
+	p.Close()
+
+	var order []int
+	for res := range p.Results() {
+		order = append(order, res.Value)
+	}
+	if want := []int{4, 1, 2, 3}; !slices.Equal(order, want) {
+		t.Errorf("got order %v, want %v", order, want)
+	}
+}
+
+func TestMainNoLeaks(t *testing.T) {
+	leaktest.Check(t)
+	testlog.Discard(t)
+	main()
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/pool"
+	"go-by-example/pkg/wal"
+)
+
+/**
+ * Tasks queued in memory are lost when the process crashes. A durable
+ * queue from pkg/wal appends every task to a log on disk before it is
+ * queued, and a task leaves the log only when it is acknowledged, so a
+ * crash loses nothing: reopening the queue replays the log and hands out
+ * the unfinished tasks again. The queue can feed a pool.Pool like any
+ * other queue.
+ *
+ * Key concepts:
+ * - Write-ahead log: Recording a change on disk before making it
+ * - Acknowledgements: Removing a task only once it is done
+ * - Crash recovery: Replaying the log and discarding a torn last record
+ * - Compaction: Rewriting the log with only what is still pending
+ *
+ * Common use cases:
+ * - Background jobs that must survive restarts
+ * - Outboxes for messages that must be delivered at least once
+ * - Local buffering while a downstream service is unavailable
+ *
+ * Tasks wait through a clock.Clock, so tests can run them with a
+ * clock.Fake.
+ */
+
+// Task represents a unit of work
+type Task struct {
+	ID int
+}
+
+// errInvalidTask is returned for tasks that fail validation
+var errInvalidTask = errors.New("invalid task")
+
+/**
+ * process returns the function the workers run for each task
+ * @param clk: clock used to simulate processing time
+ * @param delay: how long each task takes
+ * @return: a pool.Func that doubles the task ID, and stops early if the pool is cancelled
+ */
+func process(clk clock.Clock, delay time.Duration) pool.Func[Task, int] {
+	return func(ctx context.Context, task Task) (int, error) {
+		select {
+		case <-clk.After(delay):
+			return task.ID * 2, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+/**
+ * runDurable runs every pending task of a durable queue on a pool and
+ * acknowledges the ones that succeed. Failed tasks stay in the log and are
+ * handed out again after their visibility timeout or the next restart.
+ * @param ctx: cancels the pool
+ * @param q: queue to take tasks from
+ * @param fn: the function to run for each task
+ * @return: number of tasks acknowledged
+ */
+func runDurable(ctx context.Context, q *wal.Queue[Task], fn pool.Func[Task, int]) int {
+	run := func(ctx context.Context, job wal.Job[Task]) (int, error) {
+		return fn(ctx, job.Value)
+	}
+	p := pool.NewWithQueue(ctx, run, q.Source(), pool.Options{Workers: 2})
+	p.Close() // Take what is pending, but nothing submitted later
+
+	acked := 0
+	for res := range p.Results() {
+		if res.Err != nil {
+			log.Printf("Task %d failed, left in the log: %v\n", res.Input.Value.ID, res.Err)
+			continue
+		}
+		if err := q.Ack(res.Input.ID); err != nil {
+			log.Printf("Ack task %d: %v\n", res.Input.Value.ID, err)
+			continue
+		}
+		log.Printf("Task %d: %d (delivery %d)\n", res.Input.Value.ID, res.Value, res.Input.Deliveries)
+		acked++
+	}
+	if err := p.Wait(); err != nil {
+		log.Printf("Pool failed: %v\n", err)
+	}
+	return acked
+}
+
+/**
+ * crash takes two tasks from a new queue and acknowledges only the first,
+ * then closes the queue as if the process had died
+ * @param ctx: parent context of Dequeue
+ * @param path: file of the log
+ * @param n: number of tasks to enqueue first
+ * @return: an error if the queue failed
+ */
+func crash(ctx context.Context, path string, n int) error {
+	q, err := wal.Open[Task](path, wal.Options{})
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+
+	for i := 1; i <= n; i++ {
+		if _, err := q.Enqueue(Task{ID: i}); err != nil {
+			return err
+		}
+	}
+	first, err := q.Dequeue(ctx)
+	if err != nil {
+		return err
+	}
+	if err := q.Ack(first.ID); err != nil {
+		return err
+	}
+	inFlight, err := q.Dequeue(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("First run: finished task %d, crashed during task %d\n", first.Value.ID, inFlight.Value.ID)
+	return nil
+}
+
+/**
+ * tear appends the first bytes of a record to the log, like a write that
+ * was cut off by the crash
+ * @param path: file of the log
+ * @return: an error if the file couldn't be written
+ */
+func tear(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(wal.AppendRecord(nil, []byte("torn record"))[:10]); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func main() {
+	log.Println("=== Durable Queue Examples ===")
+	clk := clock.New()
+	ctx := context.Background()
+
+	/**
+	 * 1. Crash and recovery
+	 * Five tasks go into a queue backed by a write-ahead log. A first run
+	 * takes two of them and acknowledges only one before it "crashes",
+	 * leaving half a record at the end of the log like a write that was cut
+	 * off. Reopening the queue drops the torn record, and a pool finishes
+	 * the four unacknowledged tasks, including the one that was in flight.
+	 */
+	log.Println("\n1. Crash and recovery")
+	dir, err := os.MkdirTemp("", "durable-queue")
+	if err != nil {
+		log.Printf("Create log directory: %v\n", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tasks.wal")
+
+	if err := crash(ctx, path, 5); err != nil {
+		log.Printf("First run: %v\n", err)
+		return
+	}
+	if err := tear(path); err != nil {
+		log.Printf("Tear log: %v\n", err)
+		return
+	}
+
+	durable, err := wal.Open[Task](path, wal.Options{})
+	if err != nil {
+		log.Printf("Reopen queue: %v\n", err)
+		return
+	}
+	defer durable.Close()
+	recovery := durable.Recovery()
+	log.Printf("Replayed %d records, %d tasks pending, discarded %d bytes: %v\n",
+		recovery.Records, recovery.Pending, recovery.Discarded, recovery.Err)
+
+	acked := runDurable(ctx, durable, process(clk, 10*time.Millisecond))
+	log.Printf("Second run: acknowledged %d tasks\n", acked)
+
+	/**
+	 * 2. Compaction
+	 * Acknowledged tasks still take up room in the log. Compact rewrites it
+	 * with only what is pending: nothing, here.
+	 */
+	log.Println("\n2. Compaction")
+	before := durable.Stats().LogSize
+	if err := durable.Compact(); err != nil {
+		log.Printf("Compact: %v\n", err)
+	}
+	log.Printf("Log compacted from %d to %d bytes\n", before, durable.Stats().LogSize)
+}
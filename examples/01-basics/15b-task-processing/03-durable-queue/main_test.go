@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/internal/testlog"
+	"go-by-example/pkg/wal"
+)
+
+func TestRunDurable(t *testing.T) {
+	logs := testlog.Capture(t)
+	path := filepath.Join(t.TempDir(), "tasks.wal")
+	q, err := wal.Open[Task](path, wal.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Enqueue(Task{ID: 21})
+	q.Enqueue(Task{ID: 99})
+
+	fn := func(ctx context.Context, task Task) (int, error) {
+		if task.ID > 50 {
+			return 0, errInvalidTask
+		}
+		return task.ID * 2, nil
+	}
+	if got := runDurable(context.Background(), q, fn); got != 1 {
+		t.Errorf("got %d acknowledged, want 1", got)
+	}
+	got := []string{<-logs, <-logs} // Two workers, in either order
+	slices.Sort(got)
+	want := []string{"Task 21: 42 (delivery 1)", "Task 99 failed, left in the log: invalid task"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	testlog.ExpectNone(t, logs)
+	q.Close()
+
+	// Only the failed task is left for the next run
+	q, err = wal.Open[Task](path, wal.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	job, _ := q.Dequeue(context.Background())
+	if r := q.Recovery(); r.Pending != 1 || job.Value.ID != 99 {
+		t.Errorf("got %d pending, first task %d, want only task 99", r.Pending, job.Value.ID)
+	}
+}
+
+func TestCrashAndTear(t *testing.T) {
+	logs := testlog.Capture(t)
+	path := filepath.Join(t.TempDir(), "tasks.wal")
+	if err := crash(context.Background(), path, 3); err != nil {
+		t.Fatal(err)
+	}
+	testlog.Expect(t, logs, "First run: finished task 1, crashed during task 2")
+	if err := tear(path); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := wal.Open[Task](path, wal.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if r := q.Recovery(); r.Pending != 2 || r.Discarded == 0 {
+		t.Errorf("got recovery %+v, want 2 pending and a discarded torn record", r)
+	}
+}
+
+func TestMainNoLeaks(t *testing.T) {
+	leaktest.Check(t)
+	testlog.Discard(t)
+	main()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/pool"
+)
+
+/**
+ * Some tasks can only start once others are done: merging needs both
+ * fetches, publishing needs the merge. A pool.Graph runs such tasks on a
+ * worker pool, each as soon as the tasks it depends on have succeeded, and
+ * independent tasks in parallel.
+ *
+ * Key concepts:
+ * - Task Graphs: Running tasks once the tasks they depend on are done
+ * - Dependency results: Handing each task the results it depends on
+ * - Critical path: The chain of tasks that decided how long the graph took
+ * - Failure policies: Stopping everything or only the dependents of a failed task
+ *
+ * Common use cases:
+ * - Build systems
+ * - Data pipelines with fetch, transform and load steps
+ * - Deployments with ordered steps
+ *
+ * Tasks wait through a clock.Clock, so tests can run the graph with a
+ * clock.Fake.
+ */
+
+/**
+ * buildGraph returns a graph of tasks that feed each other: two fetches
+ * run in parallel, merge needs both, and publish needs merge. Each task
+ * adds its own number to the results it depends on.
+ * @param clk: clock used to simulate processing time
+ * @return: the graph, ready to run
+ */
+func buildGraph(clk clock.Clock) *pool.Graph[int] {
+	step := func(n int, delay time.Duration) pool.TaskFunc[int] {
+		return func(ctx context.Context, deps map[string]int) (int, error) {
+			select {
+			case <-clk.After(delay):
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+			for _, v := range deps {
+				n += v
+			}
+			return n, nil
+		}
+	}
+
+	g := pool.NewGraph[int]()
+	g.Add("fetch-users", step(1, 10*time.Millisecond))
+	g.Add("fetch-orders", step(10, 30*time.Millisecond))
+	g.Add("merge", step(100, 10*time.Millisecond), "fetch-users", "fetch-orders")
+	g.Add("publish", step(1000, 5*time.Millisecond), "merge")
+	return g
+}
+
+// ms formats a duration as fractional milliseconds
+func ms(d time.Duration) string {
+	return fmt.Sprintf("%.2f ms", float64(d)/float64(time.Millisecond))
+}
+
+func main() {
+	log.Println("=== Task Graph Examples ===")
+	clk := clock.New()
+	ctx := context.Background()
+
+	/**
+	 * 1. Task Dependencies
+	 * A Graph submits each task to a pool once the tasks it depends on have
+	 * succeeded and hands it their results. The critical path is the chain
+	 * of tasks that decided how long the whole graph took.
+	 */
+	log.Println("\n1. Task Dependencies")
+	report, err := buildGraph(clk).Run(ctx, pool.FailFast, pool.Options{Workers: 2, Clock: clk})
+	if err != nil {
+		log.Printf("Graph failed: %v\n", err)
+	}
+	for _, name := range []string{"fetch-users", "fetch-orders", "merge", "publish"} {
+		log.Printf("Task %s: %d\n", name, report.Tasks[name].Value)
+	}
+	log.Printf("Critical path: %v, %s of %s\n", report.CriticalPath, ms(report.Critical), ms(report.Elapsed))
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/internal/testlog"
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/pool"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+func TestBuildGraph(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	done := make(chan *pool.Report[int])
+	go func() {
+		// One worker runs the fetches one after the other
+		report, err := buildGraph(clk).Run(context.Background(), pool.FailFast, pool.Options{Workers: 1, Clock: clk})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- report
+	}()
+
+	clk.BlockUntil(1) // fetch-users
+	clk.Advance(10 * time.Millisecond)
+	clk.BlockUntil(1) // fetch-orders
+	clk.Advance(30 * time.Millisecond)
+	clk.BlockUntil(1) // merge
+	clk.Advance(10 * time.Millisecond)
+	clk.BlockUntil(1) // publish
+	clk.Advance(5 * time.Millisecond)
+	report := <-done
+
+	if got := report.Tasks["publish"].Value; got != 1111 {
+		t.Errorf("got %d, want 1111", got)
+	}
+	if want := []string{"fetch-orders", "merge", "publish"}; !slices.Equal(report.CriticalPath, want) {
+		t.Errorf("got critical path %v, want %v", report.CriticalPath, want)
+	}
+	if report.Critical != 45*time.Millisecond || report.Elapsed != 55*time.Millisecond {
+		t.Errorf("got critical %v of %v elapsed, want 45ms of 55ms", report.Critical, report.Elapsed)
+	}
+}
+
+func TestMainNoLeaks(t *testing.T) {
+	leaktest.Check(t)
+	testlog.Discard(t)
+	main()
+}
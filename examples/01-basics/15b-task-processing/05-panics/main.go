@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/pool"
+)
+
+/**
+ * A panic in a goroutine takes the whole program down, however many other
+ * tasks are still queued. pkg/pool recovers panics in its workers and
+ * turns them into errors, so one buggy task fails on its own.
+ *
+ * Key concepts:
+ * - Panic Isolation: A panicking task fails on its own instead of killing the process
+ * - recover: Stopping a panic in the goroutine it happened in
+ * - Stack traces: Keeping where the panic happened for debugging
+ * - errors.As: Telling a panic apart from an ordinary failure
+ *
+ * Common use cases:
+ * - Running plugins or user code in workers
+ * - Long-running services that must survive a bad request
+ * - Batch jobs that must not stop at the first bad record
+ *
+ * Tasks wait through a clock.Clock, so tests can run them with a
+ * clock.Fake.
+ */
+
+// Task represents a unit of work
+type Task struct {
+	ID int
+}
+
+/**
+ * process returns the function the workers run for each task
+ * @param clk: clock used to simulate processing time
+ * @param delay: how long each task takes
+ * @return: a pool.Func that doubles the task ID, and stops early if the pool is cancelled
+ */
+func process(clk clock.Clock, delay time.Duration) pool.Func[Task, int] {
+	return func(ctx context.Context, task Task) (int, error) {
+		select {
+		case <-clk.After(delay):
+			return task.ID * 2, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+/**
+ * submitTasks queues tasks 1..n and then closes the pool
+ * @param ctx: stops submitting early when done
+ * @param p: pool to submit to
+ * @param n: number of tasks
+ */
+func submitTasks(ctx context.Context, p *pool.Pool[Task, int], n int) {
+	defer p.Close()
+	for i := 1; i <= n; i++ {
+		if err := p.Submit(ctx, Task{ID: i}); err != nil {
+			log.Printf("Submit task %d: %v\n", i, err)
+			return
+		}
+	}
+}
+
+/**
+ * fragile wraps fn with a bug: tasks whose ID is a multiple of n write to
+ * a nil map, which panics. Without the pool recovering it, the panic would
+ * take the whole process down with every queued task.
+ * @param n: every nth task panics
+ * @param fn: the function to run for the other tasks
+ */
+func fragile(n int, fn pool.Func[Task, int]) pool.Func[Task, int] {
+	return func(ctx context.Context, task Task) (int, error) {
+		if task.ID%n == 0 {
+			var seen map[int]bool
+			seen[task.ID] = true
+		}
+		return fn(ctx, task)
+	}
+}
+
+func main() {
+	log.Println("=== Panicking Tasks Examples ===")
+	clk := clock.New()
+	ctx := context.Background()
+
+	/**
+	 * 1. Panicking Tasks
+	 * Every third task panics. The pool recovers each panic in the worker
+	 * and reports it as a *pool.PanicError with the panic value and stack
+	 * trace, so the other tasks still run and every task gets a result.
+	 */
+	log.Println("\n1. Panicking Tasks")
+	fragilePool := pool.New(ctx, fragile(3, process(clk, 10*time.Millisecond)), pool.Options{
+		Workers:   2,
+		QueueSize: 6,
+		Clock:     clk,
+	})
+	go submitTasks(ctx, fragilePool, 6)
+
+	for res := range fragilePool.Results() {
+		var panicErr *pool.PanicError
+		if errors.As(res.Err, &panicErr) {
+			log.Printf("Task %d panicked: %v\n", res.Input.ID, panicErr.Value)
+			continue
+		}
+		log.Printf("Task %d: %d\n", res.Input.ID, res.Value)
+	}
+	if err := fragilePool.Wait(); err != nil {
+		log.Printf("Pool failed: %v\n", err)
+	}
+	stats := fragilePool.Stats()
+	log.Printf("Statistics - Completed: %d, Failed: %d, Panics: %d\n", stats.Completed, stats.Failed, stats.Panics)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/internal/testlog"
+	"go-by-example/pkg/pool"
+)
+
+func TestFragile(t *testing.T) {
+	fn := fragile(2, func(ctx context.Context, task Task) (int, error) {
+		return task.ID * 2, nil
+	})
+	p := pool.New(context.Background(), fn, pool.Options{Workers: 1, QueueSize: 2})
+	p.Submit(context.Background(), Task{ID: 1})
+	p.Submit(context.Background(), Task{ID: 2})
+	p.Close()
+
+	for res := range p.Results() {
+		var panicErr *pool.PanicError
+		switch {
+		case res.Input.ID == 1 && (res.Err != nil || res.Value != 2):
+			t.Errorf("task 1: got %d, %v, want 2", res.Value, res.Err)
+		case res.Input.ID == 2 && !errors.As(res.Err, &panicErr):
+			t.Errorf("task 2: got %v, want a *pool.PanicError", res.Err)
+		}
+	}
+	if got := p.Stats().Panics; got != 1 {
+		t.Errorf("got %d panics, want 1", got)
+	}
+}
+
+func TestMainNoLeaks(t *testing.T) {
+	leaktest.Check(t)
+	testlog.Discard(t)
+	main()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/pool"
+)
+
+/**
+ * A worker pool hands back results in whatever order the tasks finish. An
+ * ordered pool delivers them in the order the tasks were submitted
+ * instead, holding back results that finish early, with a window that
+ * bounds how many it may hold.
+ *
+ * Key concepts:
+ * - Ordered Results: Getting results back in submission order with bounded buffering
+ * - Backpressure: Submit blocks while the reorder window is full
+ * - Iterators: Ranging over results with a range-over-func iterator
+ *
+ * Common use cases:
+ * - Parallel processing of records that must be written in order
+ * - Fetching pages concurrently and assembling them in sequence
+ * - Parallel encoding of a stream
+ *
+ * Tasks wait through a clock.Clock, so tests can run them with a
+ * clock.Fake.
+ */
+
+// Task represents a unit of work
+type Task struct {
+	ID int
+}
+
+/**
+ * slowFirst returns a function that takes longer for lower task IDs, so
+ * tasks finish in the opposite order to the one they were submitted in
+ * @param clk: clock used to simulate processing time
+ * @param n: highest task ID
+ * @param step: extra time each lower ID takes
+ * @return: a pool.Func that doubles the task ID, and stops early if the pool is cancelled
+ */
+func slowFirst(clk clock.Clock, n int, step time.Duration) pool.Func[Task, int] {
+	return func(ctx context.Context, task Task) (int, error) {
+		select {
+		case <-clk.After(time.Duration(n-task.ID+1) * step):
+			return task.ID * 2, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+/**
+ * submitTasks queues tasks 1..n and then closes the pool
+ * @param ctx: stops submitting early when done
+ * @param p: pool to submit to
+ * @param n: number of tasks
+ */
+func submitTasks(ctx context.Context, p *pool.Pool[Task, int], n int) {
+	defer p.Close()
+	for i := 1; i <= n; i++ {
+		if err := p.Submit(ctx, Task{ID: i}); err != nil {
+			log.Printf("Submit task %d: %v\n", i, err)
+			return
+		}
+	}
+}
+
+func main() {
+	log.Println("=== Ordered Results Examples ===")
+	clk := clock.New()
+	ctx := context.Background()
+
+	/**
+	 * 1. Ordered Results
+	 * Early tasks take longest here, so they finish last. An ordered pool
+	 * still delivers results in submission order, holding back the ones
+	 * that finish early. The order window caps how many tasks can be
+	 * submitted but not yet delivered: while task 1 holds up the head,
+	 * Submit blocks instead of the buffer growing without bound. All
+	 * ranges over the results as an iterator.
+	 */
+	log.Println("\n1. Ordered Results")
+	orderedPool := pool.New(ctx, slowFirst(clk, 5, 10*time.Millisecond), pool.Options{
+		Workers:     5,
+		Clock:       clk,
+		Ordered:     true,
+		OrderWindow: 5,
+	})
+	go submitTasks(ctx, orderedPool, 5)
+
+	var inOrder []int
+	for res := range orderedPool.All() {
+		inOrder = append(inOrder, res.Input.ID)
+	}
+	if err := orderedPool.Wait(); err != nil {
+		log.Printf("Pool failed: %v\n", err)
+	}
+	log.Printf("Results in submission order: %v\n", inOrder)
+}
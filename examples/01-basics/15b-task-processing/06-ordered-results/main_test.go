@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/internal/testlog"
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/pool"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+func TestSlowFirst(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	p := pool.New(context.Background(), slowFirst(clk, 3, 10*time.Millisecond), pool.Options{
+		Workers: 3,
+		Clock:   clk,
+		Ordered: true,
+	})
+	go submitTasks(context.Background(), p, 3)
+
+	// Task 3 finishes first and task 1 last, but the results stay in order
+	clk.BlockUntil(3)
+	clk.Advance(30 * time.Millisecond)
+	var got []int
+	for res := range p.All() {
+		got = append(got, res.Value)
+	}
+	if want := []int{2, 4, 6}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMainNoLeaks(t *testing.T) {
+	leaktest.Check(t)
+	testlog.Discard(t)
+	main()
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	"testing"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/internal/testlog"
+)
+
+func TestMainNoLeaks(t *testing.T) {
+	leaktest.Check(t)
+	testlog.Discard(t)
+	t.Chdir(t.TempDir()) // main writes to testdata/
+	main()
+}
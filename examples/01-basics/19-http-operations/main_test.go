@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/internal/testlog"
+)
+
+// main isn't run here: its server listens on :8080 until the process exits
+
+func TestContextExample(t *testing.T) {
+	leaktest.Check(t)
+	lines := testlog.Capture(t)
+
+	contextExample()
+	testlog.Expect(t, lines, "Processing for user: 123")
+	testlog.Expect(t, lines, "Operation completed")
+}
@@ -73,6 +73,11 @@ func signalHandlingExample() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	handleSignals(sigChan)
+}
+
+// handleSignals waits for a signal on sigChan and cleans up before returning
+func handleSignals(sigChan <-chan os.Signal) {
 	// Create channel for cleanup completion
 	done := make(chan bool)
 
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/internal/testlog"
+)
+
+func TestHandleSignals(t *testing.T) {
+	leaktest.Check(t)
+	lines := testlog.Capture(t)
+
+	sigChan := make(chan os.Signal, 1)
+	sigChan <- syscall.SIGTERM
+	handleSignals(sigChan)
+
+	testlog.Expect(t, lines, "Process running. Press Ctrl+C to exit...")
+	testlog.Expect(t, lines, "Received signal: terminated")
+	testlog.Expect(t, lines, "Performing cleanup...")
+	testlog.Expect(t, lines, "Cleanup completed")
+}
@@ -2,6 +2,9 @@ package main
 
 import (
 	"testing"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/internal/testlog"
 )
 
 func TestLineFilter(t *testing.T) {
@@ -35,6 +38,12 @@ func TestLineFilter(t *testing.T) {
 	}
 }
 
+func TestMainNoLeaks(t *testing.T) {
+	leaktest.Check(t)
+	testlog.Discard(t)
+	main()
+}
+
 func BenchmarkLineFilter(b *testing.B) {
 	input := "line1\nline2\nline3\nline4\nline5"
 	filters := []string{"line2", "line4"}
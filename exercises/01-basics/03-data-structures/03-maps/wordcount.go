@@ -0,0 +1,32 @@
+package wordcount
+
+/**
+ * Exercise: Maps
+ *
+ * Implement the two functions below using only the built-in map type.
+ *
+ * Run "go run . check maps" from the repository root to test your solution.
+ */
+
+/**
+ * WordCount counts how often each word appears in text.
+ * Words are separated by whitespace and compared case-insensitively,
+ * so "Go go GO" counts as three occurrences of "go".
+ * @param text: input text
+ * @return: map from lowercase word to number of occurrences
+ */
+func WordCount(text string) map[string]int {
+	// TODO: implement
+	return nil
+}
+
+/**
+ * Invert swaps keys and values. Several keys can share a value, so each
+ * value maps to the list of its keys, sorted alphabetically.
+ * @param m: map to invert
+ * @return: map from value to the sorted keys that had that value
+ */
+func Invert(m map[string]int) map[int][]string {
+	// TODO: implement
+	return nil
+}
@@ -0,0 +1,34 @@
+package iterators
+
+import "iter"
+
+/**
+ * Exercise: Range Iterators
+ *
+ * Since Go 1.23, range also works over functions that take a yield
+ * callback. Write two such iterators; both must stop as soon as yield
+ * returns false, which is what a break in the loop body does.
+ *
+ * Run "go run . check range-iterators" from the repository root to test your solution.
+ */
+
+/**
+ * Backward yields the index and value of every element of s, last first
+ * @param s: slice to walk
+ * @return: an iterator for "for i, v := range Backward(s)"
+ */
+func Backward[T any](s []T) iter.Seq2[int, T] {
+	// TODO: implement
+	return func(yield func(int, T) bool) {}
+}
+
+/**
+ * Filter yields the values of seq for which keep returns true
+ * @param seq: values to filter
+ * @param keep: reports whether a value is yielded
+ * @return: an iterator over the kept values
+ */
+func Filter[T any](seq iter.Seq[T], keep func(T) bool) iter.Seq[T] {
+	// TODO: implement
+	return func(yield func(T) bool) {}
+}
@@ -0,0 +1,30 @@
+package closures
+
+/**
+ * Exercise: Closures
+ *
+ * Both functions return other functions that share state captured from
+ * the enclosing scope.
+ *
+ * Run "go run . check closures" from the repository root to test your solution.
+ */
+
+/**
+ * Counter returns two functions sharing one counter.
+ * inc adds one and returns the new value, reset sets it back to zero.
+ * Separate calls to Counter must return independent counters.
+ */
+func Counter() (inc func() int, reset func()) {
+	// TODO: implement
+	return func() int { return 0 }, func() {}
+}
+
+/**
+ * Memoize wraps f so that it is only called once for each distinct argument.
+ * Later calls with the same argument return the cached result.
+ * @param f: function to memoize
+ */
+func Memoize(f func(int) int) func(int) int {
+	// TODO: implement
+	return f
+}
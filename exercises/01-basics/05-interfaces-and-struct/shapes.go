@@ -0,0 +1,42 @@
+package shapes
+
+/**
+ * Exercise: Interfaces and Structs
+ *
+ * Make Rectangle and Circle satisfy the Shape interface and implement Largest.
+ *
+ * Run "go run . check interfaces" from the repository root to test your solution.
+ */
+
+/**
+ * Shape is implemented by anything with an area and a perimeter
+ */
+type Shape interface {
+	Area() float64
+	Perimeter() float64
+}
+
+/**
+ * Rectangle is an axis-aligned rectangle
+ */
+type Rectangle struct {
+	Width, Height float64
+}
+
+/**
+ * Circle is a circle with the given radius
+ */
+type Circle struct {
+	Radius float64
+}
+
+// TODO: add the Area and Perimeter methods for Rectangle and Circle
+
+/**
+ * Largest returns the shape with the biggest area, or nil if there are none
+ * @param shapes: shapes to compare
+ */
+func Largest(shapes ...Shape) Shape {
+	// TODO: implement
+	return nil
+}
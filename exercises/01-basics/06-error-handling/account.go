@@ -0,0 +1,52 @@
+package account
+
+import "errors"
+
+/**
+ * Exercise: Error Handling
+ *
+ * Make Withdraw return errors that callers can inspect with errors.Is and
+ * errors.As.
+ *
+ * Run "go run . check error-handling" from the repository root to test your solution.
+ */
+
+/**
+ * ErrInsufficientFunds is returned (wrapped) when a withdrawal exceeds the balance
+ */
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+/**
+ * ErrInvalidAmount is returned (wrapped) for zero or negative amounts
+ */
+var ErrInvalidAmount = errors.New("invalid amount")
+
+/**
+ * TransactionError describes a failed operation on an account
+ */
+type TransactionError struct {
+	Op     string // Operation that failed, e.g. "withdraw"
+	Amount int    // Amount involved
+	Err    error  // Underlying cause, one of the sentinel errors above
+}
+
+// TODO: implement the error interface for *TransactionError, and Unwrap so
+// errors.Is(err, ErrInsufficientFunds) works
+
+/**
+ * Account is a bank account with a balance in cents
+ */
+type Account struct {
+	Balance int
+}
+
+/**
+ * Withdraw removes amount from the balance.
+ * On failure the balance is unchanged and the error is a *TransactionError
+ * with Op "withdraw" wrapping ErrInvalidAmount or ErrInsufficientFunds.
+ * @param amount: amount to withdraw in cents
+ */
+func (a *Account) Withdraw(amount int) error {
+	// TODO: implement
+	return nil
+}
@@ -0,0 +1,81 @@
+package generics
+
+/**
+ * Exercise: Generics
+ *
+ * Stack is the generic stack from the 09-generics example. Add Peek, then
+ * write a generic FIFO Queue in the same style.
+ *
+ * Run "go run . check generics" from the repository root to test your solution.
+ */
+
+/**
+ * Stack is a LIFO collection of any type
+ */
+type Stack[T any] struct {
+	items []T
+}
+
+func NewStack[T any]() *Stack[T] {
+	return &Stack[T]{items: make([]T, 0)}
+}
+
+func (s *Stack[T]) Push(item T) {
+	s.items = append(s.items, item)
+}
+
+func (s *Stack[T]) Pop() (T, bool) {
+	var zero T
+	if len(s.items) == 0 {
+		return zero, false
+	}
+	item := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return item, true
+}
+
+/**
+ * Peek returns the top item without removing it.
+ * The second result is false when the stack is empty.
+ */
+func (s *Stack[T]) Peek() (T, bool) {
+	// TODO: implement
+	var zero T
+	return zero, false
+}
+
+/**
+ * Queue is a FIFO collection of any type
+ */
+type Queue[T any] struct {
+	// TODO: add fields
+}
+
+func NewQueue[T any]() *Queue[T] {
+	return &Queue[T]{}
+}
+
+/**
+ * Enqueue adds item to the back of the queue
+ */
+func (q *Queue[T]) Enqueue(item T) {
+	// TODO: implement
+}
+
+/**
+ * Dequeue removes and returns the item at the front of the queue.
+ * The second result is false when the queue is empty.
+ */
+func (q *Queue[T]) Dequeue() (T, bool) {
+	// TODO: implement
+	var zero T
+	return zero, false
+}
+
+/**
+ * Len returns the number of items in the queue
+ */
+func (q *Queue[T]) Len() int {
+	// TODO: implement
+	return 0
+}
@@ -0,0 +1,34 @@
+package channels
+
+/**
+ * Exercise: Channels
+ *
+ * Build two small pipeline stages. Both must close the channel they return
+ * once there are no more values, so callers can range over it.
+ *
+ * Run "go run . check channels" from the repository root to test your solution.
+ */
+
+/**
+ * Generate sends each number on the returned channel, in order, and then
+ * closes it
+ * @param nums: values to send
+ */
+func Generate(nums ...int) <-chan int {
+	// TODO: implement
+	ch := make(chan int)
+	close(ch)
+	return ch
+}
+
+/**
+ * Merge forwards every value from all input channels to a single output
+ * channel (fan-in). The output is closed after every input is closed.
+ * @param inputs: channels to merge
+ */
+func Merge(inputs ...<-chan int) <-chan int {
+	// TODO: implement
+	ch := make(chan int)
+	close(ch)
+	return ch
+}
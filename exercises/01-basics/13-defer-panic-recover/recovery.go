@@ -0,0 +1,33 @@
+package recovery
+
+/**
+ * Exercise: Defer, Panic and Recover
+ *
+ * A panic in one task shouldn't take the whole program down. Catch it with
+ * a deferred recover and report it as an ordinary error instead.
+ *
+ * Run "go run . check defer-panic-recover" from the repository root to test your solution.
+ */
+
+/**
+ * SafeCall runs f and returns nil if it returns normally. If f panics,
+ * SafeCall returns an error describing the panic instead, which wraps the
+ * panic value when that value is an error.
+ * @param f: function to run
+ */
+func SafeCall(f func()) error {
+	// TODO: implement
+	f()
+	return nil
+}
+
+/**
+ * Divide returns a / b, or an error instead of the runtime panic when b
+ * is zero. Let the panic happen and recover from it; don't check b first.
+ * @param a: dividend
+ * @param b: divisor
+ */
+func Divide(a, b int) (int, error) {
+	// TODO: implement
+	return a / b, nil
+}
@@ -0,0 +1,22 @@
+package workers
+
+/**
+ * Exercise: Worker Pools
+ *
+ * Apply f to every input using a fixed number of worker goroutines.
+ *
+ * Run "go run . check worker-pools" from the repository root to test your solution.
+ */
+
+/**
+ * ParallelMap returns f applied to every input, in input order.
+ * At most workers calls to f may run at the same time, and at least one
+ * worker is used even if workers is zero or negative.
+ * @param inputs: values to process
+ * @param workers: number of worker goroutines
+ * @param f: function to apply
+ */
+func ParallelMap(inputs []int, workers int, f func(int) int) []int {
+	// TODO: implement
+	return nil
+}
@@ -0,0 +1,27 @@
+package runes
+
+/**
+ * Exercise: String Manipulation
+ *
+ * Strings are byte slices, but text is made of runes. Both functions must
+ * handle multi-byte characters such as "héllo" or "日本".
+ *
+ * Run "go run . check string-manipulation" from the repository root to test your solution.
+ */
+
+/**
+ * Reverse returns s with its runes in reverse order
+ */
+func Reverse(s string) string {
+	// TODO: implement
+	return s
+}
+
+/**
+ * IsPalindrome reports whether s reads the same backwards, ignoring case,
+ * spaces and punctuation, e.g. "A man, a plan, a canal: Panama"
+ */
+func IsPalindrome(s string) bool {
+	// TODO: implement
+	return false
+}
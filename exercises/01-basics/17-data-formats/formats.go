@@ -0,0 +1,35 @@
+package formats
+
+import "io"
+
+/**
+ * Exercise: Data Formats
+ *
+ * Person is encoded to JSON by another service that expects lowercase keys
+ * and no "email" key when there is no email address. Incoming lists of
+ * people must not contain keys that Person doesn't know about, so typos
+ * don't go unnoticed.
+ *
+ * Run "go run . check data-formats" from the repository root to test your solution.
+ */
+
+/**
+ * Person is encoded as {"name":"Alice","age":30,"email":"alice@example.com"}
+ */
+type Person struct {
+	// TODO: add json struct tags
+	Name  string
+	Age   int
+	Email string
+}
+
+/**
+ * DecodePeople reads a JSON array of people from r. An object with a key
+ * that isn't a field of Person is an error.
+ * @param r: JSON input
+ * @return: the people, or an error if the input isn't a valid list of people
+ */
+func DecodePeople(r io.Reader) ([]Person, error) {
+	// TODO: implement
+	return nil, nil
+}
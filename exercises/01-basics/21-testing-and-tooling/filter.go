@@ -0,0 +1,40 @@
+package tooling
+
+import "strings"
+
+/**
+ * Exercise: Testing and Tooling
+ *
+ * lineFilter is the function from the 21-testing-and-tooling example. It
+ * drops every line containing one of the filters as a plain substring.
+ * Change it so each filter is a regular expression instead.
+ *
+ * Run "go run . check testing-and-tooling" from the repository root to test your solution.
+ */
+
+/**
+ * lineFilter removes every line of input that matches any of the patterns
+ * @param input: text to filter
+ * @param patterns: regular expressions (regexp syntax), e.g. `^#` or `error|warn`
+ * @return: the remaining lines, or an error if a pattern doesn't compile
+ */
+func lineFilter(input string, patterns []string) (string, error) {
+	// TODO: treat patterns as regular expressions
+	lines := strings.Split(input, "\n")
+	var result []string
+
+	for _, line := range lines {
+		include := true
+		for _, filter := range patterns {
+			if strings.Contains(line, filter) {
+				include = false
+				break
+			}
+		}
+		if include {
+			result = append(result, line)
+		}
+	}
+
+	return strings.Join(result, "\n"), nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go-by-example/internal/site"
+)
+
+func genSiteCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("gen-site", flag.ContinueOnError)
+	out := fs.String("out", "site", "Output directory")
+	run := fs.Bool("run", false, "Run every example and include its output")
+	timeout := fs.Duration("timeout", 30*time.Second, "Per-example timeout when -run is set")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	examples, err := discoverExamples(examplesRoot)
+	if err != nil {
+		return err
+	}
+
+	pages := make([]site.Page, 0, len(examples))
+	for _, ex := range examples {
+		src, err := os.ReadFile(filepath.Join(ex.Dir, "main.go"))
+		if err != nil {
+			return err
+		}
+		page := site.Page{Path: ex.Path, Title: ex.Title(), Source: src}
+
+		if *run {
+			log.Printf("Running %s\n", ex.Path)
+			var output bytes.Buffer
+			err := runExample(ctx, ex, runOptions{
+				Timeout: *timeout,
+				Grace:   2 * time.Second,
+				Stdout:  &output,
+				Stderr:  &output,
+			})
+			if err != nil {
+				log.Printf("%s: %v (keeping partial output)\n", ex.Path, err)
+			}
+			page.Output = logTimestamp.ReplaceAllString(output.String(), "")
+		}
+		pages = append(pages, page)
+	}
+
+	if err := site.Generate(*out, "Go by Example", pages); err != nil {
+		return err
+	}
+	log.Printf("Wrote %d pages to %s\n", len(pages), *out)
+	return nil
+}
@@ -1,3 +1,10 @@
 module go-by-example
 
-go 1.23.1
+go 1.25.0
+
+require (
+	golang.org/x/mod v0.35.0
+	golang.org/x/tools v0.44.0
+)
+
+require golang.org/x/sync v0.21.0 // indirect
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+/**
+ * Golden-output regression tests for every example program.
+ *
+ * Each example is built and run exactly like "go run . run <name>" does. Its
+ * combined stdout/stderr is normalized (log timestamps, temp paths, PIDs,
+ * pointers, durations and any per-example masks) and compared with
+ * testdata/golden/<path>.golden.
+ *
+ * Regenerate the golden files after an intentional change with:
+ *   go test -run TestGolden -update
+ */
+
+var update = flag.Bool("update", false, "Rewrite the golden files with the current output")
+
+// goldenDir holds one .golden file per example, mirroring the examples tree
+const goldenDir = "testdata/golden"
+
+// mask replaces every match of Pattern with Replace
+type mask struct {
+	Pattern *regexp.Regexp
+	Replace string
+}
+
+func newMask(pattern, replace string) mask {
+	return mask{Pattern: regexp.MustCompile(pattern), Replace: replace}
+}
+
+// goldenCase describes how to run and normalize one example
+type goldenCase struct {
+	Masks     []mask        // Applied after commonMasks, in order
+	Drop      []string      // Lines matching any of these patterns are removed
+	Unordered bool          // Lines inside each numbered section may appear in any order
+	Interrupt time.Duration // Interrupt the example after this long instead of waiting for it to exit
+}
+
+// commonMasks normalize values that change on every run of any example
+var commonMasks = []mask{
+	{Pattern: logTimestamp, Replace: ""},
+	newMask(regexp.QuoteMeta(filepath.ToSlash(os.TempDir()))+`/[^\s"']*`, "<tmp>"),
+	newMask(`0x[0-9a-f]{6,}`, "<addr>"),
+	newMask(`(?i)\bpid:? \d+`, "PID: <pid>"),
+	newMask(`\b(\d+(\.\d+)?(ns|µs|us|ms|h|m|s))+\b`, "<duration>"),
+}
+
+// goldenCases lists the examples whose output needs more than commonMasks.
+// Examples that are not listed are expected to be fully deterministic.
+var goldenCases = map[string]goldenCase{
+	"01-basics/03-data-structures/03-maps": {
+		Unordered: true, // ranges over maps
+	},
+	"01-basics/04-control-flow-and-iterators/03-switch": {
+		Masks: []mask{newMask(`(?m)^Good (morning|afternoon|evening)!$`, "Good <time of day>!")},
+	},
+	"01-basics/04-control-flow-and-iterators/04-range-iterators": {
+		Unordered: true, // ranges over maps
+	},
+	"01-basics/10-goroutines": {
+		Unordered: true,
+	},
+	"01-basics/12-channels": {
+		Unordered: true,
+		// Which consumer wins each value in the fan-out section is up to the scheduler
+		Masks: []mask{newMask(`(?m)^Consumer \d+ received`, "Consumer <id> received")},
+	},
+	"01-basics/14-timers-tickers": {
+		// How many times each ticker fires before "done" depends on scheduling
+		Drop:  []string{`^Ticker \d fired$`},
+		Masks: []mask{newMask(`Processed \d+ ticks`, "Processed <n> ticks")},
+	},
+	"01-basics/15-worker-pools": {
+		Unordered: true,
+		// How far the dynamic pool scales depends on scheduling
+		Drop: []string{`^Pool scaled (up|down) `},
+		Masks: []mask{
+			newMask(`(?m)^(Rate-limited worker|Worker) \d+`, "$1 <id>"),
+			newMask(`Average Time: [\d.]+ ms`, "Average Time: <n> ms"),
+			newMask(`(?m)^(Queue wait|Processing) - .*$`, "$1 - <percentiles>"),
+			newMask(`Throughput: [\d.]+ tasks/s`, "Throughput: <n> tasks/s"),
+			newMask(`(?m)^  Worker \d+: \d+ tasks, p50 processing [\d.]+ ms$`, "  Worker <id>: <n> tasks, p50 processing <n> ms"),
+		},
+	},
+	"01-basics/15b-task-processing/01-retries": {
+		Unordered: true,
+	},
+	"01-basics/15b-task-processing/03-durable-queue": {
+		Unordered: true,
+	},
+	"01-basics/15b-task-processing/04-task-graphs": {
+		Masks: []mask{newMask(`(Critical path: \[.*\]), [\d.]+ ms of [\d.]+ ms`, "$1, <n> ms of <n> ms")},
+	},
+	"01-basics/15b-task-processing/05-panics": {
+		Unordered: true,
+	},
+	"01-basics/17-data-formats": {
+		Masks: []mask{
+			newMask(`(?m)^(Current time|Formatted \(RFC3339\)|Formatted \(custom\)|Tomorrow): .*$`, "$1: <now>"),
+			newMask(`(?m)^Unix epoch: \d+$`, "Unix epoch: <now>"),
+			newMask(`(?m)^(Random int|Random float|Random range \(1-100\)|Random bytes \(base64\)): .*$`, "$1: <random>"),
+		},
+	},
+	"01-basics/20-process-management": {
+		// signalHandlingExample blocks until it receives SIGINT/SIGTERM
+		Interrupt: 2 * time.Second,
+		Masks: []mask{
+			newMask(`(?m)^(Program name|HOME|PATH): .*$`, "$1: <env>"),
+		},
+		// Output of "ls -l" in the example directory
+		Drop: []string{`^total \d+$`, `^[-dl][-rwxsStT]{9}[.+@]? `},
+	},
+}
+
+// normalizeOutput applies the common and per-example rules to out
+func normalizeOutput(out string, gc goldenCase) string {
+	out = strings.ReplaceAll(out, "\r\n", "\n")
+	for _, m := range commonMasks {
+		out = m.Pattern.ReplaceAllString(out, m.Replace)
+	}
+	for _, m := range gc.Masks {
+		out = m.Pattern.ReplaceAllString(out, m.Replace)
+	}
+
+	var drop []*regexp.Regexp
+	for _, pattern := range gc.Drop {
+		drop = append(drop, regexp.MustCompile(pattern))
+	}
+
+	var lines []string
+	for _, line := range strings.Split(out, "\n") {
+		dropped := false
+		for _, re := range drop {
+			if re.MatchString(line) {
+				dropped = true
+				break
+			}
+		}
+		if !dropped {
+			lines = append(lines, line)
+		}
+	}
+
+	if gc.Unordered {
+		sortSections(lines)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sectionHeader matches the "1. Basic channel usage" lines that the examples
+// print before each numbered section
+var sectionHeader = regexp.MustCompile(`^\d+\. `)
+
+// sortSections sorts the lines between consecutive section headers in place,
+// so output from concurrent goroutines or map iteration compares equal
+// regardless of the order it was printed in. The blank line that
+// log.Println("\n1. ...") leaves before each header stays where it is.
+func sortSections(lines []string) {
+	start := 0
+	for i := 0; i <= len(lines); i++ {
+		if i == len(lines) || sectionHeader.MatchString(lines[i]) {
+			end := i
+			if end > start && i < len(lines) && lines[end-1] == "" {
+				end--
+			}
+			sort.Strings(lines[start:end])
+			start = i + 1
+		}
+	}
+}
+
+func TestGolden(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping golden tests in short mode")
+	}
+
+	examples, err := discoverExamples(examplesRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ex := range examples {
+		t.Run(ex.Path, func(t *testing.T) {
+			t.Parallel()
+
+			gc := goldenCases[ex.Path]
+			var out bytes.Buffer
+			opts := runOptions{
+				Timeout: 2 * time.Minute,
+				Grace:   5 * time.Second,
+				Stdout:  &out,
+				Stderr:  &out,
+			}
+			if gc.Interrupt > 0 {
+				opts.Timeout = gc.Interrupt
+			}
+
+			err := runExample(context.Background(), ex, opts)
+			if err != nil && !(gc.Interrupt > 0 && errors.Is(err, errTimeout)) {
+				t.Fatalf("run failed: %v\n%s", err, out.String())
+			}
+
+			got := normalizeOutput(out.String(), gc)
+			path := filepath.Join(goldenDir, filepath.FromSlash(ex.Path)+".golden")
+
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("output differs from %s (run with -update to accept it)\n%s", path, lineDiff(string(want), got))
+			}
+		})
+	}
+}
+
+func TestNormalizeOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		gc       goldenCase
+		expected string
+	}{
+		{
+			name:     "Log prefix and duration",
+			input:    "2024/03/15 14:30:00 Duration: 1.00062039s",
+			expected: "Duration: <duration>",
+		},
+		{
+			name:     "Unordered sections",
+			input:    "1. First\nb\na\n\n2. Second\nd\nc",
+			gc:       goldenCase{Unordered: true},
+			expected: "1. First\na\nb\n\n2. Second\nc\nd",
+		},
+		{
+			name:     "Drop lines",
+			input:    "Ticker 1 fired\nProcessed 3 ticks",
+			gc:       goldenCases["01-basics/14-timers-tickers"],
+			expected: "Processed <n> ticks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := normalizeOutput(tt.input, tt.gc)
+			if result != tt.expected {
+				t.Errorf("got %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+// lineDiff returns a minimal line-oriented description of how got differs
+// from want: the first differing line plus a few lines of context.
+func lineDiff(want, got string) string {
+	wl := strings.Split(want, "\n")
+	gl := strings.Split(got, "\n")
+
+	i := 0
+	for i < len(wl) && i < len(gl) && wl[i] == gl[i] {
+		i++
+	}
+
+	var b strings.Builder
+	for j := i; j < i+5; j++ {
+		if j < len(wl) {
+			b.WriteString("- " + wl[j] + "\n")
+		}
+		if j < len(gl) {
+			b.WriteString("+ " + gl[j] + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,29 @@
+// Package broadcast wakes every goroutine waiting for shared state to
+// change, like sync.Cond.Broadcast, but through a channel, so waiters can
+// select on it together with a context or a timer.
+package broadcast
+
+// Signal hands out channels that the next Notify closes. It has no lock of
+// its own: the mutex that guards the state it reports changes of must be
+// held for both methods. The zero Signal is ready to use.
+type Signal struct {
+	ch chan struct{}
+}
+
+// Chan returns a channel that is closed by the next Notify. A waiter takes
+// it while still holding the lock, then waits on it after unlocking, so it
+// can't miss a change in between.
+func (s *Signal) Chan() <-chan struct{} {
+	if s.ch == nil {
+		s.ch = make(chan struct{})
+	}
+	return s.ch
+}
+
+// Notify wakes everyone waiting on a channel from Chan
+func (s *Signal) Notify() {
+	if s.ch != nil {
+		close(s.ch)
+		s.ch = nil
+	}
+}
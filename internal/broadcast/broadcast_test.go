@@ -0,0 +1,35 @@
+package broadcast
+
+import "testing"
+
+func closed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestSignal(t *testing.T) {
+	var s Signal
+	s.Notify() // Nobody is waiting yet
+
+	first, second := s.Chan(), s.Chan()
+	if closed(first) || closed(second) {
+		t.Fatal("channel closed before Notify")
+	}
+	s.Notify()
+	if !closed(first) || !closed(second) {
+		t.Fatal("Notify didn't close every channel handed out")
+	}
+
+	next := s.Chan()
+	if closed(next) {
+		t.Error("channel taken after Notify is already closed")
+	}
+	s.Notify()
+	if !closed(next) {
+		t.Error("second Notify didn't close the new channel")
+	}
+}
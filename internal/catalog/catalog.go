@@ -0,0 +1,164 @@
+// Package catalog builds a searchable index of the examples from their
+// header comments ("Key concepts", "Common use cases"), their exported
+// declarations and the standard library packages they import.
+package catalog
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-by-example/internal/site"
+)
+
+// modulePath is this repository's module, whose packages aren't stdlib
+const modulePath = "go-by-example"
+
+// Catalog is the JSON index of every example
+type Catalog struct {
+	Examples []Entry `json:"examples"`
+}
+
+// Entry describes a single example
+type Entry struct {
+	Path     string   `json:"path"`
+	Title    string   `json:"title"`
+	Summary  string   `json:"summary,omitempty"`
+	Concepts []Topic  `json:"concepts,omitempty"`
+	UseCases []string `json:"use_cases,omitempty"`
+	Types    []string `json:"types,omitempty"`
+	Funcs    []string `json:"funcs,omitempty"`
+	Stdlib   []string `json:"stdlib,omitempty"`
+}
+
+// Topic is one "Key concepts" item, e.g. "Worker Pool: Group of goroutines
+// processing tasks concurrently" has the name "Worker Pool"
+type Topic struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// ParseEntry extracts the catalog entry for one example from its main.go
+func ParseEntry(path, title string, src []byte) (Entry, error) {
+	entry := Entry{Path: path, Title: title}
+
+	segments, err := site.Parse(path+"/main.go", src)
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, s := range segments {
+		if s.Doc != "" {
+			parseHeader(&entry, s.Doc)
+			break
+		}
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path+"/main.go", src, parser.SkipObjectResolution)
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, imp := range file.Imports {
+		pkg, err := strconv.Unquote(imp.Path.Value)
+		if err == nil && isStdlib(pkg) {
+			entry.Stdlib = append(entry.Stdlib, pkg)
+		}
+	}
+	entry.Types, entry.Funcs = exportedDecls(file)
+
+	sort.Strings(entry.Stdlib)
+	return entry, nil
+}
+
+// parseHeader fills in the summary and the labelled lists of a header comment
+func parseHeader(entry *Entry, doc string) {
+	var summary []string
+	list := ""
+
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			list = ""
+		case strings.HasSuffix(line, ":") && !strings.HasPrefix(line, "- "):
+			list = "concepts"
+			if strings.Contains(strings.ToLower(line), "use case") {
+				list = "use cases"
+			}
+		case strings.HasPrefix(line, "- ") && list == "concepts":
+			name, desc, _ := strings.Cut(strings.TrimPrefix(line, "- "), ": ")
+			entry.Concepts = append(entry.Concepts, Topic{Name: name, Description: desc})
+		case strings.HasPrefix(line, "- ") && list == "use cases":
+			entry.UseCases = append(entry.UseCases, strings.TrimPrefix(line, "- "))
+		case list == "" && len(entry.Concepts) == 0 && len(entry.UseCases) == 0:
+			summary = append(summary, line)
+		}
+	}
+
+	entry.Summary = firstSentence(strings.Join(summary, " "))
+}
+
+// firstSentence returns text up to and including the first full stop
+func firstSentence(text string) string {
+	if i := strings.Index(text, ". "); i >= 0 {
+		return text[:i+1]
+	}
+	return text
+}
+
+// isStdlib reports whether an import path belongs to the standard library,
+// whose first path element never contains a dot
+func isStdlib(pkg string) bool {
+	first, _, _ := strings.Cut(pkg, "/")
+	return !strings.Contains(first, ".") && first != modulePath
+}
+
+// exportedDecls returns the exported type names and the exported functions
+// and methods ("Stack.Push") declared in file
+func exportedDecls(file *ast.File) (types, funcs []string) {
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				if ts := spec.(*ast.TypeSpec); ts.Name.IsExported() {
+					types = append(types, ts.Name.Name)
+				}
+			}
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				recv := receiverName(d.Recv.List[0].Type)
+				if !ast.IsExported(recv) {
+					continue
+				}
+				name = recv + "." + name
+			}
+			funcs = append(funcs, name)
+		}
+	}
+	return types, funcs
+}
+
+// receiverName unwraps *T, T[K] and *T[K] receivers down to T
+func receiverName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(e.X)
+	case *ast.IndexExpr:
+		return receiverName(e.X)
+	case *ast.IndexListExpr:
+		return receiverName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
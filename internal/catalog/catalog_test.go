@@ -0,0 +1,95 @@
+package catalog
+
+import (
+	"reflect"
+	"testing"
+)
+
+const sample = `package main
+
+import (
+	"log"
+	"sync/atomic"
+
+	"go-by-example/pkg/clock"
+)
+
+/**
+ * Worker Pools in Go demonstrate concurrent task processing. More text.
+ *
+ * Key concepts:
+ * - Worker Pool: Group of goroutines processing tasks concurrently
+ * - WaitGroup
+ *
+ * Common use cases:
+ * - API rate limiting
+ */
+
+// Task represents a unit of work
+type Task struct{ ID int }
+
+type stats struct{}
+
+type Stack[T any] struct{ items []T }
+
+func (s *Stack[T]) Push(item T) {}
+
+func (s *stats) Add() {}
+
+func NewTask(id int) Task { return Task{ID: id} }
+
+func main() {
+	var n atomic.Int64
+	log.Println(n.Load(), clock.New())
+}
+`
+
+func TestParseEntry(t *testing.T) {
+	entry, err := ParseEntry("01-basics/15-worker-pools", "Worker Pools", []byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Entry{
+		Path:    "01-basics/15-worker-pools",
+		Title:   "Worker Pools",
+		Summary: "Worker Pools in Go demonstrate concurrent task processing.",
+		Concepts: []Topic{
+			{Name: "Worker Pool", Description: "Group of goroutines processing tasks concurrently"},
+			{Name: "WaitGroup"},
+		},
+		UseCases: []string{"API rate limiting"},
+		Types:    []string{"Task", "Stack"},
+		Funcs:    []string{"Stack.Push", "NewTask"},
+		Stdlib:   []string{"log", "sync/atomic"},
+	}
+	if !reflect.DeepEqual(entry, expected) {
+		t.Errorf("got %+v\nwant %+v", entry, expected)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	c := &Catalog{Examples: []Entry{
+		{Path: "01-basics/14-timers-tickers", Concepts: []Topic{{Name: "Ticker"}}, UseCases: []string{"Rate limiting"}},
+		{Path: "01-basics/15-worker-pools", Concepts: []Topic{{Name: "Rate Limiting"}}, UseCases: []string{"API rate limiting"}, Stdlib: []string{"sync/atomic"}},
+		{Path: "01-basics/17-data-formats", Stdlib: []string{"encoding/xml"}},
+	}}
+
+	results := c.Search("rate limiting")
+	var paths []string
+	for _, res := range results {
+		paths = append(paths, res.Entry.Path)
+	}
+	if want := []string{"01-basics/15-worker-pools", "01-basics/14-timers-tickers"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("got %q, want %q", paths, want)
+	}
+
+	if got := c.Search("rate xml"); len(got) != 0 {
+		t.Errorf("every word must match, got %d results", len(got))
+	}
+
+	byPkg := c.ImportedBy("encoding/xml")
+	if len(byPkg) != 1 || byPkg[0].Path != "01-basics/17-data-formats" {
+		t.Errorf("got %+v, want 17-data-formats", byPkg)
+	}
+}
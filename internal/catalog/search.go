@@ -0,0 +1,103 @@
+package catalog
+
+import (
+	"sort"
+	"strings"
+)
+
+// Result is a search hit together with the lines of the entry that matched
+type Result struct {
+	Entry   Entry    `json:"entry"`
+	Score   int      `json:"score"`
+	Matches []string `json:"matches"`
+}
+
+// field is one searchable line of an entry with its weight
+type field struct {
+	text   string
+	weight int
+}
+
+func (e Entry) fields() []field {
+	fields := []field{
+		{e.Path, 3},
+		{e.Title, 3},
+		{e.Summary, 1},
+	}
+	for _, c := range e.Concepts {
+		fields = append(fields, field{c.Name, 3})
+		if c.Description != "" {
+			fields = append(fields, field{c.Description, 1})
+		}
+	}
+	for _, u := range e.UseCases {
+		fields = append(fields, field{u, 2})
+	}
+	for _, t := range e.Types {
+		fields = append(fields, field{t, 2})
+	}
+	for _, f := range e.Funcs {
+		fields = append(fields, field{f, 2})
+	}
+	for _, pkg := range e.Stdlib {
+		fields = append(fields, field{pkg, 2})
+	}
+	return fields
+}
+
+// Search returns the entries in which every word of query appears, best
+// matches first. Lines that contain the whole query as a phrase count double.
+func (c *Catalog) Search(query string) []Result {
+	query = strings.ToLower(strings.TrimSpace(query))
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return nil
+	}
+
+	var results []Result
+	for _, entry := range c.Examples {
+		res := Result{Entry: entry}
+		found := make(map[string]bool)
+
+		for _, f := range entry.fields() {
+			text := strings.ToLower(f.text)
+			hit := false
+			for _, w := range words {
+				if strings.Contains(text, w) {
+					found[w] = true
+					res.Score += f.weight
+					hit = true
+				}
+			}
+			if strings.Contains(text, query) {
+				res.Score += 2 * f.weight
+			}
+			if hit {
+				res.Matches = append(res.Matches, f.text)
+			}
+		}
+
+		if len(found) == len(words) {
+			results = append(results, res)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
+// ImportedBy returns the entries that import the standard library package pkg
+func (c *Catalog) ImportedBy(pkg string) []Entry {
+	var entries []Entry
+	for _, entry := range c.Examples {
+		for _, imp := range entry.Stdlib {
+			if imp == pkg {
+				entries = append(entries, entry)
+				break
+			}
+		}
+	}
+	return entries
+}
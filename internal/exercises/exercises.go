@@ -0,0 +1,161 @@
+// Package exercises runs the practice exercises under exercises/ against
+// their hidden tests and keeps track of each learner's progress.
+//
+// Every exercise directory mirrors an example (exercises/01-basics/09-generics
+// goes with examples/01-basics/09-generics) and holds stub code plus an
+// exercise.json with a title, description and hints. The tests live in this
+// package's testdata/tests directory, embedded into the binary, and are only
+// copied next to the learner's code in a temporary directory while checking.
+// A reference solution for each exercise lives in testdata/solutions, which
+// is not embedded.
+package exercises
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed testdata/tests
+var hiddenTests embed.FS
+
+// Exercise is one practice task
+type Exercise struct {
+	Name        string   `json:"-"` // Topic name, e.g. "09-generics"
+	Path        string   `json:"-"` // Slash-separated path below the exercises root
+	Dir         string   `json:"-"` // Directory on disk with the learner's code
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Hints       []string `json:"hints"`
+}
+
+// Result is the outcome of checking an exercise
+type Result struct {
+	Passed bool
+	Output string // Output of "go test"
+}
+
+// Load returns every exercise below root, sorted by path
+func Load(root string) ([]Exercise, error) {
+	var list []Exercise
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != "exercise.json" {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		var ex Exercise
+		if err := json.Unmarshal(data, &ex); err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+
+		ex.Dir = filepath.Dir(p)
+		rel, err := filepath.Rel(root, ex.Dir)
+		if err != nil {
+			return err
+		}
+		ex.Path = filepath.ToSlash(rel)
+		ex.Name = path.Base(ex.Path)
+		list = append(list, ex)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Path < list[j].Path
+	})
+	return list, nil
+}
+
+// Check copies the learner's code and the exercise's hidden tests into a
+// temporary module and runs "go test" there. A failing test is reported in
+// the Result; the error is only for problems running the check itself.
+func Check(ctx context.Context, ex Exercise, timeout time.Duration) (Result, error) {
+	tests, err := fs.Sub(hiddenTests, path.Join("testdata/tests", ex.Path))
+	if err != nil {
+		return Result{}, err
+	}
+	if _, err := fs.Stat(tests, "."); err != nil {
+		return Result{}, fmt.Errorf("no hidden tests for %s", ex.Path)
+	}
+
+	tmp, err := os.MkdirTemp("", "go-by-example-check-*")
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := copyGoFiles(os.DirFS(ex.Dir), tmp, false); err != nil {
+		return Result{}, err
+	}
+	if err := copyGoFiles(tests, tmp, true); err != nil {
+		return Result{}, err
+	}
+	goMod := "module exercise\n\ngo 1.23\n"
+	if err := os.WriteFile(filepath.Join(tmp, "go.mod"), []byte(goMod), 0644); err != nil {
+		return Result{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "go", "test", "-count=1", ".")
+	cmd.Dir = tmp
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	out, err := cmd.CombinedOutput()
+	output := strings.ReplaceAll(string(out), tmp, ex.Dir)
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		return Result{Output: output}, fmt.Errorf("tests did not finish within %v", timeout)
+	case errors.As(err, &exitErr):
+		return Result{Output: output}, nil
+	case err != nil:
+		return Result{}, err
+	}
+	return Result{Passed: true, Output: output}, nil
+}
+
+// copyGoFiles copies the .go files at the top of src into dir. Test files
+// are only copied from the hidden tests, so a learner's own tests can't
+// replace them.
+func copyGoFiles(src fs.FS, dir string, tests bool) error {
+	entries, err := fs.ReadDir(src, ".")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") != tests {
+			continue
+		}
+		data, err := fs.ReadFile(src, name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package exercises
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	list, err := Load("../../exercises")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) == 0 {
+		t.Fatal("no exercises found")
+	}
+
+	for _, ex := range list {
+		if ex.Title == "" || ex.Description == "" || len(ex.Hints) == 0 {
+			t.Errorf("%s: exercise.json needs a title, description and hints", ex.Path)
+		}
+		tests, err := fs.Glob(hiddenTests, path.Join("testdata/tests", ex.Path, "*_test.go"))
+		if err != nil || len(tests) == 0 {
+			t.Errorf("%s: no hidden tests", ex.Path)
+		}
+		if _, err := os.Stat(filepath.Join("testdata/solutions", ex.Path)); err != nil {
+			t.Errorf("%s: no reference solution", ex.Path)
+		}
+	}
+}
+
+func TestCheckStub(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping go test invocation in short mode")
+	}
+
+	list, err := Load("../../exercises")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ex := range list {
+		if ex.Name != "09-generics" {
+			continue
+		}
+		res, err := Check(context.Background(), ex, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Passed {
+			t.Error("the unsolved stub passed its hidden tests")
+		}
+		return
+	}
+	t.Fatal("09-generics exercise not found")
+}
+
+func TestCheckSolutions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping go test invocation in short mode")
+	}
+
+	list, err := Load("../../exercises")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ex := range list {
+		t.Run(ex.Path, func(t *testing.T) {
+			t.Parallel()
+			ex.Dir = filepath.Join("testdata/solutions", ex.Path)
+			res, err := Check(context.Background(), ex, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !res.Passed {
+				t.Errorf("the reference solution failed its hidden tests:\n%s", res.Output)
+			}
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	ex := Exercise{Path: "01-basics/09-generics", Hints: []string{"first", "second"}}
+	p := &Progress{Exercises: make(map[string]*Status)}
+	now := time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+	for _, want := range []string{"first", "second", "second"} {
+		if hint, _ := p.Record(ex, Result{}, now); hint != want {
+			t.Errorf("got hint %q, want %q", hint, want)
+		}
+	}
+
+	if hint, _ := p.Record(ex, Result{Passed: true}, now); hint != "" {
+		t.Errorf("got hint %q after passing", hint)
+	}
+	s := p.Status(ex.Path)
+	if !s.Passed || s.Attempts != 4 || !s.PassedAt.Equal(now) {
+		t.Errorf("got %+v, want passed on attempt 4 at %v", s, now)
+	}
+	if got := p.Passed([]Exercise{ex}); got != 1 {
+		t.Errorf("Passed() = %d, want 1", got)
+	}
+}
@@ -0,0 +1,97 @@
+package exercises
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Progress is the learner's record of attempts, stored as JSON
+type Progress struct {
+	Exercises map[string]*Status `json:"exercises"`
+}
+
+// Status tracks one exercise
+type Status struct {
+	Attempts   int       `json:"attempts"`
+	Passed     bool      `json:"passed"`
+	PassedAt   time.Time `json:"passed_at,omitempty"`
+	HintsShown int       `json:"hints_shown"`
+}
+
+// LoadProgress reads the progress file, returning empty progress if it
+// doesn't exist yet
+func LoadProgress(path string) (*Progress, error) {
+	p := &Progress{Exercises: make(map[string]*Status)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	if p.Exercises == nil {
+		p.Exercises = make(map[string]*Status)
+	}
+	return p, nil
+}
+
+// Save writes the progress file
+func (p *Progress) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Status returns the status of the exercise at path, creating it if needed
+func (p *Progress) Status(path string) *Status {
+	s, ok := p.Exercises[path]
+	if !ok {
+		s = &Status{}
+		p.Exercises[path] = s
+	}
+	return s
+}
+
+// Record updates the status of ex after a check and returns the hint to
+// show, if any. Every failed attempt reveals one more hint until they run
+// out; the last one is then repeated.
+func (p *Progress) Record(ex Exercise, res Result, now time.Time) (hint string, number int) {
+	s := p.Status(ex.Path)
+	s.Attempts++
+
+	if res.Passed {
+		if !s.Passed {
+			s.Passed = true
+			s.PassedAt = now
+		}
+		return "", 0
+	}
+
+	if len(ex.Hints) == 0 {
+		return "", 0
+	}
+	if s.HintsShown < len(ex.Hints) {
+		s.HintsShown++
+	}
+	return ex.Hints[s.HintsShown-1], s.HintsShown
+}
+
+// Passed returns how many of the given exercises have been passed
+func (p *Progress) Passed(list []Exercise) int {
+	n := 0
+	for _, ex := range list {
+		if s, ok := p.Exercises[ex.Path]; ok && s.Passed {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,44 @@
+package iterators
+
+import "iter"
+
+/**
+ * Exercise: Range Iterators
+ *
+ * Since Go 1.23, range also works over functions that take a yield
+ * callback. Write two such iterators; both must stop as soon as yield
+ * returns false, which is what a break in the loop body does.
+ *
+ * Run "go run . check range-iterators" from the repository root to test your solution.
+ */
+
+/**
+ * Backward yields the index and value of every element of s, last first
+ * @param s: slice to walk
+ * @return: an iterator for "for i, v := range Backward(s)"
+ */
+func Backward[T any](s []T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for i := len(s) - 1; i >= 0; i-- {
+			if !yield(i, s[i]) {
+				return
+			}
+		}
+	}
+}
+
+/**
+ * Filter yields the values of seq for which keep returns true
+ * @param seq: values to filter
+ * @param keep: reports whether a value is yielded
+ * @return: an iterator over the kept values
+ */
+func Filter[T any](seq iter.Seq[T], keep func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range seq {
+			if keep(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
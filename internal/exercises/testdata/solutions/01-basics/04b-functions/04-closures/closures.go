@@ -0,0 +1,44 @@
+package closures
+
+/**
+ * Exercise: Closures
+ *
+ * Both functions return other functions that share state captured from
+ * the enclosing scope.
+ *
+ * Run "go run . check closures" from the repository root to test your solution.
+ */
+
+/**
+ * Counter returns two functions sharing one counter.
+ * inc adds one and returns the new value, reset sets it back to zero.
+ * Separate calls to Counter must return independent counters.
+ */
+func Counter() (inc func() int, reset func()) {
+	count := 0
+	inc = func() int {
+		count++
+		return count
+	}
+	reset = func() {
+		count = 0
+	}
+	return inc, reset
+}
+
+/**
+ * Memoize wraps f so that it is only called once for each distinct argument.
+ * Later calls with the same argument return the cached result.
+ * @param f: function to memoize
+ */
+func Memoize(f func(int) int) func(int) int {
+	cache := make(map[int]int)
+	return func(n int) int {
+		if v, ok := cache[n]; ok {
+			return v
+		}
+		v := f(n)
+		cache[n] = v
+		return v
+	}
+}
@@ -0,0 +1,63 @@
+package shapes
+
+import "math"
+
+/**
+ * Exercise: Interfaces and Structs
+ *
+ * Make Rectangle and Circle satisfy the Shape interface and implement Largest.
+ *
+ * Run "go run . check interfaces" from the repository root to test your solution.
+ */
+
+/**
+ * Shape is implemented by anything with an area and a perimeter
+ */
+type Shape interface {
+	Area() float64
+	Perimeter() float64
+}
+
+/**
+ * Rectangle is an axis-aligned rectangle
+ */
+type Rectangle struct {
+	Width, Height float64
+}
+
+/**
+ * Circle is a circle with the given radius
+ */
+type Circle struct {
+	Radius float64
+}
+
+func (r Rectangle) Area() float64 {
+	return r.Width * r.Height
+}
+
+func (r Rectangle) Perimeter() float64 {
+	return 2 * (r.Width + r.Height)
+}
+
+func (c Circle) Area() float64 {
+	return math.Pi * c.Radius * c.Radius
+}
+
+func (c Circle) Perimeter() float64 {
+	return 2 * math.Pi * c.Radius
+}
+
+/**
+ * Largest returns the shape with the biggest area, or nil if there are none
+ * @param shapes: shapes to compare
+ */
+func Largest(shapes ...Shape) Shape {
+	var largest Shape
+	for _, s := range shapes {
+		if largest == nil || s.Area() > largest.Area() {
+			largest = s
+		}
+	}
+	return largest
+}
@@ -0,0 +1,69 @@
+package embedding
+
+import "fmt"
+
+/**
+ * Exercise: Struct Embedding
+ *
+ * Reuse BaseLogger through embedding instead of forwarding each method by
+ * hand, and override a promoted method without losing the original.
+ *
+ * Run "go run . check struct-embedding" from the repository root to test your solution.
+ */
+
+/**
+ * Logger is implemented by anything that records messages
+ */
+type Logger interface {
+	Log(message string)
+	Lines() []string
+}
+
+/**
+ * BaseLogger records messages with a prefix
+ */
+type BaseLogger struct {
+	prefix string
+	lines  []string
+}
+
+func NewBaseLogger(prefix string) *BaseLogger {
+	return &BaseLogger{prefix: prefix}
+}
+
+func (b *BaseLogger) Log(message string) {
+	b.lines = append(b.lines, fmt.Sprintf("%s: %s", b.prefix, message))
+}
+
+func (b *BaseLogger) Lines() []string {
+	return b.lines
+}
+
+/**
+ * Service is a named service that logs through a BaseLogger.
+ * It must satisfy Logger without methods of its own.
+ */
+type Service struct {
+	*BaseLogger
+	Name string
+}
+
+/**
+ * NewService returns a Service whose messages are prefixed with its name
+ * @param name: name of the service
+ */
+func NewService(name string) *Service {
+	return &Service{BaseLogger: NewBaseLogger(name), Name: name}
+}
+
+/**
+ * TimedService is a Service whose messages also carry how long the
+ * operation took, e.g. "api: saved (12ms)"
+ */
+type TimedService struct {
+	*Service
+}
+
+func (s TimedService) Log(message string, ms int) {
+	s.Service.Log(fmt.Sprintf("%s (%dms)", message, ms))
+}
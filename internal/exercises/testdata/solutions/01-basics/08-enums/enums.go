@@ -0,0 +1,99 @@
+package enums
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/**
+ * Exercise: Enums
+ *
+ * Direction and Permission are the kinds of enums from the 08-enums
+ * example. Make them safe to print, parse and combine.
+ *
+ * Run "go run . check enums" from the repository root to test your solution.
+ */
+
+/**
+ * Direction is a compass direction
+ */
+type Direction int
+
+const (
+	North Direction = iota
+	East
+	South
+	West
+)
+
+// ErrUnknownDirection is returned (wrapped) by ParseDirection
+var ErrUnknownDirection = errors.New("unknown direction")
+
+/**
+ * String returns "North", "East", "South" or "West", and "Direction(n)"
+ * for any other value instead of panicking
+ */
+func (d Direction) String() string {
+	if d < North || d > West {
+		return fmt.Sprintf("Direction(%d)", int(d))
+	}
+	return [...]string{"North", "East", "South", "West"}[d]
+}
+
+/**
+ * ParseDirection is the reverse of String for the four directions, ignoring
+ * case. Any other input returns an error wrapping ErrUnknownDirection.
+ * @param s: name of a direction, e.g. "north"
+ */
+func ParseDirection(s string) (Direction, error) {
+	for d := North; d <= West; d++ {
+		if strings.EqualFold(s, d.String()) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownDirection, s)
+}
+
+/**
+ * Turn returns the direction after a quarter turn, clockwise if right is
+ * true, e.g. West turned right is North
+ */
+func (d Direction) Turn(right bool) Direction {
+	if right {
+		return (d + 1) % 4
+	}
+	return (d + 3) % 4
+}
+
+/**
+ * Permission is a set of flags that can be combined with |
+ */
+type Permission uint
+
+const (
+	Read Permission = 1 << iota
+	Write
+	Execute
+)
+
+/**
+ * String lists the flags that are set, in the order read, write, execute,
+ * e.g. "read|execute", or "none" if no flag is set
+ */
+func (p Permission) String() string {
+	var names []string
+	if p&Read != 0 {
+		names = append(names, "read")
+	}
+	if p&Write != 0 {
+		names = append(names, "write")
+	}
+	if p&Execute != 0 {
+		names = append(names, "execute")
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
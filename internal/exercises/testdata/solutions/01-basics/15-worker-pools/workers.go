@@ -0,0 +1,40 @@
+package workers
+
+import "sync"
+
+/**
+ * Exercise: Worker Pools
+ *
+ * Apply f to every input using a fixed number of worker goroutines.
+ *
+ * Run "go run . check worker-pools" from the repository root to test your solution.
+ */
+
+/**
+ * ParallelMap returns f applied to every input, in input order.
+ * At most workers calls to f may run at the same time, and at least one
+ * worker is used even if workers is zero or negative.
+ * @param inputs: values to process
+ * @param workers: number of worker goroutines
+ * @param f: function to apply
+ */
+func ParallelMap(inputs []int, workers int, f func(int) int) []int {
+	results := make([]int, len(inputs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = f(inputs[i])
+			}
+		}()
+	}
+	for i := range inputs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
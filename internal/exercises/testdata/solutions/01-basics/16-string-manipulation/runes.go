@@ -0,0 +1,42 @@
+package runes
+
+import "unicode"
+
+/**
+ * Exercise: String Manipulation
+ *
+ * Strings are byte slices, but text is made of runes. Both functions must
+ * handle multi-byte characters such as "héllo" or "日本".
+ *
+ * Run "go run . check string-manipulation" from the repository root to test your solution.
+ */
+
+/**
+ * Reverse returns s with its runes in reverse order
+ */
+func Reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+/**
+ * IsPalindrome reports whether s reads the same backwards, ignoring case,
+ * spaces and punctuation, e.g. "A man, a plan, a canal: Panama"
+ */
+func IsPalindrome(s string) bool {
+	var letters []rune
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			letters = append(letters, unicode.ToLower(r))
+		}
+	}
+	for i, j := 0, len(letters)-1; i < j; i, j = i+1, j-1 {
+		if letters[i] != letters[j] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package formats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+/**
+ * Exercise: Data Formats
+ *
+ * Person is encoded to JSON by another service that expects lowercase keys
+ * and no "email" key when there is no email address. Incoming lists of
+ * people must not contain keys that Person doesn't know about, so typos
+ * don't go unnoticed.
+ *
+ * Run "go run . check data-formats" from the repository root to test your solution.
+ */
+
+/**
+ * Person is encoded as {"name":"Alice","age":30,"email":"alice@example.com"}
+ */
+type Person struct {
+	Name  string `json:"name"`
+	Age   int    `json:"age"`
+	Email string `json:"email,omitempty"`
+}
+
+/**
+ * DecodePeople reads a JSON array of people from r. An object with a key
+ * that isn't a field of Person is an error.
+ * @param r: JSON input
+ * @return: the people, or an error if the input isn't a valid list of people
+ */
+func DecodePeople(r io.Reader) ([]Person, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var people []Person
+	if err := dec.Decode(&people); err != nil {
+		return nil, fmt.Errorf("decode people: %w", err)
+	}
+	return people, nil
+}
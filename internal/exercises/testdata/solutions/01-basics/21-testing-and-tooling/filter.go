@@ -0,0 +1,51 @@
+package tooling
+
+import (
+	"regexp"
+	"strings"
+)
+
+/**
+ * Exercise: Testing and Tooling
+ *
+ * lineFilter is the function from the 21-testing-and-tooling example. It
+ * drops every line containing one of the filters as a plain substring.
+ * Change it so each filter is a regular expression instead.
+ *
+ * Run "go run . check testing-and-tooling" from the repository root to test your solution.
+ */
+
+/**
+ * lineFilter removes every line of input that matches any of the patterns
+ * @param input: text to filter
+ * @param patterns: regular expressions (regexp syntax), e.g. `^#` or `error|warn`
+ * @return: the remaining lines, or an error if a pattern doesn't compile
+ */
+func lineFilter(input string, patterns []string) (string, error) {
+	filters := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return "", err
+		}
+		filters[i] = re
+	}
+
+	lines := strings.Split(input, "\n")
+	var result []string
+
+	for _, line := range lines {
+		include := true
+		for _, filter := range filters {
+			if filter.MatchString(line) {
+				include = false
+				break
+			}
+		}
+		if include {
+			result = append(result, line)
+		}
+	}
+
+	return strings.Join(result, "\n"), nil
+}
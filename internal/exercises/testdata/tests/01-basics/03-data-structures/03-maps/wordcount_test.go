@@ -0,0 +1,26 @@
+package wordcount
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWordCount(t *testing.T) {
+	got := WordCount("Go go GO\nmaps  are\tfun maps")
+	want := map[string]int{"go": 3, "maps": 2, "are": 1, "fun": 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WordCount = %v, want %v", got, want)
+	}
+
+	if got := WordCount(""); len(got) != 0 {
+		t.Errorf("WordCount(\"\") = %v, want an empty map", got)
+	}
+}
+
+func TestInvert(t *testing.T) {
+	got := Invert(map[string]int{"carol": 92, "alice": 95, "bob": 92})
+	want := map[int][]string{92: {"bob", "carol"}, 95: {"alice"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Invert = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+package iterators
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestBackward(t *testing.T) {
+	var indexes []int
+	var values []string
+	for i, v := range Backward([]string{"a", "b", "c"}) {
+		indexes = append(indexes, i)
+		values = append(values, v)
+	}
+	if want := []int{2, 1, 0}; !reflect.DeepEqual(indexes, want) {
+		t.Errorf("got indexes %v, want %v", indexes, want)
+	}
+	if want := []string{"c", "b", "a"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("got values %v, want %v", values, want)
+	}
+
+	for range Backward([]int(nil)) {
+		t.Error("Backward(nil) yielded a value")
+	}
+}
+
+func TestBackwardBreak(t *testing.T) {
+	var got []int
+	for _, v := range Backward([]int{1, 2, 3, 4}) {
+		if v == 2 {
+			break
+		}
+		got = append(got, v)
+	}
+	if want := []int{4, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	even := func(n int) bool { return n%2 == 0 }
+	got := slices.Collect(Filter(slices.Values([]int{1, 2, 3, 4, 5, 6}), even))
+	if want := []int{2, 4, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Calling yield after a break panics, so this fails if Filter doesn't stop
+	var first []int
+	for v := range Filter(slices.Values([]int{1, 2, 3, 4, 5, 6}), even) {
+		first = append(first, v)
+		break
+	}
+	if want := []int{2}; !reflect.DeepEqual(first, want) {
+		t.Errorf("got %v after break, want %v", first, want)
+	}
+}
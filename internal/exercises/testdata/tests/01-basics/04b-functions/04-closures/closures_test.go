@@ -0,0 +1,41 @@
+package closures
+
+import "testing"
+
+func TestCounter(t *testing.T) {
+	inc, reset := Counter()
+	for want := 1; want <= 3; want++ {
+		if got := inc(); got != want {
+			t.Fatalf("inc() = %d, want %d", got, want)
+		}
+	}
+	reset()
+	if got := inc(); got != 1 {
+		t.Errorf("inc() after reset = %d, want 1", got)
+	}
+
+	other, _ := Counter()
+	if got := other(); got != 1 {
+		t.Errorf("a new counter started at %d, want 1", got-1)
+	}
+}
+
+func TestMemoize(t *testing.T) {
+	calls := 0
+	square := Memoize(func(n int) int {
+		calls++
+		return n * n
+	})
+
+	for i := 0; i < 3; i++ {
+		if got := square(4); got != 16 {
+			t.Fatalf("square(4) = %d, want 16", got)
+		}
+	}
+	if got := square(5); got != 25 {
+		t.Errorf("square(5) = %d, want 25", got)
+	}
+	if calls != 2 {
+		t.Errorf("f was called %d times, want 2", calls)
+	}
+}
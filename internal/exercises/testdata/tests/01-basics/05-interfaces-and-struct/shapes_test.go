@@ -0,0 +1,46 @@
+package shapes
+
+import (
+	"math"
+	"testing"
+)
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestShapes(t *testing.T) {
+	tests := []struct {
+		name      string
+		shape     Shape
+		area      float64
+		perimeter float64
+	}{
+		{name: "Rectangle", shape: Rectangle{Width: 3, Height: 4}, area: 12, perimeter: 14},
+		{name: "Circle", shape: Circle{Radius: 2}, area: 4 * math.Pi, perimeter: 4 * math.Pi},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.shape.Area(); !approx(got, tt.area) {
+				t.Errorf("Area() = %v, want %v", got, tt.area)
+			}
+			if got := tt.shape.Perimeter(); !approx(got, tt.perimeter) {
+				t.Errorf("Perimeter() = %v, want %v", got, tt.perimeter)
+			}
+		})
+	}
+}
+
+func TestLargest(t *testing.T) {
+	big := Circle{Radius: 3}
+	if got := Largest(Rectangle{Width: 2, Height: 2}, big, Rectangle{Width: 6, Height: 6}); got != (Rectangle{Width: 6, Height: 6}) {
+		t.Errorf("Largest = %v, want the 6x6 rectangle", got)
+	}
+	if got := Largest(Rectangle{Width: 1, Height: 1}, big); got != big {
+		t.Errorf("Largest = %v, want %v", got, big)
+	}
+	if got := Largest(); got != nil {
+		t.Errorf("Largest() = %v, want nil", got)
+	}
+}
@@ -0,0 +1,55 @@
+package account
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithdraw(t *testing.T) {
+	a := &Account{Balance: 100}
+	if err := a.Withdraw(30); err != nil {
+		t.Fatalf("Withdraw(30) = %v, want nil", err)
+	}
+	if a.Balance != 70 {
+		t.Errorf("Balance = %d, want 70", a.Balance)
+	}
+}
+
+func TestWithdrawErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int
+		target error
+	}{
+		{name: "Insufficient funds", amount: 500, target: ErrInsufficientFunds},
+		{name: "Zero amount", amount: 0, target: ErrInvalidAmount},
+		{name: "Negative amount", amount: -5, target: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Account{Balance: 100}
+			err := a.Withdraw(tt.amount)
+			if err == nil {
+				t.Fatal("Withdraw returned nil, want an error")
+			}
+			if a.Balance != 100 {
+				t.Errorf("Balance changed to %d on a failed withdrawal", a.Balance)
+			}
+			if !errors.Is(err, tt.target) {
+				t.Errorf("errors.Is(err, %v) = false for %v", tt.target, err)
+			}
+
+			var txErr *TransactionError
+			if !errors.As(err, &txErr) {
+				t.Fatalf("errors.As(err, *TransactionError) = false for %v", err)
+			}
+			if txErr.Op != "withdraw" || txErr.Amount != tt.amount {
+				t.Errorf("got Op %q Amount %d, want \"withdraw\" %d", txErr.Op, txErr.Amount, tt.amount)
+			}
+			if err.Error() == "" {
+				t.Error("Error() returned an empty message")
+			}
+		})
+	}
+}
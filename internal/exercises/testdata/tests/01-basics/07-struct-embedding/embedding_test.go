@@ -0,0 +1,31 @@
+package embedding
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestService(t *testing.T) {
+	var logger Logger = NewService("api")
+	logger.Log("started")
+	logger.Log("ready")
+
+	want := []string{"api: started", "api: ready"}
+	if got := logger.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Lines() = %q, want %q", got, want)
+	}
+}
+
+func TestTimedService(t *testing.T) {
+	s := TimedService{NewService("db")}
+	s.Log("saved", 12)
+	s.Service.Log("closed")
+
+	want := []string{"db: saved (12ms)", "db: closed"}
+	if got := s.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Lines() = %q, want %q", got, want)
+	}
+	if s.Name != "db" {
+		t.Errorf("Name = %q, want \"db\"", s.Name)
+	}
+}
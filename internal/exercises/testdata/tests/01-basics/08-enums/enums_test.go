@@ -0,0 +1,74 @@
+package enums
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDirectionString(t *testing.T) {
+	tests := []struct {
+		d        Direction
+		expected string
+	}{
+		{d: North, expected: "North"},
+		{d: West, expected: "West"},
+		{d: Direction(7), expected: "Direction(7)"},
+		{d: Direction(-1), expected: "Direction(-1)"},
+	}
+
+	for _, tt := range tests {
+		if got := fmt.Sprint(tt.d); got != tt.expected {
+			t.Errorf("got %q, want %q", got, tt.expected)
+		}
+	}
+}
+
+func TestParseDirection(t *testing.T) {
+	for _, d := range []Direction{North, East, South, West} {
+		got, err := ParseDirection(d.String())
+		if err != nil || got != d {
+			t.Errorf("ParseDirection(%q) = %v, %v, want %v", d.String(), got, err, d)
+		}
+	}
+	if got, err := ParseDirection("sOuTh"); err != nil || got != South {
+		t.Errorf("ParseDirection(\"sOuTh\") = %v, %v, want South", got, err)
+	}
+	if _, err := ParseDirection("up"); !errors.Is(err, ErrUnknownDirection) {
+		t.Errorf("ParseDirection(\"up\") returned %v, want ErrUnknownDirection", err)
+	}
+}
+
+func TestTurn(t *testing.T) {
+	if got := West.Turn(true); got != North {
+		t.Errorf("West.Turn(true) = %v, want North", got)
+	}
+	if got := North.Turn(false); got != West {
+		t.Errorf("North.Turn(false) = %v, want West", got)
+	}
+	d := East
+	for i := 0; i < 4; i++ {
+		d = d.Turn(true)
+	}
+	if d != East {
+		t.Errorf("four right turns from East ended at %v", d)
+	}
+}
+
+func TestPermissionString(t *testing.T) {
+	tests := []struct {
+		p        Permission
+		expected string
+	}{
+		{p: 0, expected: "none"},
+		{p: Read, expected: "read"},
+		{p: Execute | Read, expected: "read|execute"},
+		{p: Read | Write | Execute, expected: "read|write|execute"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.p.String(); got != tt.expected {
+			t.Errorf("Permission(%d).String() = %q, want %q", uint(tt.p), got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package generics
+
+import "testing"
+
+func TestStackPeek(t *testing.T) {
+	s := NewStack[string]()
+	if _, ok := s.Peek(); ok {
+		t.Error("Peek on an empty stack reported ok")
+	}
+
+	s.Push("a")
+	s.Push("b")
+	for i := 0; i < 2; i++ {
+		if got, ok := s.Peek(); !ok || got != "b" {
+			t.Fatalf("Peek() = %q, %v, want \"b\", true", got, ok)
+		}
+	}
+	if got, _ := s.Pop(); got != "b" {
+		t.Errorf("Peek removed the item, Pop() = %q", got)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	q := NewQueue[int]()
+	if _, ok := q.Dequeue(); ok {
+		t.Error("Dequeue on an empty queue reported ok")
+	}
+
+	for i := 1; i <= 3; i++ {
+		q.Enqueue(i)
+	}
+	if q.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", q.Len())
+	}
+	for want := 1; want <= 3; want++ {
+		if got, ok := q.Dequeue(); !ok || got != want {
+			t.Fatalf("Dequeue() = %d, %v, want %d, true", got, ok, want)
+		}
+	}
+	if q.Len() != 0 {
+		t.Errorf("Len() = %d after draining, want 0", q.Len())
+	}
+
+	words := NewQueue[string]()
+	words.Enqueue("hello")
+	if got, _ := words.Dequeue(); got != "hello" {
+		t.Errorf("Dequeue() = %q, want \"hello\"", got)
+	}
+}
@@ -0,0 +1,47 @@
+package channels
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// collect drains ch, failing the test if it isn't closed in time
+func collect(t *testing.T, ch <-chan int) []int {
+	t.Helper()
+	var got []int
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case v, ok := <-ch:
+			if !ok {
+				return got
+			}
+			got = append(got, v)
+		case <-timeout:
+			t.Fatalf("channel was not closed, received %v so far", got)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	got := collect(t, Generate(3, 1, 2))
+	if want := []int{3, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Generate = %v, want %v", got, want)
+	}
+	if got := collect(t, Generate()); len(got) != 0 {
+		t.Errorf("Generate() = %v, want nothing", got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	got := collect(t, Merge(Generate(1, 2, 3), Generate(4, 5), Generate()))
+	sort.Ints(got)
+	if want := []int{1, 2, 3, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge = %v, want %v", got, want)
+	}
+	if got := collect(t, Merge()); len(got) != 0 {
+		t.Errorf("Merge() = %v, want nothing", got)
+	}
+}
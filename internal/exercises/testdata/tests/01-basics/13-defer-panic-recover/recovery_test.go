@@ -0,0 +1,47 @@
+package recovery
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSafeCall(t *testing.T) {
+	if err := SafeCall(func() {}); err != nil {
+		t.Errorf("SafeCall of a function that returns = %v, want nil", err)
+	}
+
+	err := SafeCall(func() { panic("boom") })
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("got %v, want an error mentioning the panic value \"boom\"", err)
+	}
+
+	errBroken := errors.New("broken")
+	if err := SafeCall(func() { panic(errBroken) }); !errors.Is(err, errBroken) {
+		t.Errorf("got %v, want an error wrapping the panic value", err)
+	}
+
+	var rtErr runtime.Error
+	err = SafeCall(func() {
+		var s []int
+		_ = s[3]
+	})
+	if !errors.As(err, &rtErr) {
+		t.Errorf("got %v, want an error wrapping the runtime.Error", err)
+	}
+}
+
+func TestDivide(t *testing.T) {
+	if got, err := Divide(7, 2); got != 3 || err != nil {
+		t.Errorf("Divide(7, 2) = %d, %v, want 3, nil", got, err)
+	}
+
+	got, err := Divide(1, 0)
+	if err == nil {
+		t.Fatalf("Divide(1, 0) = %d, nil, want an error", got)
+	}
+	if got != 0 {
+		t.Errorf("Divide(1, 0) returned %d with the error, want 0", got)
+	}
+}
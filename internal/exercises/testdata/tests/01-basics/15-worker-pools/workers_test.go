@@ -0,0 +1,53 @@
+package workers
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallelMap(t *testing.T) {
+	inputs := []int{5, 4, 3, 2, 1, 0, 9, 8}
+	got := ParallelMap(inputs, 3, func(n int) int {
+		time.Sleep(time.Duration(n) * time.Millisecond) // Finish out of order
+		return n * 10
+	})
+	want := []int{50, 40, 30, 20, 10, 0, 90, 80}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParallelMap = %v, want %v", got, want)
+	}
+}
+
+func TestParallelMapConcurrencyLimit(t *testing.T) {
+	var running, peak int64
+	inputs := make([]int, 20)
+	ParallelMap(inputs, 4, func(n int) int {
+		now := atomic.AddInt64(&running, 1)
+		for {
+			old := atomic.LoadInt64(&peak)
+			if now <= old || atomic.CompareAndSwapInt64(&peak, old, now) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt64(&running, -1)
+		return n
+	})
+
+	if peak > 4 {
+		t.Errorf("%d calls ran at once, want at most 4", peak)
+	}
+	if peak < 2 {
+		t.Errorf("only %d call ran at once, the work wasn't parallel", peak)
+	}
+}
+
+func TestParallelMapEdgeCases(t *testing.T) {
+	if got := ParallelMap(nil, 2, func(n int) int { return n }); len(got) != 0 {
+		t.Errorf("ParallelMap(nil) = %v, want empty", got)
+	}
+	if got := ParallelMap([]int{1, 2}, 0, func(n int) int { return n + 1 }); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Errorf("ParallelMap with 0 workers = %v, want [2 3]", got)
+	}
+}
@@ -0,0 +1,40 @@
+package runes
+
+import "testing"
+
+func TestReverse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "", expected: ""},
+		{input: "hello", expected: "olleh"},
+		{input: "héllo", expected: "olléh"},
+		{input: "日本語", expected: "語本日"},
+	}
+
+	for _, tt := range tests {
+		if got := Reverse(tt.input); got != tt.expected {
+			t.Errorf("Reverse(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestIsPalindrome(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{input: "racecar", expected: true},
+		{input: "A man, a plan, a canal: Panama", expected: true},
+		{input: "été", expected: true},
+		{input: "hello", expected: false},
+		{input: "ab", expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPalindrome(tt.input); got != tt.expected {
+			t.Errorf("IsPalindrome(%q) = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
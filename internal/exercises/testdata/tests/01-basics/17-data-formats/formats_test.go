@@ -0,0 +1,70 @@
+package formats
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPersonJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		person   Person
+		expected string
+	}{
+		{
+			name:     "With email",
+			person:   Person{Name: "Alice", Age: 30, Email: "alice@example.com"},
+			expected: `{"name":"Alice","age":30,"email":"alice@example.com"}`,
+		},
+		{
+			name:     "Without email",
+			person:   Person{Name: "Bob", Age: 25},
+			expected: `{"name":"Bob","age":25}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.person)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("got %s, want %s", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDecodePeople(t *testing.T) {
+	input := `[{"name":"Alice","age":30,"email":"alice@example.com"},{"name":"Bob","age":25}]`
+	got, err := DecodePeople(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Person{{Name: "Alice", Age: 30, Email: "alice@example.com"}, {Name: "Bob", Age: 25}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodePeopleErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "Unknown field", input: `[{"name":"Alice","agee":30}]`},
+		{name: "Wrong type", input: `[{"name":"Alice","age":"thirty"}]`},
+		{name: "Not JSON", input: `name=Alice`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if people, err := DecodePeople(strings.NewReader(tt.input)); err == nil {
+				t.Errorf("got %+v, want an error", people)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package tooling
+
+import "testing"
+
+func TestLineFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		patterns []string
+		expected string
+	}{
+		{
+			name:     "No filters",
+			input:    "line1\nline2\nline3",
+			patterns: []string{},
+			expected: "line1\nline2\nline3",
+		},
+		{
+			name:     "Plain text still works",
+			input:    "line1\nline2\nline3",
+			patterns: []string{"line2"},
+			expected: "line1\nline3",
+		},
+		{
+			name:     "Anchored pattern",
+			input:    "# comment\ncode # not a comment\n#another",
+			patterns: []string{`^#`},
+			expected: "code # not a comment",
+		},
+		{
+			name:     "Alternation and several patterns",
+			input:    "ERROR disk\nwarn: cpu\ninfo ok\ndebug x",
+			patterns: []string{`(?i)error|warn`, `^debug`},
+			expected: "info ok",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := lineFilter(tt.input, tt.patterns)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLineFilterInvalidPattern(t *testing.T) {
+	if _, err := lineFilter("line", []string{"("}); err == nil {
+		t.Error("expected an error for an invalid regular expression")
+	}
+}
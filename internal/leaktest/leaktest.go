@@ -0,0 +1,111 @@
+// Package leaktest finds goroutines that a test leaves running, such as a
+// sender blocked forever on a channel nobody reads anymore.
+package leaktest
+
+import (
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// grace is how long goroutines started by a test get to exit once it has
+// finished. Exiting goroutines need a moment to be gone.
+var grace = time.Second
+
+// known matches goroutines that are not the test's to stop: the ones the
+// testing package starts for subtests, and the ones os/signal starts on
+// the first signal.Notify and keeps for the life of the process
+var known = []string{
+	"created by testing.(*T).Run",
+	"created by testing.(*B).run1",
+	"created by testing.(*B).doBench",
+	"os/signal.signal_recv",
+	"os/signal.loop",
+	"runtime.ensureSigM",
+}
+
+// Check fails the test if goroutines started while it ran are still
+// running a grace period after it finished, including its deferred calls
+// and the cleanups registered after Check. The failure shows their stacks.
+//
+// Goroutines whose stack contains any of ignore are left out, for tests of
+// code that deliberately keeps a goroutine for the life of the process.
+// Check does not work with parallel tests, whose goroutines it can't tell
+// apart.
+func Check(t testing.TB, ignore ...string) {
+	t.Helper()
+	before := make(map[int]bool)
+	for _, g := range running() {
+		before[g.id] = true
+	}
+
+	t.Cleanup(func() {
+		var leaked []goroutine
+		deadline := time.Now().Add(grace)
+		for {
+			leaked = leaked[:0]
+			for _, g := range running() {
+				if !before[g.id] && !g.matches(known) && !g.matches(ignore) {
+					leaked = append(leaked, g)
+				}
+			}
+			if len(leaked) == 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if len(leaked) > 0 {
+			stacks := make([]string, len(leaked))
+			for i, g := range leaked {
+				stacks[i] = g.stack
+			}
+			t.Errorf("%d goroutines leaked:\n\n%s", len(leaked), strings.Join(stacks, "\n\n"))
+		}
+	})
+}
+
+// goroutine is one entry of a full stack dump
+type goroutine struct {
+	id    int
+	stack string // Starting with "goroutine <id> [<state>]:"
+}
+
+// matches reports whether the stack contains any of the patterns
+func (g goroutine) matches(patterns []string) bool {
+	return slices.ContainsFunc(patterns, func(p string) bool {
+		return strings.Contains(g.stack, p)
+	})
+}
+
+// running returns every goroutine except the calling one
+func running() []goroutine {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	// The first entry is always the calling goroutine
+	entries := strings.Split(strings.TrimSpace(string(buf)), "\n\n")[1:]
+	goroutines := make([]goroutine, 0, len(entries))
+	for _, e := range entries {
+		fields := strings.Fields(e)
+		if len(fields) < 2 || fields[0] != "goroutine" {
+			continue
+		}
+		id, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		goroutines = append(goroutines, goroutine{id: id, stack: e})
+	}
+	return goroutines
+}
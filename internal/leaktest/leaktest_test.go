@@ -0,0 +1,92 @@
+package leaktest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeT records what Check reports instead of failing the real test
+type fakeT struct {
+	testing.TB
+	cleanups []func()
+	errors   []string
+}
+
+func (f *fakeT) Helper()           {}
+func (f *fakeT) Cleanup(fn func()) { f.cleanups = append(f.cleanups, fn) }
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+// finish runs the cleanups the way the testing package does
+func (f *fakeT) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+// blockOn stands for a goroutine stuck on a channel
+func blockOn(ch chan struct{}) {
+	<-ch
+}
+
+func TestCheck(t *testing.T) {
+	defer func(d time.Duration) { grace = d }(grace)
+	grace = 100 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		run      func(release chan struct{})
+		ignore   []string
+		expected bool // Whether a leak is reported
+	}{
+		{
+			name:     "nothing started",
+			run:      func(chan struct{}) {},
+			expected: false,
+		},
+		{
+			name: "exits within the grace period",
+			run: func(chan struct{}) {
+				go time.Sleep(10 * time.Millisecond)
+			},
+			expected: false,
+		},
+		{
+			name: "blocked forever",
+			run: func(release chan struct{}) {
+				go blockOn(release)
+			},
+			expected: true,
+		},
+		{
+			name: "ignored",
+			run: func(release chan struct{}) {
+				go blockOn(release)
+			},
+			ignore:   []string{"leaktest.blockOn"},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			defer close(release)
+
+			f := &fakeT{TB: t}
+			Check(f, tt.ignore...)
+			tt.run(release)
+			f.finish()
+
+			if got := len(f.errors) > 0; got != tt.expected {
+				t.Fatalf("got leak reported %v, want %v: %q", got, tt.expected, f.errors)
+			}
+			if tt.expected && !strings.Contains(f.errors[0], "leaktest.blockOn") {
+				t.Errorf("report doesn't show the leaked stack:\n%s", f.errors[0])
+			}
+		})
+	}
+}
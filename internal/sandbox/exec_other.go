@@ -0,0 +1,23 @@
+//go:build !unix
+
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+)
+
+func command(ctx context.Context, lim Limits, name string, args ...string) (*exec.Cmd, error) {
+	return nil, errors.New("sandboxed execution requires a unix system")
+}
+
+func cleanup(cmd *exec.Cmd) {}
+
+func exitStatus(err *exec.ExitError) (int, string) {
+	return err.ExitCode(), ""
+}
+
+func cpuLimitSignal(sig string) bool {
+	return false
+}
@@ -0,0 +1,61 @@
+//go:build unix
+
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// command returns a Cmd that runs name with args under the rlimits in lim.
+// Go can't set rlimits on a child directly, so a shell applies them with
+// ulimit and then execs the program in its place. The program leads a new process group,
+// which is killed as a whole when ctx is done so that anything it started
+// goes with it.
+func command(ctx context.Context, lim Limits, name string, args ...string) (*exec.Cmd, error) {
+	var script []string
+	if lim.CPUTime > 0 {
+		script = append(script, fmt.Sprintf("ulimit -t %d", lim.cpuSeconds()))
+	}
+	if lim.Memory > 0 {
+		script = append(script, fmt.Sprintf("ulimit -v %d", lim.Memory>>10))
+	}
+	if lim.Output > 0 {
+		// Files are capped like output, in POSIX's 512-byte blocks
+		script = append(script, fmt.Sprintf("ulimit -f %d", max(lim.Output>>9, 1)))
+	}
+	script = append(script, `exec "$0" "$@"`)
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", append([]string{"-c", strings.Join(script, " && "), name}, args...)...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return cmd, nil
+}
+
+// cleanup kills whatever is left of the program's process group
+func cleanup(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// exitStatus reports the exit code, or -1 and the signal name if the
+// program was killed
+func exitStatus(err *exec.ExitError) (int, string) {
+	if ws, ok := err.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return -1, ws.Signal().String()
+	}
+	return err.ExitCode(), ""
+}
+
+// cpuLimitSignal reports whether sig, as returned by exitStatus, is one the
+// kernel sends when a process reaches RLIMIT_CPU. The Go runtime ignores
+// SIGXCPU, so Go programs run on until the SIGKILL at the hard limit.
+func cpuLimitSignal(sig string) bool {
+	return sig == syscall.SIGXCPU.String() || sig == syscall.SIGKILL.String()
+}
@@ -0,0 +1,291 @@
+// Package sandbox builds and runs single-file Go programs under resource
+// limits so teammates can edit and run examples from a browser.
+//
+// A program is compiled with "go build" in a fresh temporary module and then
+// started in its own process group with CPU time, address space and file size
+// rlimits. The build runs under the same CPU time and address space limits.
+// The wall-clock limit kills the whole group, and output beyond a fixed size
+// is discarded. This keeps runaway or careless programs in check;
+// it is not a security boundary, so only serve it to people you trust.
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/modfile"
+)
+
+// ErrNotMain is returned for source that isn't a main package
+var ErrNotMain = errors.New("source must be a main package")
+
+// Limits bounds the resources a program may use. A zero field means no
+// limit.
+type Limits struct {
+	CPUTime   time.Duration // CPU time (RLIMIT_CPU), rounded up to whole seconds
+	WallTime  time.Duration // Real time before the process group is killed
+	Memory    int64         // Address space in bytes (RLIMIT_AS)
+	Output    int           // Bytes kept per output stream
+	BuildTime time.Duration // Real time allowed for "go build"
+}
+
+// DefaultLimits returns limits suitable for the examples in this repository.
+// The Go runtime reserves a few hundred megabytes of address space at
+// startup, so Memory much below 1GiB stops programs from starting at all.
+func DefaultLimits() Limits {
+	return Limits{
+		CPUTime:   5 * time.Second,
+		WallTime:  10 * time.Second,
+		Memory:    1 << 30,
+		Output:    64 << 10,
+		BuildTime: 60 * time.Second,
+	}
+}
+
+// cpuSeconds is CPUTime rounded up to the whole seconds RLIMIT_CPU counts in
+func (l Limits) cpuSeconds() int64 {
+	return int64((l.CPUTime + time.Second - 1) / time.Second)
+}
+
+// Result is the outcome of building and running a program
+type Result struct {
+	Stdout      string `json:"stdout"`
+	Stderr      string `json:"stderr"`
+	ExitCode    int    `json:"exit_code"`        // -1 if the program was killed or never ran
+	Signal      string `json:"signal,omitempty"` // Signal that terminated the program
+	TimedOut    bool   `json:"timed_out"`        // Killed after Limits.WallTime
+	CPUExceeded bool   `json:"cpu_exceeded"`     // Killed after Limits.CPUTime
+	Truncated   bool   `json:"truncated"`        // Output exceeded Limits.Output
+	BuildFailed bool   `json:"build_failed"`     // The program didn't compile
+	BuildOutput string `json:"build_output,omitempty"`
+	DurationMS  int64  `json:"duration_ms"` // Run time, excluding the build
+}
+
+// Runner builds and runs programs
+type Runner struct {
+	Limits Limits
+
+	// ModulePath and ModuleDir, if set, let programs import packages from a
+	// local module, so examples using go-by-example/pkg/... run unchanged
+	ModulePath string
+	ModuleDir  string
+}
+
+// Run builds src as the main.go of a new module and runs it. Compile errors
+// and non-zero exits are reported in the Result; the error is only for
+// invalid input or problems running the sandbox itself.
+func (r *Runner) Run(ctx context.Context, src []byte) (Result, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "main.go", src, parser.PackageClauseOnly)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: %v", ErrNotMain, err)
+	}
+	if f.Name.Name != "main" {
+		return Result{}, fmt.Errorf("%w, not package %s", ErrNotMain, f.Name.Name)
+	}
+
+	tmp, err := os.MkdirTemp("", "go-by-example-sandbox-*")
+	if err != nil {
+		return Result{}, err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := r.writeModule(ctx, tmp, src); err != nil {
+		return Result{}, err
+	}
+
+	if out, err := r.build(ctx, tmp); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return Result{}, err
+		}
+		return Result{ExitCode: -1, BuildFailed: true, BuildOutput: out}, nil
+	}
+
+	return r.run(ctx, tmp)
+}
+
+// writeModule lays out main.go and go.mod in dir. The go directive is the
+// local module's, so programs get the language version and GODEBUG defaults
+// of the code they import, or else the go command's own, as with "go mod
+// init".
+func (r *Runner) writeModule(ctx context.Context, dir string, src []byte) error {
+	var goMod strings.Builder
+	if r.ModulePath == "" {
+		version, err := toolchainVersion(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&goMod, "module sandbox\n\ngo %s\n", version)
+	} else {
+		abs, err := filepath.Abs(r.ModuleDir)
+		if err != nil {
+			return err
+		}
+		version, err := goVersion(filepath.Join(abs, "go.mod"))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&goMod, "module sandbox\n\ngo %s\n", version)
+		fmt.Fprintf(&goMod, "\nrequire %s v0.0.0\n\nreplace %s => %s\n", r.ModulePath, r.ModulePath, abs)
+
+		// The local module's checksums cover its own dependencies
+		if sum, err := os.ReadFile(filepath.Join(abs, "go.sum")); err == nil {
+			if err := os.WriteFile(filepath.Join(dir, "go.sum"), sum, 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod.String()), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "main.go"), src, 0644)
+}
+
+// goVersion returns the go directive of the go.mod file at path
+func goVersion(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	f, err := modfile.ParseLax(path, data, nil)
+	if err != nil {
+		return "", err
+	}
+	if f.Go == nil {
+		return "", fmt.Errorf("sandbox: %s has no go directive", path)
+	}
+	return f.Go.Version, nil
+}
+
+// toolchainVersion returns the version of the go command, e.g. "1.25.0"
+func toolchainVersion(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "go", "env", "GOVERSION").Output()
+	if err != nil {
+		return "", fmt.Errorf("sandbox: go env GOVERSION: %w", err)
+	}
+	version, ok := strings.CutPrefix(strings.TrimSpace(string(out)), "go")
+	if !ok {
+		return "", fmt.Errorf("sandbox: unexpected go version %q", out)
+	}
+	return version, nil
+}
+
+// build compiles the module in dir into dir/prog and returns the compiler
+// output. Nothing is downloaded, so programs are limited to the standard
+// library and the local module. The go command and the compiler and linker
+// it starts each get the CPU time and address space limits of a program.
+// The file size limit is left out, as the binary and the build cache
+// outgrow any output cap.
+func (r *Runner) build(ctx context.Context, dir string) (string, error) {
+	if r.Limits.BuildTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Limits.BuildTime)
+		defer cancel()
+	}
+
+	lim := r.Limits
+	lim.Output = 0
+	cmd, err := command(ctx, lim, "go", "build", "-o", "prog", ".")
+	if err != nil {
+		return "", err
+	}
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod", "GOPROXY=off", "CGO_ENABLED=0")
+	out, err := cmd.CombinedOutput()
+	cleanup(cmd)
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("build did not finish within %v", r.Limits.BuildTime)
+	}
+	return strings.ReplaceAll(string(out), dir+string(filepath.Separator), ""), err
+}
+
+// run starts the compiled program under the limits and collects its output
+func (r *Runner) run(ctx context.Context, dir string) (Result, error) {
+	lim := r.Limits
+	runCtx := ctx
+	if lim.WallTime > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, lim.WallTime)
+		defer cancel()
+	}
+
+	cmd, err := command(runCtx, lim, filepath.Join(dir, "prog"))
+	if err != nil {
+		return Result{}, err
+	}
+	cmd.Dir = dir
+	cmd.Env = []string{"HOME=" + dir, "TMPDIR=" + dir, "PATH=" + os.Getenv("PATH")}
+	stdout := &limitedBuffer{max: lim.Output}
+	stderr := &limitedBuffer{max: lim.Output}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Don't wait forever for pipes held open by a background child
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	duration := time.Since(start)
+	cleanup(cmd)
+
+	res := Result{
+		Stdout:     stdout.String(),
+		Stderr:     stderr.String(),
+		Truncated:  stdout.truncated || stderr.truncated,
+		TimedOut:   ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded),
+		DurationMS: duration.Milliseconds(),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		res.ExitCode, res.Signal = exitStatus(exitErr)
+	case errors.Is(err, exec.ErrWaitDelay):
+		// The program exited, only a straggling child kept its output open
+		res.ExitCode, res.Signal = exitStatus(&exec.ExitError{ProcessState: cmd.ProcessState})
+	case err != nil:
+		return Result{}, err
+	}
+	if lim.CPUTime > 0 && !res.TimedOut && ctx.Err() == nil {
+		// Only RLIMIT_CPU kills the program before its wall-clock limit. The
+		// CPU time reported back isn't compared with the limit, as on a busy
+		// or virtualized machine it can fall far short of what the kernel
+		// counted against it
+		res.CPUExceeded = cpuLimitSignal(res.Signal)
+	}
+	if ctx.Err() != nil {
+		return res, ctx.Err()
+	}
+	return res, nil
+}
+
+// limitedBuffer keeps the first max bytes written to it and silently drops
+// the rest, so a chatty program can't block on a full pipe or exhaust memory
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.max - b.buf.Len(); b.max > 0 && n > room {
+		p = p[:max(room, 0)]
+		b.truncated = true
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
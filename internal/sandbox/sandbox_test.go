@@ -0,0 +1,294 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRunner(t *testing.T) *Runner {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds programs; skipped with -short")
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("sandboxed execution requires a unix system")
+	}
+	lim := DefaultLimits()
+	lim.WallTime = 3 * time.Second
+	lim.Output = 1024
+	return &Runner{Limits: lim}
+}
+
+func TestRun(t *testing.T) {
+	r := newTestRunner(t)
+
+	tests := []struct {
+		name     string
+		src      string
+		limits   func(*Limits)
+		expected func(t *testing.T, res Result)
+	}{
+		{
+			name: "hello",
+			src: `package main
+import "fmt"
+func main() { fmt.Println("hello") }`,
+			expected: func(t *testing.T, res Result) {
+				if res.Stdout != "hello\n" || res.ExitCode != 0 {
+					t.Errorf("got stdout %q exit %d, want %q exit 0", res.Stdout, res.ExitCode, "hello\n")
+				}
+			},
+		},
+		{
+			name: "exit code and stderr",
+			src: `package main
+import ("fmt"; "os")
+func main() { fmt.Fprintln(os.Stderr, "oops"); os.Exit(3) }`,
+			expected: func(t *testing.T, res Result) {
+				if res.Stderr != "oops\n" || res.ExitCode != 3 {
+					t.Errorf("got stderr %q exit %d, want %q exit 3", res.Stderr, res.ExitCode, "oops\n")
+				}
+			},
+		},
+		{
+			name: "build error",
+			src: `package main
+func main() { undefined() }`,
+			expected: func(t *testing.T, res Result) {
+				if !res.BuildFailed || !strings.Contains(res.BuildOutput, "main.go:2") {
+					t.Errorf("got build_failed %v output %q, want a failure at main.go:2", res.BuildFailed, res.BuildOutput)
+				}
+			},
+		},
+		{
+			name: "wall time",
+			src: `package main
+import "time"
+func main() { time.Sleep(time.Hour) }`,
+			limits: func(l *Limits) { l.WallTime = 500 * time.Millisecond },
+			expected: func(t *testing.T, res Result) {
+				if !res.TimedOut || res.Signal != "killed" {
+					t.Errorf("got timed_out %v signal %q, want a timeout and SIGKILL", res.TimedOut, res.Signal)
+				}
+			},
+		},
+		{
+			name: "cpu time",
+			src: `package main
+func main() { for {} }`,
+			limits: func(l *Limits) {
+				// Far more wall time than CPU time, for a busy machine
+				l.CPUTime = time.Second
+				l.WallTime = 30 * time.Second
+			},
+			expected: func(t *testing.T, res Result) {
+				if res.TimedOut || !res.CPUExceeded {
+					t.Errorf("got timed_out %v cpu_exceeded %v, want only the CPU limit", res.TimedOut, res.CPUExceeded)
+				}
+			},
+		},
+		{
+			name: "memory",
+			src: `package main
+import "fmt"
+func main() {
+	var keep [][]byte
+	for i := 0; i < 64; i++ { keep = append(keep, make([]byte, 64<<20)) }
+	fmt.Println(len(keep))
+}`,
+			expected: func(t *testing.T, res Result) {
+				if res.ExitCode == 0 || !strings.Contains(res.Stderr, "out of memory") {
+					t.Errorf("got exit %d stderr %q, want out of memory", res.ExitCode, res.Stderr)
+				}
+			},
+		},
+		{
+			name: "go directive",
+			src: `package main
+import ("fmt"; "math/rand")
+func main() {
+	rand.Seed(1); first := rand.Int63()
+	rand.Seed(1)
+	fmt.Println(first == rand.Int63())
+}`,
+			expected: func(t *testing.T, res Result) {
+				// rand.Seed does nothing from go 1.24 on, unless go.mod asks
+				// for an older version
+				if res.Stdout != "false\n" {
+					t.Errorf("got stdout %q build output %q, want %q", res.Stdout, res.BuildOutput, "false\n")
+				}
+			},
+		},
+		{
+			name: "build limits",
+			src: `package main
+func main() {}`,
+			limits: func(l *Limits) { l.Memory = 16 << 20 },
+			expected: func(t *testing.T, res Result) {
+				if !res.BuildFailed {
+					t.Error("got build_failed false, want go build to fail in 16MiB of address space")
+				}
+			},
+		},
+		{
+			name: "output cap",
+			src: `package main
+import "fmt"
+func main() { for i := 0; i < 1000; i++ { fmt.Println("line", i) } }`,
+			expected: func(t *testing.T, res Result) {
+				if !res.Truncated || len(res.Stdout) != 1024 || res.ExitCode != 0 {
+					t.Errorf("got truncated %v, %d bytes, exit %d; want 1024 truncated bytes, exit 0", res.Truncated, len(res.Stdout), res.ExitCode)
+				}
+			},
+		},
+		{
+			name: "process group",
+			src: `package main
+import ("os"; "os/exec")
+func main() {
+	cmd := exec.Command("sleep", "60")
+	cmd.Stdout = os.Stdout
+	cmd.Start()
+}`,
+			expected: func(t *testing.T, res Result) {
+				if res.ExitCode != 0 || res.DurationMS > 2500 {
+					t.Errorf("got exit %d after %dms, want exit 0 without waiting for the child", res.ExitCode, res.DurationMS)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			runner := *r
+			if tt.limits != nil {
+				tt.limits(&runner.Limits)
+			}
+			res, err := runner.Run(context.Background(), []byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.expected(t, res)
+		})
+	}
+}
+
+func TestRunLocalModule(t *testing.T) {
+	r := newTestRunner(t)
+	r.ModulePath = "go-by-example"
+	r.ModuleDir = filepath.Join("..", "..")
+
+	src := `package main
+import ("fmt"; "go-by-example/pkg/clock")
+func main() { fmt.Println(clock.New().Now().IsZero()) }`
+	res, err := r.Run(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.BuildFailed || res.Stdout != "false\n" {
+		t.Errorf("got stdout %q build output %q, want %q", res.Stdout, res.BuildOutput, "false\n")
+	}
+}
+
+func TestRunNotMain(t *testing.T) {
+	r := &Runner{Limits: DefaultLimits()}
+	for _, src := range []string{"package lib", "not go at all"} {
+		if _, err := r.Run(context.Background(), []byte(src)); !errors.Is(err, ErrNotMain) {
+			t.Errorf("Run(%q): got %v, want ErrNotMain", src, err)
+		}
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{max: 5}
+	for _, s := range []string{"abc", "def", "ghi"} {
+		if n, err := b.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+	if got := b.String(); got != "abcde" || !b.truncated {
+		t.Errorf("got %q truncated %v, want %q truncated", got, b.truncated, "abcde")
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	src := "package main\n\nfunc main() {}\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	examples := []Example{{Path: "01-basics/01-hello", Title: "Hello", Dir: dir}}
+	srv := httptest.NewServer(NewServer(&Runner{Limits: DefaultLimits()}, examples, 1).Handler())
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		header   map[string]string // Added to a JSON request from the server's own origin
+		code     int
+		expected string
+	}{
+		{name: "index", method: "GET", path: "/", code: 200},
+		{name: "examples", method: "GET", path: "/api/examples", code: 200, expected: "Examples available"},
+		{name: "source", method: "GET", path: "/api/examples/01-basics/01-hello", code: 200, expected: "Hello"},
+		{name: "unknown example", method: "GET", path: "/api/examples/nope", code: 404, expected: "no example nope"},
+		{name: "bad json", method: "POST", path: "/api/run", body: "{", code: 400},
+		{name: "not main", method: "POST", path: "/api/run", body: `{"source": "package lib"}`, code: 400},
+		{name: "too large", method: "POST", path: "/api/run", body: `{"source": "` + strings.Repeat("x", maxSourceSize) + `"}`, code: 413},
+		{name: "wrong method", method: "GET", path: "/api/run", code: 405},
+		{name: "plain text", method: "POST", path: "/api/run", body: `{"source": "package lib"}`,
+			header: map[string]string{"Content-Type": "text/plain"}, code: 415},
+		{name: "cross origin", method: "POST", path: "/api/run", body: `{"source": "package lib"}`,
+			header: map[string]string{"Origin": "https://example.com"}, code: 403},
+		{name: "not loopback", method: "POST", path: "/api/run", body: `{"source": "package lib"}`,
+			header: map[string]string{"Host": "attacker.example.com"}, code: 403},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, srv.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Origin", srv.URL)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			if host, ok := tt.header["Host"]; ok {
+				req.Host = host
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.code {
+				t.Fatalf("got status %d, want %d", resp.StatusCode, tt.code)
+			}
+			if tt.expected == "" {
+				return
+			}
+			var got Response
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Message != tt.expected {
+				t.Errorf("got message %q, want %q", got.Message, tt.expected)
+			}
+		})
+	}
+}
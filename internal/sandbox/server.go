@@ -0,0 +1,289 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxSourceSize bounds the request body of /api/run
+const maxSourceSize = 64 << 10
+
+// Response is the envelope every API reply is wrapped in, the same shape as
+// in 19-http-operations
+type Response struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// RunRequest is the body of POST /api/run
+type RunRequest struct {
+	Source string `json:"source"`
+}
+
+// Example is an example the browser can load into the editor
+type Example struct {
+	Path  string `json:"path"`
+	Title string `json:"title"`
+	Dir   string `json:"-"` // Directory holding main.go
+}
+
+// Server serves the editor page and the JSON API
+type Server struct {
+	runner   *Runner
+	examples []Example
+	slots    chan struct{}
+}
+
+// NewServer returns a Server that runs at most concurrency programs at once.
+// Further requests wait for a free slot until their client gives up.
+func NewServer(runner *Runner, examples []Example, concurrency int) *Server {
+	return &Server{
+		runner:   runner,
+		examples: examples,
+		slots:    make(chan struct{}, max(concurrency, 1)),
+	}
+}
+
+// Handler returns the routes:
+//
+//	GET  /                     editor page
+//	GET  /api/examples         list of examples
+//	GET  /api/examples/{path}  source of one example
+//	POST /api/run              build and run {"source": "..."}
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.HandleFunc("GET /api/examples", s.handleExamples)
+	mux.HandleFunc("GET /api/examples/{path...}", s.handleSource)
+	mux.HandleFunc("POST /api/run", s.handleRun)
+	return middleware(mux)
+}
+
+// middleware logs each request and how long it took
+func middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		log.Printf("%s %s (%v)", r.Method, r.URL.Path, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, resp Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, Response{Status: "error", Message: msg})
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if err := indexPage.Execute(w, s.examples); err != nil {
+		log.Printf("render index: %v", err)
+	}
+}
+
+func (s *Server) handleExamples(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Response{
+		Status:  "success",
+		Message: "Examples available",
+		Data:    s.examples,
+	})
+}
+
+func (s *Server) handleSource(w http.ResponseWriter, r *http.Request) {
+	path := r.PathValue("path")
+	for _, ex := range s.examples {
+		if ex.Path != path {
+			continue
+		}
+		src, err := os.ReadFile(filepath.Join(ex.Dir, "main.go"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, Response{
+			Status:  "success",
+			Message: ex.Title,
+			Data:    map[string]string{"path": ex.Path, "source": string(src)},
+		})
+		return
+	}
+	writeError(w, http.StatusNotFound, "no example "+path)
+}
+
+// sameOrigin checks that a run request comes from the editor page of this
+// server on the loopback interface. Browsers send any page's plain-text
+// form posts cross-origin without asking first, so without these checks
+// every web page the user visits could run code on their machine; a
+// loopback Host also defeats DNS rebinding.
+func sameOrigin(r *http.Request) (int, string) {
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "application/json" {
+		return http.StatusUnsupportedMediaType, "Content-Type must be application/json"
+	}
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return http.StatusForbidden, "host " + r.Host + " is not a loopback address"
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			return http.StatusForbidden, "cross-origin request from " + origin
+		}
+	}
+	return http.StatusOK, ""
+}
+
+func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	if code, msg := sameOrigin(r); code != http.StatusOK {
+		writeError(w, code, msg)
+		return
+	}
+
+	var req RunRequest
+	body := http.MaxBytesReader(w, r.Body, maxSourceSize)
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "source is too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-r.Context().Done():
+		return
+	}
+
+	res, err := s.runner.Run(r.Context(), []byte(req.Source))
+	switch {
+	case errors.Is(err, ErrNotMain):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Status:  "success",
+		Message: summary(res),
+		Data:    res,
+	})
+}
+
+// summary describes a Result in a few words
+func summary(res Result) string {
+	switch {
+	case res.BuildFailed:
+		return "Build failed"
+	case res.TimedOut:
+		return "Program timed out"
+	case res.CPUExceeded:
+		return "Program exceeded its CPU time limit"
+	case res.Signal != "":
+		return "Program killed: " + res.Signal
+	case res.ExitCode != 0:
+		return "Program exited with an error"
+	}
+	return "Program exited"
+}
+
+var indexPage = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Go by Example Playground</title>
+<style>
+body { font-family: Georgia, serif; max-width: 1000px; margin: 2em auto; padding: 0 1em; color: #252519; }
+h1 { font-weight: normal; }
+textarea, pre { font-family: Menlo, Consolas, monospace; font-size: 0.85em; tab-size: 4; width: 100%; box-sizing: border-box; }
+textarea { height: 28em; }
+pre { background: #252519; color: #eee; padding: 1em; min-height: 4em; white-space: pre-wrap; }
+#status { color: #999; margin-left: 1em; }
+</style>
+</head>
+<body>
+<h1>Go by Example Playground</h1>
+<p>
+<select id="example">
+<option value="">Load an example…</option>
+{{range .}}<option value="{{.Path}}">{{.Path}}</option>
+{{end}}</select>
+<button id="run">Run</button><span id="status"></span>
+</p>
+<textarea id="source" spellcheck="false">package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("Hello, Go by Example!")
+}
+</textarea>
+<pre id="output"></pre>
+<script>
+const $ = (id) => document.getElementById(id);
+
+$("example").addEventListener("change", async (e) => {
+	if (!e.target.value) return;
+	const resp = await (await fetch("/api/examples/" + e.target.value)).json();
+	if (resp.status === "success") $("source").value = resp.data.source;
+});
+
+$("source").addEventListener("keydown", (e) => {
+	if (e.key !== "Tab") return;
+	e.preventDefault();
+	const t = e.target, at = t.selectionStart;
+	t.value = t.value.slice(0, at) + "\t" + t.value.slice(t.selectionEnd);
+	t.selectionStart = t.selectionEnd = at + 1;
+});
+
+$("run").addEventListener("click", async () => {
+	$("run").disabled = true;
+	$("status").textContent = "Running…";
+	$("output").textContent = "";
+	try {
+		const resp = await (await fetch("/api/run", {
+			method: "POST",
+			headers: {"Content-Type": "application/json"},
+			body: JSON.stringify({source: $("source").value}),
+		})).json();
+		const r = resp.data;
+		if (!r) {
+			$("status").textContent = resp.message;
+			return;
+		}
+		$("output").textContent = r.build_failed ? r.build_output : r.stdout + r.stderr;
+		let status = resp.message;
+		if (!r.build_failed) status += " (exit " + r.exit_code + ", " + r.duration_ms + "ms)";
+		if (r.truncated) status += ", output truncated";
+		$("status").textContent = status;
+	} catch (err) {
+		$("status").textContent = err;
+	} finally {
+		$("run").disabled = false;
+	}
+});
+</script>
+</body>
+</html>
+`))
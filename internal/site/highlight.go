@@ -0,0 +1,85 @@
+package site
+
+import (
+	"go/scanner"
+	"go/token"
+	"html"
+	"html/template"
+	"strings"
+)
+
+// predeclared identifiers get their own colour, like in most editors
+var predeclared = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true, "complex64": true,
+	"complex128": true, "error": true, "float32": true, "float64": true, "int": true,
+	"int8": true, "int16": true, "int32": true, "int64": true, "rune": true,
+	"string": true, "uint": true, "uint8": true, "uint16": true, "uint32": true,
+	"uint64": true, "uintptr": true, "true": true, "false": true, "iota": true,
+	"nil": true, "append": true, "cap": true, "clear": true, "close": true,
+	"complex": true, "copy": true, "delete": true, "imag": true, "len": true,
+	"make": true, "max": true, "min": true, "new": true, "panic": true,
+	"print": true, "println": true, "real": true, "recover": true,
+}
+
+// tokenClass returns the CSS class for a token, or "" for plain text
+func tokenClass(tok token.Token, lit string) string {
+	switch {
+	case tok == token.COMMENT:
+		return "com"
+	case tok == token.STRING || tok == token.CHAR:
+		return "str"
+	case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+		return "num"
+	case tok.IsKeyword():
+		return "kw"
+	case tok == token.IDENT && predeclared[lit]:
+		return "builtin"
+	}
+	return ""
+}
+
+// Highlight returns code as HTML with tokens wrapped in classed <span>s.
+// Whitespace between tokens is copied verbatim so indentation survives.
+// Code doesn't have to be a complete file; scan errors are ignored and the
+// rest of the input is emitted as plain text.
+func Highlight(code string) template.HTML {
+	src := []byte(code)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, func(token.Position, string) {}, scanner.ScanComments)
+
+	var b strings.Builder
+	last := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue // Automatically inserted, not in the source
+		}
+
+		start := file.Offset(pos)
+		end := start + len(tok.String())
+		if lit != "" {
+			end = start + len(lit)
+		}
+		if start < last || end > len(src) {
+			continue
+		}
+
+		b.WriteString(html.EscapeString(string(src[last:start])))
+		text := html.EscapeString(string(src[start:end]))
+		if class := tokenClass(tok, lit); class != "" {
+			b.WriteString(`<span class="` + class + `">` + text + `</span>`)
+		} else {
+			b.WriteString(text)
+		}
+		last = end
+	}
+	b.WriteString(html.EscapeString(string(src[last:])))
+
+	return template.HTML(b.String())
+}
@@ -0,0 +1,121 @@
+// Package site renders the examples as a static, annotated tutorial: every
+// comment block is shown next to the code that follows it.
+package site
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// Segment is a piece of prose paired with the code it describes. Either side
+// may be empty, e.g. the package clause has no prose in front of it.
+type Segment struct {
+	Doc  string // Comment text with the comment markers removed
+	Code string // Source code, with its original indentation
+}
+
+// Parse splits a Go source file into segments. Every comment group that sits
+// on lines of its own starts a new segment; comments that trail code on the
+// same line stay part of the code.
+func Parse(filename string, src []byte) ([]Segment, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []Segment
+	codeStart := 0
+	addCode := func(end int) {
+		code := trimBlankLines(string(src[codeStart:end]))
+		if code == "" {
+			return
+		}
+		if len(segments) == 0 || segments[len(segments)-1].Code != "" {
+			segments = append(segments, Segment{})
+		}
+		segments[len(segments)-1].Code = code
+	}
+
+	for _, group := range file.Comments {
+		start := fset.Position(group.Pos()).Offset
+		end := fset.Position(group.End()).Offset
+		if !standalone(src, start, end) {
+			continue
+		}
+
+		addCode(lineStart(src, start))
+		doc := commentText(group)
+		if n := len(segments); n > 0 && segments[n-1].Code == "" {
+			// Consecutive comment blocks with nothing in between read as one
+			segments[n-1].Doc += "\n\n" + doc
+		} else {
+			segments = append(segments, Segment{Doc: doc})
+		}
+		codeStart = lineEnd(src, end)
+	}
+	addCode(len(src))
+
+	return segments, nil
+}
+
+// standalone reports whether src[start:end] is the only thing on its lines
+func standalone(src []byte, start, end int) bool {
+	before := string(src[lineStart(src, start):start])
+	after := string(src[end:lineEnd(src, end)])
+	return strings.TrimSpace(before) == "" && strings.TrimSpace(after) == ""
+}
+
+// lineStart returns the offset of the first byte of the line containing off
+func lineStart(src []byte, off int) int {
+	for off > 0 && src[off-1] != '\n' {
+		off--
+	}
+	return off
+}
+
+// lineEnd returns the offset just past the newline ending the line at off
+func lineEnd(src []byte, off int) int {
+	for off < len(src) && src[off] != '\n' {
+		off++
+	}
+	if off < len(src) {
+		off++
+	}
+	return off
+}
+
+// trimBlankLines removes leading and trailing blank lines but keeps the
+// indentation of the first non-blank line
+func trimBlankLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// commentText strips the comment markers from a group, handling both the
+// "//" style and the "/** ... * ... */" style used throughout the examples
+func commentText(group *ast.CommentGroup) string {
+	var lines []string
+	for _, c := range group.List {
+		if text, ok := strings.CutPrefix(c.Text, "//"); ok {
+			lines = append(lines, strings.TrimPrefix(text, " "))
+			continue
+		}
+
+		text := strings.TrimSuffix(strings.TrimLeft(c.Text, "/*"), "*/")
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(line)
+			line = strings.TrimPrefix(line, "*")
+			lines = append(lines, strings.TrimPrefix(line, " "))
+		}
+	}
+	return trimBlankLines(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,87 @@
+package site
+
+import (
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+var (
+	// sectionHeading matches the "1. Basic channel usage" headings in main
+	sectionHeading = regexp.MustCompile(`^\d+\.\s+\S`)
+	// paramLine matches "@param name: description" and "@return: description"
+	paramLine = regexp.MustCompile(`^@(param|return)\s*([^:]*):\s*(.*)$`)
+	// inlineCode matches `code` spans
+	inlineCode = regexp.MustCompile("`([^`]+)`")
+)
+
+// inline escapes text and turns `code` spans into <code> elements
+func inline(text string) string {
+	return inlineCode.ReplaceAllString(html.EscapeString(text), "<code>$1</code>")
+}
+
+// RenderDoc turns the plain-text comment conventions used by the examples
+// into HTML: blank lines separate paragraphs, "- item" lines become lists,
+// numbered lines become section headings, "Key concepts:" style lines become
+// labels and @param/@return lines become a parameter list.
+func RenderDoc(doc string) template.HTML {
+	var b strings.Builder
+	var para []string
+	list := ""
+
+	flushPara := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + inline(strings.Join(para, " ")) + "</p>\n")
+			para = nil
+		}
+	}
+	closeList := func() {
+		if list != "" {
+			b.WriteString("</" + list + ">\n")
+			list = ""
+		}
+	}
+	openList := func(kind string) {
+		flushPara()
+		if list != kind {
+			closeList()
+			b.WriteString("<" + kind + ">\n")
+			list = kind
+		}
+	}
+
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			flushPara()
+			closeList()
+		case strings.HasPrefix(line, "- "):
+			openList("ul")
+			b.WriteString("<li>" + inline(strings.TrimPrefix(line, "- ")) + "</li>\n")
+		case paramLine.MatchString(line):
+			m := paramLine.FindStringSubmatch(line)
+			openList("dl")
+			name := strings.TrimSpace(m[2])
+			if name == "" {
+				name = m[1]
+			}
+			b.WriteString("<dt><code>" + html.EscapeString(name) + "</code></dt><dd>" + inline(m[3]) + "</dd>\n")
+		case sectionHeading.MatchString(line):
+			flushPara()
+			closeList()
+			b.WriteString("<h3>" + inline(line) + "</h3>\n")
+		case strings.HasSuffix(line, ":") && len(para) == 0:
+			closeList()
+			b.WriteString(`<p class="label">` + inline(line) + "</p>\n")
+		default:
+			closeList()
+			para = append(para, line)
+		}
+	}
+	flushPara()
+	closeList()
+
+	return template.HTML(b.String())
+}
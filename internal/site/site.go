@@ -0,0 +1,180 @@
+package site
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Page is one example as it appears on the site
+type Page struct {
+	Path   string // Example path, e.g. "01-basics/15-worker-pools"
+	Title  string // Human readable title, e.g. "Worker Pools"
+	Source []byte // Contents of main.go
+	Output string // Captured program output, empty if the example wasn't run
+}
+
+// Slug returns the page's file name on the site
+func (p Page) Slug() string {
+	return strings.ReplaceAll(p.Path, "/", "--") + ".html"
+}
+
+// Section returns the directory part of the path, used to group the
+// table of contents
+func (p Page) Section() string {
+	if i := strings.LastIndexByte(p.Path, '/'); i >= 0 {
+		return p.Path[:i]
+	}
+	return ""
+}
+
+// renderedSegment is a Segment ready to be placed into the template
+type renderedSegment struct {
+	Doc  template.HTML
+	Code template.HTML
+}
+
+type pageData struct {
+	Page
+	Segments []renderedSegment
+	Prev     *Page
+	Next     *Page
+}
+
+type indexSection struct {
+	Name  string
+	Pages []Page
+}
+
+// Generate writes index.html, one HTML page per example and a shared
+// stylesheet into dir. Pages are linked in the order given.
+func Generate(dir string, title string, pages []Page) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "style.css"), []byte(stylesheet), 0644); err != nil {
+		return err
+	}
+
+	var sections []indexSection
+	for _, p := range pages {
+		if len(sections) == 0 || sections[len(sections)-1].Name != p.Section() {
+			sections = append(sections, indexSection{Name: p.Section()})
+		}
+		sections[len(sections)-1].Pages = append(sections[len(sections)-1].Pages, p)
+	}
+	if err := writeTemplate(filepath.Join(dir, "index.html"), "index", map[string]any{
+		"Title":    title,
+		"Sections": sections,
+	}); err != nil {
+		return err
+	}
+
+	for i, p := range pages {
+		segments, err := Parse(p.Path+"/main.go", p.Source)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", p.Path, err)
+		}
+
+		data := pageData{Page: p}
+		for _, s := range segments {
+			data.Segments = append(data.Segments, renderedSegment{
+				Doc:  RenderDoc(s.Doc),
+				Code: Highlight(s.Code),
+			})
+		}
+		if i > 0 {
+			data.Prev = &pages[i-1]
+		}
+		if i < len(pages)-1 {
+			data.Next = &pages[i+1]
+		}
+
+		if err := writeTemplate(filepath.Join(dir, p.Slug()), "page", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTemplate(path, name string, data any) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := templates.ExecuteTemplate(f, name, data); err != nil {
+		f.Close()
+		return fmt.Errorf("render %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+var templates = template.Must(template.New("").Parse(`
+{{define "header"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.}}</title>
+<link rel="stylesheet" href="style.css">
+</head>
+<body>
+{{end}}
+
+{{define "index"}}{{template "header" .Title}}
+<h1>{{.Title}}</h1>
+{{range .Sections}}
+<h2>{{.Name}}</h2>
+<ol>
+{{range .Pages}}<li><a href="{{.Slug}}">{{.Title}}</a> <span class="path">{{.Path}}</span></li>
+{{end}}</ol>
+{{end}}
+</body>
+</html>
+{{end}}
+
+{{define "nav"}}<nav>
+{{if .Prev}}<a href="{{.Prev.Slug}}">&larr; {{.Prev.Title}}</a>{{end}}
+<a href="index.html">Contents</a>
+{{if .Next}}<a href="{{.Next.Slug}}">{{.Next.Title}} &rarr;</a>{{end}}
+</nav>
+{{end}}
+
+{{define "page"}}{{template "header" .Title}}
+{{template "nav" .}}
+<h1>{{.Title}}</h1>
+<p class="path">{{.Path}}/main.go</p>
+<table>
+{{range .Segments}}<tr>
+<td class="docs">{{.Doc}}</td>
+<td class="code">{{if .Code}}<pre>{{.Code}}</pre>{{end}}</td>
+</tr>
+{{end}}</table>
+{{if .Output}}<h2>Output</h2>
+<pre class="output">{{.Output}}</pre>
+{{end}}
+{{template "nav" .}}
+</body>
+</html>
+{{end}}
+`))
+
+const stylesheet = `body { font-family: Georgia, serif; max-width: 1200px; margin: 2em auto; padding: 0 1em; color: #252519; }
+h1, h2, h3 { font-weight: normal; }
+.path { color: #999; font-size: 0.9em; }
+nav { display: flex; justify-content: space-between; margin: 1em 0; }
+table { border-collapse: collapse; width: 100%; }
+td { vertical-align: top; padding: 0.3em 1em; }
+td.docs { width: 40%; font-size: 0.95em; }
+td.docs p.label { font-weight: bold; margin-bottom: 0.2em; }
+td.docs dt { float: left; clear: left; margin-right: 0.5em; }
+td.code { background: #f8f8f8; border-left: 1px solid #e5e5ee; }
+pre { font-family: Menlo, Consolas, monospace; font-size: 0.85em; tab-size: 4; margin: 0; white-space: pre-wrap; }
+pre.output { background: #252519; color: #eee; padding: 1em; }
+.kw { color: #954121; font-weight: bold; }
+.str { color: #219161; }
+.num { color: #19469d; }
+.com { color: #999; font-style: italic; }
+.builtin { color: #7c4dff; }
+`
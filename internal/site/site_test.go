@@ -0,0 +1,134 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sample = `package main
+
+import "log"
+
+/**
+ * Example demonstrates parsing.
+ *
+ * Key concepts:
+ * - Segments
+ */
+
+/**
+ * add sums two numbers
+ * @param a: first number
+ */
+func add(a, b int) int {
+	return a + b // Trailing comments stay with the code
+}
+
+func main() {
+	/**
+	 * 1. Basic usage
+	 */
+	log.Println(add(1, 2))
+}
+`
+
+func TestParse(t *testing.T) {
+	segments, err := Parse("main.go", []byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Segment{
+		{Doc: "", Code: "package main\n\nimport \"log\""},
+		{
+			Doc:  "Example demonstrates parsing.\n\nKey concepts:\n- Segments\n\nadd sums two numbers\n@param a: first number",
+			Code: "func add(a, b int) int {\n\treturn a + b // Trailing comments stay with the code\n}\n\nfunc main() {",
+		},
+		{Doc: "1. Basic usage", Code: "\tlog.Println(add(1, 2))\n}"},
+	}
+
+	if len(segments) != len(expected) {
+		t.Fatalf("got %d segments, want %d: %q", len(segments), len(expected), segments)
+	}
+	for i := range expected {
+		if segments[i] != expected[i] {
+			t.Errorf("segment %d:\ngot  %q\nwant %q", i, segments[i], expected[i])
+		}
+	}
+}
+
+func TestRenderDoc(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		expected string
+	}{
+		{
+			name:     "Paragraph with code span",
+			doc:      "Uses `chan<- int`\nfor sending",
+			expected: "<p>Uses <code>chan&lt;- int</code> for sending</p>\n",
+		},
+		{
+			name:     "Label and list",
+			doc:      "Key concepts:\n- One\n- Two",
+			expected: "<p class=\"label\">Key concepts:</p>\n<ul>\n<li>One</li>\n<li>Two</li>\n</ul>\n",
+		},
+		{
+			name:     "Section heading",
+			doc:      "1. Basic channel usage\nShows send and receive",
+			expected: "<h3>1. Basic channel usage</h3>\n<p>Shows send and receive</p>\n",
+		},
+		{
+			name:     "Params",
+			doc:      "@param id: worker identifier",
+			expected: "<dl>\n<dt><code>id</code></dt><dd>worker identifier</dd>\n</dl>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(RenderDoc(tt.doc)); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	got := string(Highlight("\tx := len(\"a<b\") // note"))
+	expected := "\tx := <span class=\"builtin\">len</span>(<span class=\"str\">&#34;a&lt;b&#34;</span>) <span class=\"com\">// note</span>"
+	if got != expected {
+		t.Errorf("got %q, want %q", got, expected)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	pages := []Page{
+		{Path: "01-basics/01-first", Title: "First", Source: []byte(sample)},
+		{Path: "01-basics/02-second", Title: "Second", Source: []byte(sample), Output: "3\n"},
+	}
+	if err := Generate(dir, "Test Site", pages); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(index), `<a href="01-basics--02-second.html">Second</a>`) {
+		t.Error("index is missing a link to the second page")
+	}
+
+	second, err := os.ReadFile(filepath.Join(dir, "01-basics--02-second.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`href="01-basics--01-first.html"`, `<pre class="output">3`} {
+		if !strings.Contains(string(second), want) {
+			t.Errorf("second page is missing %q", want)
+		}
+	}
+}
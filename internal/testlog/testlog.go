@@ -0,0 +1,75 @@
+// Package testlog lets example tests wait for the lines an example writes
+// with the log package, one line at a time.
+package testlog
+
+import (
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// timeout only guards against a hung test. Tests that use a fake clock do
+// their real timing with it, not with this.
+const timeout = time.Second
+
+// lineWriter sends every log line to a channel
+type lineWriter chan string
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	w <- strings.TrimSuffix(string(p), "\n")
+	return len(p), nil
+}
+
+// Capture redirects the log package, without timestamps, to the returned
+// channel until the test finishes
+func Capture(t testing.TB) <-chan string {
+	lines := make(lineWriter, 1024)
+	redirect(t, lines)
+	return lines
+}
+
+// Discard throws away what the log package writes until the test
+// finishes, for tests that run a whole example and only check how it ends
+func Discard(t testing.TB) {
+	redirect(t, io.Discard)
+}
+
+// redirect sends the log package, without timestamps, to w until the test
+// finishes
+func redirect(t testing.TB, w io.Writer) {
+	flags, prefix := log.Flags(), log.Prefix()
+	log.SetFlags(0)
+	log.SetPrefix("")
+	log.SetOutput(w)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+	})
+}
+
+// Expect fails the test unless the next log line is want
+func Expect(t testing.TB, lines <-chan string, want string) {
+	t.Helper()
+	select {
+	case got := <-lines:
+		if got != want {
+			t.Fatalf("got log line %q, want %q", got, want)
+		}
+	case <-time.After(timeout):
+		t.Fatalf("timed out waiting for log line %q", want)
+	}
+}
+
+// ExpectNone fails the test if a line is logged within a short grace period
+func ExpectNone(t testing.TB, lines <-chan string) {
+	t.Helper()
+	select {
+	case got := <-lines:
+		t.Fatalf("unexpected log line %q", got)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
@@ -0,0 +1,36 @@
+package vet
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// ContextKey flags context.WithValue keys of built-in types
+var ContextKey = &analysis.Analyzer{
+	Name: "contextkey",
+	Doc: `report context.WithValue keys of built-in types
+
+Two packages that both store a value under the string "userID" overwrite
+each other's values. Context keys should have an unexported type of their
+own, such as "type userIDKey struct{}", so they can never collide.`,
+	Run: runContextKey,
+}
+
+func runContextKey(pass *analysis.Pass) (any, error) {
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 3 || !isFunc(pass.TypesInfo, call, "context", "WithValue") {
+				return true
+			}
+			key := pass.TypesInfo.TypeOf(call.Args[1])
+			if basic, ok := key.(*types.Basic); ok {
+				pass.Reportf(call.Args[1].Pos(), "context key has built-in type %s; define an unexported key type to avoid collisions", types.Default(basic))
+			}
+			return true
+		})
+	}
+	return nil, nil
+}
@@ -0,0 +1,44 @@
+package vet
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// DeferLoop flags defer statements directly inside loops
+var DeferLoop = &analysis.Analyzer{
+	Name: "deferloop",
+	Doc: `report defer statements inside loops
+
+Deferred calls run when the function returns, not at the end of the loop
+iteration, so a defer in a loop holds every file, lock or connection open
+until the whole loop finishes. Move the body into a function, as
+13-defer-panic-recover does, or release the resource explicitly.`,
+	Run: runDeferLoop,
+}
+
+func runDeferLoop(pass *analysis.Pass) (any, error) {
+	funcBodies(pass, func(body *ast.BlockStmt) {
+		inspectBody(body, func(n ast.Node) bool {
+			var loopBody *ast.BlockStmt
+			switch loop := n.(type) {
+			case *ast.ForStmt:
+				loopBody = loop.Body
+			case *ast.RangeStmt:
+				loopBody = loop.Body
+			default:
+				return true
+			}
+			inspectBody(loopBody, func(n ast.Node) bool {
+				if d, ok := n.(*ast.DeferStmt); ok {
+					pass.Reportf(d.Pos(), "defer inside a loop runs when the function returns, not at the end of each iteration")
+				}
+				return true
+			})
+			// Nested loops have been covered by the walk above
+			return false
+		})
+	})
+	return nil, nil
+}
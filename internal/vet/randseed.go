@@ -0,0 +1,31 @@
+package vet
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// RandSeed flags calls to the deprecated math/rand.Seed
+var RandSeed = &analysis.Analyzer{
+	Name: "randseed",
+	Doc: `report calls to the deprecated math/rand.Seed
+
+Since Go 1.20 the global generator in math/rand is seeded randomly at
+startup, so seeding it with the time is unnecessary, and seeding it with a
+constant affects every other user of the package. Use
+rand.New(rand.NewSource(seed)) for a reproducible sequence.`,
+	Run: runRandSeed,
+}
+
+func runRandSeed(pass *analysis.Pass) (any, error) {
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok && isFunc(pass.TypesInfo, call, "math/rand", "Seed") {
+				pass.Reportf(call.Pos(), "rand.Seed is deprecated: the global generator is seeded automatically; use rand.New(rand.NewSource(seed)) for a reproducible sequence")
+			}
+			return true
+		})
+	}
+	return nil, nil
+}
@@ -0,0 +1,41 @@
+package vet
+
+import (
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// SleepSync flags time.Sleep used to wait for goroutines
+var SleepSync = &analysis.Analyzer{
+	Name: "sleepsync",
+	Doc: `report time.Sleep used to wait for goroutines
+
+A time.Sleep that follows a go statement in the same function is almost
+always a guess at how long the goroutine needs. It makes programs slow when
+the guess is too long and racy when it's too short. Wait for the goroutine
+with a sync.WaitGroup, a channel or an errgroup instead.`,
+	Run: runSleepSync,
+}
+
+func runSleepSync(pass *analysis.Pass) (any, error) {
+	funcBodies(pass, func(body *ast.BlockStmt) {
+		firstGo := token.NoPos
+		inspectBody(body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.GoStmt:
+				if !firstGo.IsValid() {
+					firstGo = n.Pos()
+				}
+				return false
+			case *ast.CallExpr:
+				if firstGo.IsValid() && n.Pos() > firstGo && isFunc(pass.TypesInfo, n, "time", "Sleep") {
+					pass.Reportf(n.Pos(), "time.Sleep used to wait for a goroutine; use a sync.WaitGroup or channel instead")
+				}
+			}
+			return true
+		})
+	})
+	return nil, nil
+}
@@ -0,0 +1,59 @@
+package vet
+
+import (
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Suppression silences one analyzer in the files of one directory, for
+// examples that show a flagged pattern on purpose
+type Suppression struct {
+	Analyzer string
+	Dir      string // Slash-separated, matched against the end of a file's directory
+	Reason   string
+}
+
+// Suppressions is the vet configuration of the repository
+var Suppressions = []Suppression{
+	{
+		Analyzer: "unreachable",
+		Dir:      "examples/01-basics/13-defer-panic-recover",
+		Reason:   "the statement after panic shows what never runs",
+	},
+}
+
+// Suppress returns analyzers with the findings covered by suppressions
+// dropped. Analyzers without a suppression are returned as they are.
+func Suppress(analyzers []*analysis.Analyzer, suppressions []Suppression) []*analysis.Analyzer {
+	out := make([]*analysis.Analyzer, len(analyzers))
+	for i, a := range analyzers {
+		var dirs []string
+		for _, s := range suppressions {
+			if s.Analyzer == a.Name {
+				dirs = append(dirs, s.Dir)
+			}
+		}
+		if len(dirs) == 0 {
+			out[i] = a
+			continue
+		}
+		quiet := *a
+		quiet.Run = func(pass *analysis.Pass) (any, error) {
+			report := pass.Report
+			pass.Report = func(d analysis.Diagnostic) {
+				dir := filepath.ToSlash(filepath.Dir(pass.Fset.Position(d.Pos).Filename))
+				for _, s := range dirs {
+					if dir == s || strings.HasSuffix(dir, "/"+s) {
+						return
+					}
+				}
+				report(d)
+			}
+			return a.Run(pass)
+		}
+		out[i] = &quiet
+	}
+	return out
+}
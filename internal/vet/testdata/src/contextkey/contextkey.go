@@ -0,0 +1,21 @@
+package contextkey
+
+import "context"
+
+type userIDKey struct{}
+
+type requestKey string
+
+func values(ctx context.Context) {
+	_ = context.WithValue(ctx, "userID", "123") // want `context key has built-in type string`
+	_ = context.WithValue(ctx, 42, "answer")    // want `context key has built-in type int`
+
+	key := "userID"
+	_ = context.WithValue(ctx, key, "123") // want `context key has built-in type string`
+
+	_ = context.WithValue(ctx, userIDKey{}, "123")
+	_ = context.WithValue(ctx, requestKey("id"), "123")
+
+	var anyKey any = "userID"
+	_ = context.WithValue(ctx, anyKey, "123") // Dynamic type unknown, not reported
+}
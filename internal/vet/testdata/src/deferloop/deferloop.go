@@ -0,0 +1,32 @@
+package deferloop
+
+import "os"
+
+func closeLater(names []string) {
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			continue
+		}
+		defer f.Close() // want `defer inside a loop`
+	}
+
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			defer println(i, j) // want `defer inside a loop`
+		}
+	}
+}
+
+func closeEachIteration(names []string) {
+	for _, name := range names {
+		func() {
+			f, err := os.Open(name)
+			if err != nil {
+				return
+			}
+			defer f.Close()
+		}()
+	}
+	defer println("done")
+}
@@ -0,0 +1,15 @@
+package randseed
+
+import (
+	"math/rand"
+	"time"
+)
+
+func seeds() {
+	rand.Seed(time.Now().UnixNano()) // want `rand.Seed is deprecated`
+	rand.Seed(42)                    // want `rand.Seed is deprecated`
+
+	r := rand.New(rand.NewSource(42))
+	_ = r.Intn(10)
+	_ = rand.Intn(10)
+}
@@ -0,0 +1,45 @@
+package sleepsync
+
+import (
+	"sync"
+	"time"
+)
+
+func work() {
+	time.Sleep(10 * time.Millisecond) // Simulated work, not waiting for anyone
+}
+
+func waitBySleeping() {
+	go work()
+	time.Sleep(time.Second) // want `time.Sleep used to wait for a goroutine`
+}
+
+func waitInLoop() {
+	for i := 0; i < 3; i++ {
+		go work()
+	}
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond) // want `time.Sleep used to wait for a goroutine`
+	}
+}
+
+func sleepBeforeStarting() {
+	time.Sleep(time.Millisecond)
+	go work()
+}
+
+func sleepInsideGoroutine() {
+	go func() {
+		time.Sleep(time.Millisecond)
+	}()
+}
+
+func waitGroup() {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		work()
+	}()
+	wg.Wait()
+}
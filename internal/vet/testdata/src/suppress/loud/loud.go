@@ -0,0 +1,6 @@
+package loud
+
+func fail() {
+	panic("but not here")
+	println("never runs") // want "unreachable code"
+}
@@ -0,0 +1,6 @@
+package quiet
+
+func fail() {
+	panic("unreachable is suppressed here")
+	println("never runs")
+}
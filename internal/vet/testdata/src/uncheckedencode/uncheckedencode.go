@@ -0,0 +1,26 @@
+package uncheckedencode
+
+import (
+	"encoding/json"
+	"io"
+	"text/template"
+)
+
+type encoder struct{}
+
+// Encode returns nothing, so there is nothing to check
+func (encoder) Encode(v any) {}
+
+func render(w io.Writer, t *template.Template, v any) error {
+	t.Execute(w, v)                    // want `error returned by Execute is not checked`
+	t.ExecuteTemplate(w, "item", v)    // want `error returned by ExecuteTemplate is not checked`
+	json.NewEncoder(w).Encode(v)       // want `error returned by Encode is not checked`
+	defer json.NewEncoder(w).Encode(v) // Deferred calls are out of scope
+
+	_ = t.Execute(w, v)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		return err
+	}
+	encoder{}.Encode(v)
+	return t.Execute(w, v)
+}
@@ -0,0 +1,64 @@
+package vet
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// uncheckedMethods are the methods whose error is easy to forget because
+// they write to an io.Writer that seldom fails in examples
+var uncheckedMethods = map[string]bool{
+	"Execute":         true,
+	"ExecuteTemplate": true,
+	"Encode":          true,
+}
+
+// UncheckedEncode flags Execute, ExecuteTemplate and Encode calls whose
+// error result is dropped
+var UncheckedEncode = &analysis.Analyzer{
+	Name: "uncheckedencode",
+	Doc: `report ignored errors from template Execute and encoder Encode
+
+Template execution fails on missing fields and bad pipelines, and encoders
+fail on unsupported values and broken writers; either way the output is
+incomplete. Check the error, or assign it to _ to show it's deliberate.`,
+	Run: runUncheckedEncode,
+}
+
+func runUncheckedEncode(pass *analysis.Pass) (any, error) {
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			stmt, ok := n.(*ast.ExprStmt)
+			if !ok {
+				return true
+			}
+			call, ok := stmt.X.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+			if !ok || !uncheckedMethods[fn.Name()] {
+				return true
+			}
+			sig := fn.Type().(*types.Signature)
+			if sig.Recv() == nil || !returnsError(sig) {
+				return true
+			}
+			pass.Reportf(call.Pos(), "error returned by %s is not checked", fn.Name())
+			return true
+		})
+	}
+	return nil, nil
+}
+
+// returnsError reports whether the last result of sig is an error
+func returnsError(sig *types.Signature) bool {
+	res := sig.Results()
+	if res.Len() == 0 {
+		return false
+	}
+	return types.Identical(res.At(res.Len()-1).Type(), types.Universe.Lookup("error").Type())
+}
@@ -0,0 +1,60 @@
+// Package vet holds go/analysis analyzers for the concurrency and API
+// misuse patterns that the examples warn about. They run together in
+// cmd/examplevet, or one at a time with analysistest.
+package vet
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Analyzers is the full suite, in the order they're documented
+var Analyzers = []*analysis.Analyzer{
+	SleepSync,
+	ContextKey,
+	RandSeed,
+	UncheckedEncode,
+	DeferLoop,
+}
+
+// isFunc reports whether call invokes the package-level function pkg.name
+func isFunc(info *types.Info, call *ast.CallExpr, pkg, name string) bool {
+	fn, ok := typeutil.Callee(info, call).(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	return sig.Recv() == nil && fn.Pkg().Path() == pkg && fn.Name() == name
+}
+
+// funcBodies calls visit with the body of every function declaration and
+// function literal in the package
+func funcBodies(pass *analysis.Pass, visit func(body *ast.BlockStmt)) {
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch fn := n.(type) {
+			case *ast.FuncDecl:
+				if fn.Body != nil {
+					visit(fn.Body)
+				}
+			case *ast.FuncLit:
+				visit(fn.Body)
+			}
+			return true
+		})
+	}
+}
+
+// inspectBody walks body like ast.Inspect but doesn't descend into function
+// literals, whose statements belong to another function
+func inspectBody(body *ast.BlockStmt, f func(ast.Node) bool) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+		return f(n)
+	})
+}
@@ -0,0 +1,45 @@
+package vet
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzers(t *testing.T) {
+	tests := []struct {
+		name     string
+		analyzer *analysis.Analyzer
+	}{
+		{"sleepsync", SleepSync},
+		{"contextkey", ContextKey},
+		{"randseed", RandSeed},
+		{"uncheckedencode", UncheckedEncode},
+		{"deferloop", DeferLoop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysistest.Run(t, analysistest.TestData(), tt.analyzer, tt.name)
+		})
+	}
+}
+
+func TestAnalyzersValid(t *testing.T) {
+	all := append(Suppress(Standard, Suppressions), Analyzers...)
+	if err := analysis.Validate(all); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	analyzers := Suppress(Standard, []Suppression{{Analyzer: "unreachable", Dir: "suppress/quiet"}})
+	for _, a := range analyzers {
+		if a.Name == "unreachable" {
+			analysistest.Run(t, analysistest.TestData(), a, "suppress/quiet", "suppress/loud")
+			return
+		}
+	}
+	t.Fatal("no unreachable analyzer in Standard")
+}
@@ -1,7 +1,210 @@
 package main
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+)
+
+/**
+ * Go by Example runner: one entry point for discovering and running every
+ * example under examples/.
+ *
+ * Usage:
+ *   go run . list [query]
+ *   go run . run [-timeout 30s] <name>...
+ *   go run . run --all
+ *   go run . gen-site [-out site] [-run]
+ *   go run . search "rate limiting"
+ *   go run . search -pkg sync/atomic
+ *   go run . catalog [-o catalog.json]
+ *   go run . check [name...]
+ *   go run . serve [-addr localhost:8080]
+ *
+ * Names are matched fuzzily, so "15-worker-pools", "worker-pools",
+ * "worker" and "wrkpl" all select the same example.
+ */
+
+// SubCommand is a named action of the runner CLI
+type SubCommand struct {
+	Name        string
+	Usage       string
+	Description string
+	Execute     func(ctx context.Context, args []string) error
+}
+
+func commands() map[string]SubCommand {
+	return map[string]SubCommand{
+		"list": {
+			Name:        "list",
+			Usage:       "list [query]",
+			Description: "List available examples, optionally filtered by query",
+			Execute:     listCommand,
+		},
+		"catalog": {
+			Name:        "catalog",
+			Usage:       "catalog [-o file]",
+			Description: "Write a JSON index of concepts, use cases, declarations and imports",
+			Execute:     catalogCommand,
+		},
+		"check": {
+			Name:        "check",
+			Usage:       "check [-list] [-v] [name...]",
+			Description: "Run the hidden tests for your exercise solutions and record progress",
+			Execute:     checkCommand,
+		},
+		"gen-site": {
+			Name:        "gen-site",
+			Usage:       "gen-site [-out dir] [-run] [-timeout d]",
+			Description: "Render the examples as an annotated static HTML site",
+			Execute:     genSiteCommand,
+		},
+		"run": {
+			Name:        "run",
+			Usage:       "run [-timeout d] [-all] <name>...",
+			Description: "Build and run one or more examples",
+			Execute:     runCommand,
+		},
+		"serve": {
+			Name:        "serve",
+			Usage:       "serve [-addr host:port] [-cpu d] [-wall d]",
+			Description: "Serve a browser playground that runs edited examples in a sandbox",
+			Execute:     serveCommand,
+		},
+		"search": {
+			Name:        "search",
+			Usage:       "search [-pkg path] [-json] [query]",
+			Description: "Find examples by concept, use case, declaration or imported package",
+			Execute:     searchCommand,
+		},
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Welcome to Go by Example!")
+	fmt.Fprintln(os.Stderr, "\nUsage: go run . <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+
+	cmds := commands()
+	names := make([]string, 0, len(cmds))
+	for name := range cmds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-40s %s\n", cmds[name].Usage, cmds[name].Description)
+	}
+}
+
+func listCommand(ctx context.Context, args []string) error {
+	examples, err := discoverExamples(examplesRoot)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		examples = matchExamples(examples, strings.Join(args, " "))
+	}
+	for _, ex := range examples {
+		fmt.Println(ex.Path)
+	}
+	return nil
+}
+
+func runCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	all := fs.Bool("all", false, "Run every example")
+	timeout := fs.Duration("timeout", 30*time.Second, "Per-example timeout (0 disables it)")
+	grace := fs.Duration("grace", 2*time.Second, "Time to wait after interrupting a timed out example before killing it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	examples, err := discoverExamples(examplesRoot)
+	if err != nil {
+		return err
+	}
+
+	var selected []Example
+	switch {
+	case *all:
+		selected = examples
+	case fs.NArg() == 0:
+		return errors.New("run requires an example name or -all")
+	default:
+		for _, query := range fs.Args() {
+			ex, err := findExample(examples, query)
+			if err != nil {
+				return err
+			}
+			selected = append(selected, ex)
+		}
+	}
+
+	opts := runOptions{
+		Timeout: *timeout,
+		Grace:   *grace,
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+	}
+
+	var failed []string
+	for _, ex := range selected {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("=== RUN %s\n", ex.Path)
+		start := time.Now()
+		if err := runExample(ctx, ex, opts); err != nil {
+			log.Printf("--- FAIL %s (%v): %v\n", ex.Path, time.Since(start).Round(time.Millisecond), err)
+			failed = append(failed, ex.Path)
+			continue
+		}
+		log.Printf("--- OK %s (%v)\n", ex.Path, time.Since(start).Round(time.Millisecond))
+	}
+
+	if len(selected) > 1 {
+		log.Printf("Ran %d examples, %d failed\n", len(selected), len(failed))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed examples: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
 
 func main() {
-	fmt.Println("Welcome to Go by Example!")
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands()[os.Args[1]]
+	if !ok {
+		switch os.Args[1] {
+		case "help", "-h", "-help", "--help":
+			usage()
+			return
+		}
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd.Execute(ctx, os.Args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatal(err)
+	}
 }
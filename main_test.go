@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestMatchExamples(t *testing.T) {
+	examples := []Example{
+		{Name: "12-channels", Path: "01-basics/12-channels"},
+		{Name: "14-timers-tickers", Path: "01-basics/14-timers-tickers"},
+		{Name: "15-worker-pools", Path: "01-basics/15-worker-pools"},
+		{Name: "01-for", Path: "01-basics/04-control-flow-and-iterators/01-for"},
+		{Name: "02-if-else", Path: "01-basics/04-control-flow-and-iterators/02-if-else"},
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "Exact name", query: "15-worker-pools", expected: []string{"01-basics/15-worker-pools"}},
+		{name: "Exact path", query: "01-basics/12-channels", expected: []string{"01-basics/12-channels"}},
+		{name: "Without ordinal", query: "Worker_Pools", expected: []string{"01-basics/15-worker-pools"}},
+		{name: "Substring", query: "timers", expected: []string{"01-basics/14-timers-tickers"}},
+		{name: "Spaces fold to dashes", query: "control flow", expected: []string{
+			"01-basics/04-control-flow-and-iterators/01-for",
+			"01-basics/04-control-flow-and-iterators/02-if-else",
+		}},
+		{name: "Subsequence", query: "wrkpl", expected: []string{"01-basics/15-worker-pools"}},
+		{name: "No match", query: "zzz", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := matchExamples(examples, tt.query)
+			var got []string
+			for _, ex := range found {
+				got = append(got, ex.Path)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("got %q, want %q", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("got %q, want %q", got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestDiscoverExamples(t *testing.T) {
+	examples, err := discoverExamples(examplesRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ex, err := findExample(examples, "15-worker-pools")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex.Path != "01-basics/15-worker-pools" {
+		t.Errorf("got %q, want %q", ex.Path, "01-basics/15-worker-pools")
+	}
+
+	if _, err := findExample(examples, "01"); err == nil {
+		t.Error("expected ambiguous query to fail")
+	}
+}
@@ -0,0 +1,143 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+func TestFakeTimer(t *testing.T) {
+	clk := NewFake(epoch)
+	timer := clk.NewTimer(2 * time.Second)
+
+	clk.Advance(1999 * time.Millisecond)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	clk.Advance(time.Millisecond)
+	select {
+	case got := <-timer.C():
+		if want := epoch.Add(2 * time.Second); !got.Equal(want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	default:
+		t.Fatal("timer did not fire")
+	}
+
+	if clk.Waiters() != 0 {
+		t.Errorf("got %d waiters after firing, want 0", clk.Waiters())
+	}
+}
+
+func TestFakeTimerStopAndReset(t *testing.T) {
+	clk := NewFake(epoch)
+	timer := clk.NewTimer(time.Second)
+
+	if !timer.Reset(500 * time.Millisecond) {
+		t.Error("Reset of a pending timer should report true")
+	}
+	clk.Advance(500 * time.Millisecond)
+	if len(timer.C()) != 1 {
+		t.Fatal("timer did not fire after reset")
+	}
+
+	if timer.Stop() {
+		t.Error("Stop of a fired timer should report false")
+	}
+	if len(timer.C()) != 0 {
+		t.Error("Stop should discard the unreceived value")
+	}
+}
+
+func TestFakeNonPositiveDelay(t *testing.T) {
+	tests := []struct {
+		name  string
+		timer func(clk *Fake) <-chan time.Time
+	}{
+		{name: "NewTimer", timer: func(clk *Fake) <-chan time.Time { return clk.NewTimer(0).C() }},
+		{name: "After", timer: func(clk *Fake) <-chan time.Time { return clk.After(-time.Second) }},
+		{name: "Reset", timer: func(clk *Fake) <-chan time.Time {
+			timer := clk.NewTimer(time.Hour)
+			timer.Reset(0)
+			return timer.C()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Fires without the clock advancing, like a real timer
+			clk := NewFake(epoch)
+			select {
+			case got := <-tt.timer(clk):
+				if !got.Equal(epoch) {
+					t.Errorf("got %v, want %v", got, epoch)
+				}
+			default:
+				t.Fatal("timer did not fire")
+			}
+			if clk.Waiters() != 0 {
+				t.Errorf("got %d waiters, want 0", clk.Waiters())
+			}
+		})
+	}
+
+	clk := NewFake(epoch)
+	clk.Sleep(0) // Returns at once
+}
+
+func TestFakeTicker(t *testing.T) {
+	clk := NewFake(epoch)
+	ticker := clk.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	ticks := 0
+	for i := 0; i < 4; i++ {
+		clk.Advance(500 * time.Millisecond)
+		select {
+		case <-ticker.C():
+			ticks++
+		default:
+		}
+	}
+	if ticks != 4 {
+		t.Errorf("got %d ticks, want 4", ticks)
+	}
+
+	// Ticks are dropped when nobody is receiving, like time.Ticker
+	clk.Advance(2 * time.Second)
+	if len(ticker.C()) != 1 {
+		t.Errorf("got %d buffered ticks, want 1", len(ticker.C()))
+	}
+}
+
+func TestFakeSleep(t *testing.T) {
+	clk := NewFake(epoch)
+	done := make(chan struct{})
+
+	go func() {
+		clk.Sleep(time.Minute)
+		close(done)
+	}()
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute)
+	<-done
+
+	if got := clk.Now(); !got.Equal(epoch.Add(time.Minute)) {
+		t.Errorf("got %v, want %v", got, epoch.Add(time.Minute))
+	}
+}
+
+func TestRealClock(t *testing.T) {
+	clk := New()
+	start := clk.Now()
+	clk.Sleep(time.Millisecond)
+	<-clk.After(time.Millisecond)
+	if clk.Now().Sub(start) < 2*time.Millisecond {
+		t.Error("real clock did not advance")
+	}
+}
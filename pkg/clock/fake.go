@@ -0,0 +1,202 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock whose time only moves when Advance is called. Timers,
+// tickers, After and Sleep all fire synchronously inside Advance, in deadline
+// order, so tests can drive time-based code without waiting on the wall clock.
+//
+// Like the time package, fake timer and ticker channels have a buffer of one
+// and a tick is dropped if the previous one hasn't been received yet.
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter is a pending timer, ticker, After or Sleep
+type fakeWaiter struct {
+	clock  *Fake
+	when   time.Time
+	period time.Duration // Zero for one-shot timers
+	c      chan time.Time
+}
+
+// NewFake returns a Fake clock set to start
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns the fake current time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Advance moves the clock forward by d, firing every timer and ticker whose
+// deadline falls within that window
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	target := f.now.Add(d)
+	for {
+		sort.SliceStable(f.waiters, func(i, j int) bool {
+			return f.waiters[i].when.Before(f.waiters[j].when)
+		})
+		if len(f.waiters) == 0 || f.waiters[0].when.After(target) {
+			break
+		}
+
+		w := f.waiters[0]
+		f.now = w.when
+		select {
+		case w.c <- f.now:
+		default: // Receiver is behind, drop the tick like time.Ticker does
+		}
+
+		if w.period > 0 {
+			w.when = w.when.Add(w.period)
+		} else {
+			f.remove(w)
+		}
+	}
+	f.now = target
+}
+
+// BlockUntil waits until at least n timers, tickers or sleepers are pending.
+// Tests use it to make sure the code under test has started waiting before
+// advancing the clock.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// Waiters returns the number of pending timers, tickers and sleepers
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// NewTimer returns a Timer that fires once the clock has advanced by d, or
+// at once if d <= 0
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	w := &fakeWaiter{clock: f, c: make(chan time.Time, 1)}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.add(w, d, 0)
+	return w
+}
+
+// NewTicker returns a Ticker that fires every time the clock advances by d
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	w := &fakeWaiter{clock: f, c: make(chan time.Time, 1)}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.add(w, d, d)
+	return (*fakeTicker)(w)
+}
+
+// After is the fake equivalent of time.After
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// Sleep blocks until the clock has advanced by d
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// add registers w to fire after d, or fires it now if d <= 0 like a real
+// timer; the caller holds f.mu
+func (f *Fake) add(w *fakeWaiter, d, period time.Duration) {
+	w.when = f.now.Add(d)
+	w.period = period
+	if period == 0 && !w.when.After(f.now) {
+		select {
+		case w.c <- f.now:
+		default:
+		}
+		return
+	}
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+}
+
+// remove unregisters w and reports whether it was pending; the caller holds f.mu
+func (f *Fake) remove(w *fakeWaiter) bool {
+	for i, other := range f.waiters {
+		if other == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// drain discards a value that was sent but not yet received, matching the
+// Go 1.23 guarantee that no stale value is received after Stop or Reset
+func (w *fakeWaiter) drain() {
+	select {
+	case <-w.c:
+	default:
+	}
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.c
+}
+
+func (w *fakeWaiter) Stop() bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	w.drain()
+	return w.clock.remove(w)
+}
+
+func (w *fakeWaiter) Reset(d time.Duration) bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	w.drain()
+	active := w.clock.remove(w)
+	w.clock.add(w, d, 0)
+	return active
+}
+
+// fakeTicker gives a fakeWaiter the Ticker method set
+type fakeTicker fakeWaiter
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	(*fakeWaiter)(t).Stop()
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	w := (*fakeWaiter)(t)
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	w.drain()
+	w.clock.remove(w)
+	w.clock.add(w, d, d)
+}
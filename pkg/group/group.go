@@ -0,0 +1,152 @@
+// Package group runs goroutines as one unit of work. Wait returns once
+// all of them have returned, with all their errors; the first error
+// cancels the others, and a panic becomes an error instead of crashing the
+// program.
+//
+//	g := group.New(ctx, group.Options{Limit: 4})
+//	for _, url := range urls {
+//		g.Go(func(ctx context.Context) error {
+//			return fetch(ctx, url)
+//		})
+//	}
+//	if err := g.Wait(); err != nil {
+//		...
+//	}
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// Options configures a Group
+type Options struct {
+	Limit int // Goroutines running at once; 0 means no limit
+}
+
+// PanicError is the error of a function that panicked. The group
+// recovers, so the panic fails the group but not the program. Package
+// supervisor reports panicking children with it too.
+type PanicError struct {
+	Value any    // What was passed to panic
+	Stack []byte // Stack trace of the goroutine at the panic
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("goroutine panicked: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error, so errors.Is and
+// errors.As see through a panic(err)
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Group is a set of goroutines started with Go and waited for with Wait.
+// Its methods are safe for concurrent use, and functions may start more
+// functions in the same group.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	sem    chan struct{} // Holds a token per running function; nil without a limit
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	errs   []error
+	failed bool // An error was recorded and the context cancelled
+}
+
+// New returns an empty Group. Its functions get a context derived from
+// ctx, which is cancelled by the first error and when Wait returns.
+func New(ctx context.Context, opts Options) *Group {
+	ctx, cancel := context.WithCancelCause(ctx)
+	g := &Group{ctx: ctx, cancel: cancel}
+	if opts.Limit > 0 {
+		g.sem = make(chan struct{}, opts.Limit)
+	}
+	return g
+}
+
+// Context returns the context passed to the functions. After the first
+// error, context.Cause returns that error.
+func (g *Group) Context() context.Context {
+	return g.ctx
+}
+
+// Go runs fn in a new goroutine. At the Limit, Go waits until a running
+// function returns; if the group's context is done first, fn doesn't run.
+// A function that starts another one in the same group while holding the
+// last slot waits forever.
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		case <-g.ctx.Done():
+			g.record(g.ctx.Err())
+			return
+		}
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+		g.record(g.call(fn))
+	}()
+}
+
+// Wait returns once every function has returned, then cancels the
+// context. Its error joins those of the functions with errors.Join, in the
+// order they returned. Cancellation errors of functions stopping because
+// the group was cancelled are left out: after the first error they only
+// echo it, and after the parent context is done they are kept once.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(context.Canceled)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return errors.Join(g.errs...)
+}
+
+// call runs fn, turning a panic into a *PanicError
+func (g *Group) call(fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn(g.ctx)
+}
+
+// record keeps err for Wait, cancelling the group if it is the first
+func (g *Group) record(err error) {
+	if err == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.failed && cancellation(err) {
+		return
+	}
+	g.errs = append(g.errs, err)
+	if !g.failed {
+		g.failed = true
+		g.cancel(err)
+	}
+}
+
+// cancellation reports whether err only says a context was done
+func cancellation(err error) bool {
+	var p *PanicError
+	if errors.As(err, &p) {
+		return false
+	}
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
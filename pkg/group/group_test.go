@@ -0,0 +1,246 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go-by-example/internal/leaktest"
+)
+
+func TestWait(t *testing.T) {
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+
+	tests := []struct {
+		name     string
+		fns      []func(ctx context.Context) error
+		expected []error
+	}{
+		{
+			name:     "no functions",
+			expected: nil,
+		},
+		{
+			name: "all succeed",
+			fns: []func(ctx context.Context) error{
+				func(context.Context) error { return nil },
+				func(context.Context) error { return nil },
+			},
+			expected: nil,
+		},
+		{
+			name: "every error is joined",
+			fns: []func(ctx context.Context) error{
+				func(context.Context) error { return errA },
+				func(context.Context) error { return errB },
+				func(context.Context) error { return nil },
+			},
+			expected: []error{errA, errB},
+		},
+		{
+			name: "siblings stopping on cancellation are left out",
+			fns: []func(ctx context.Context) error{
+				func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+				func(context.Context) error { return errA },
+			},
+			expected: []error{errA},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaktest.Check(t)
+			g := New(context.Background(), Options{})
+			for _, fn := range tt.fns {
+				g.Go(fn)
+			}
+
+			err := g.Wait()
+			if (err == nil) != (tt.expected == nil) {
+				t.Fatalf("got %v, want %v", err, tt.expected)
+			}
+			for _, want := range tt.expected {
+				if !errors.Is(err, want) {
+					t.Errorf("got %v, want it to include %v", err, want)
+				}
+			}
+			if errors.Is(err, context.Canceled) {
+				t.Errorf("got %v, want no cancellation errors", err)
+			}
+			if g.Context().Err() == nil {
+				t.Error("context still active after Wait")
+			}
+		})
+	}
+}
+
+func TestFirstErrorCancels(t *testing.T) {
+	leaktest.Check(t)
+	errFirst := errors.New("first")
+	g := New(context.Background(), Options{})
+
+	stopped := make(chan error, 1)
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		stopped <- context.Cause(ctx)
+		return ctx.Err()
+	})
+	g.Go(func(context.Context) error { return errFirst })
+
+	if err := g.Wait(); !errors.Is(err, errFirst) || err.Error() != "first" {
+		t.Errorf("got %v, want %v alone", err, errFirst)
+	}
+	if cause := <-stopped; cause != errFirst {
+		t.Errorf("sibling saw cause %v, want %v", cause, errFirst)
+	}
+}
+
+func TestParentCancelled(t *testing.T) {
+	leaktest.Check(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	g := New(ctx, Options{})
+	for range 3 {
+		g.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}
+	cancel()
+
+	// The cancellation is reported once, not once per function
+	if err := g.Wait(); err == nil || err.Error() != context.Canceled.Error() {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	leaktest.Check(t)
+	g := New(context.Background(), Options{Limit: 2})
+	var running, peak atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	// Go blocks at the limit, so the functions are started from elsewhere
+	go func() {
+		for range 6 {
+			g.Go(func(context.Context) error {
+				n := running.Add(1)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+				started <- struct{}{}
+				<-release
+				running.Add(-1)
+				return nil
+			})
+		}
+	}()
+
+	<-started
+	<-started
+	close(release)
+	for range 4 {
+		<-started
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if p := peak.Load(); p != 2 {
+		t.Errorf("got %d running at once, want 2", p)
+	}
+}
+
+func TestLimitSkipsAfterCancel(t *testing.T) {
+	leaktest.Check(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	g := New(ctx, Options{Limit: 1})
+
+	release := make(chan struct{})
+	g.Go(func(context.Context) error {
+		<-release
+		return nil
+	})
+	cancel()
+
+	// The only slot is taken and the group is cancelled, so this never runs
+	ran := false
+	g.Go(func(context.Context) error {
+		ran = true
+		return nil
+	})
+	close(release)
+
+	if err := g.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+	if ran {
+		t.Error("function ran after the group was cancelled")
+	}
+}
+
+func TestPanic(t *testing.T) {
+	errSentinel := errors.New("sentinel")
+
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{name: "string", value: "boom"},
+		{name: "error", value: errSentinel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaktest.Check(t)
+			g := New(context.Background(), Options{})
+			g.Go(func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			})
+			g.Go(func(context.Context) error { panic(tt.value) })
+
+			err := g.Wait()
+			var p *PanicError
+			if !errors.As(err, &p) {
+				t.Fatalf("got %v, want a *PanicError", err)
+			}
+			if p.Value != tt.value {
+				t.Errorf("got panic value %v, want %v", p.Value, tt.value)
+			}
+			if !strings.Contains(string(p.Stack), "group.TestPanic") {
+				t.Errorf("stack doesn't show where the panic happened:\n%s", p.Stack)
+			}
+			if _, isErr := tt.value.(error); errors.Is(err, errSentinel) != isErr {
+				t.Errorf("errors.Is(err, sentinel) = %v, want %v", !isErr, isErr)
+			}
+		})
+	}
+}
+
+func TestNestedGo(t *testing.T) {
+	leaktest.Check(t)
+	g := New(context.Background(), Options{})
+	var count atomic.Int32
+	for range 3 {
+		g.Go(func(context.Context) error {
+			count.Add(1)
+			g.Go(func(context.Context) error {
+				count.Add(1)
+				return nil
+			})
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if n := count.Load(); n != 6 {
+		t.Errorf("got %d functions run, want 6", n)
+	}
+}
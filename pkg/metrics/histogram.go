@@ -0,0 +1,211 @@
+// Package metrics records latencies and rates for reporting.
+//
+// A Histogram counts durations in buckets whose width grows with the value,
+// so it keeps a relative error below about 6% from nanoseconds to hours in a
+// fixed amount of memory. Recording is a handful of atomic operations and
+// never blocks, so one Histogram can be shared by many goroutines on a hot
+// path. A Meter counts events in time slots to report throughput over a
+// sliding window.
+//
+// Snapshots of both export to JSON through their struct tags and
+// MarshalJSON methods, and to the Prometheus text format through an
+// Exposition.
+package metrics
+
+import (
+	"encoding/json"
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	subBits    = 4 // Each power of two is split into 1<<subBits buckets
+	subBuckets = 1 << subBits
+	numBuckets = (64 - subBits + 1) * subBuckets
+)
+
+// bucketOf returns the bucket a non-negative value falls into. Values below
+// subBuckets get a bucket each; above that, every power of two is split
+// into subBuckets buckets of equal width.
+func bucketOf(v uint64) int {
+	if v < subBuckets {
+		return int(v)
+	}
+	exp := bits.Len64(v) - 1
+	shift := exp - subBits
+	return (shift+1)*subBuckets + int(v>>shift)&(subBuckets-1)
+}
+
+// bucketBounds returns the smallest and largest value of bucket i
+func bucketBounds(i int) (lo, hi uint64) {
+	if i < subBuckets {
+		return uint64(i), uint64(i)
+	}
+	shift := i/subBuckets - 1
+	lo = uint64(subBuckets+i%subBuckets) << shift
+	return lo, lo + 1<<shift - 1
+}
+
+// Histogram records the distribution of durations. The zero value is ready
+// to use. A Histogram must not be copied after first use.
+type Histogram struct {
+	counts [numBuckets]atomic.Uint64
+	sum    atomic.Int64
+	min    atomic.Int64 // Offset by one so the zero value means "none"
+	max    atomic.Int64
+}
+
+// Record adds a duration. Negative durations count as zero.
+func (h *Histogram) Record(d time.Duration) {
+	v := max(int64(d), 0)
+	h.counts[bucketOf(uint64(v))].Add(1)
+	h.sum.Add(v)
+
+	for {
+		cur := h.min.Load()
+		if cur != 0 && cur-1 <= v {
+			break
+		}
+		if h.min.CompareAndSwap(cur, v+1) {
+			break
+		}
+	}
+	for {
+		cur := h.max.Load()
+		if cur >= v || h.max.CompareAndSwap(cur, v) {
+			break
+		}
+	}
+}
+
+// Snapshot returns the durations recorded so far
+func (h *Histogram) Snapshot() Snapshot {
+	return h.snapshot(false)
+}
+
+// Reset clears the histogram and returns what it held. Each bucket count
+// and the sum are swapped atomically but one after another, so periodic
+// Reset calls add up to every duration exactly once. A duration recorded
+// during a Reset may still be counted in one Snapshot while its Sum, Min
+// and Max land in the next, so a single Snapshot is only approximately
+// consistent.
+func (h *Histogram) Reset() Snapshot {
+	return h.snapshot(true)
+}
+
+func (h *Histogram) snapshot(reset bool) Snapshot {
+	var s Snapshot
+	for i := range h.counts {
+		var n uint64
+		if reset {
+			n = h.counts[i].Swap(0)
+		} else {
+			n = h.counts[i].Load()
+		}
+		if n > 0 {
+			if s.counts == nil {
+				s.counts = make([]uint64, numBuckets)
+			}
+			s.counts[i] = n
+			s.Count += n
+		}
+	}
+	if reset {
+		s.Sum = time.Duration(h.sum.Swap(0))
+		s.Min = time.Duration(max(h.min.Swap(0)-1, 0))
+		s.Max = time.Duration(h.max.Swap(0))
+	} else {
+		s.Sum = time.Duration(h.sum.Load())
+		s.Min = time.Duration(max(h.min.Load()-1, 0))
+		s.Max = time.Duration(h.max.Load())
+	}
+	return s
+}
+
+// Snapshot is a point-in-time copy of a Histogram
+type Snapshot struct {
+	Count uint64
+	Sum   time.Duration
+	Min   time.Duration
+	Max   time.Duration
+
+	counts []uint64 // Per bucket; nil when empty
+}
+
+// Mean returns the average duration, or 0 if there are none
+func (s Snapshot) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / time.Duration(s.Count)
+}
+
+// Quantile returns the duration below which a fraction q of the recorded
+// durations fall, such as 0.99 for the 99th percentile. The result is the
+// upper bound of the bucket holding that rank, clamped to Min and Max, so
+// Quantile(0) is Min and Quantile(1) is Max.
+func (s Snapshot) Quantile(q float64) time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(min(max(q, 0), 1) * float64(s.Count)))
+	rank = max(rank, 1)
+
+	var seen uint64
+	for i, n := range s.counts {
+		seen += n
+		if seen >= rank {
+			_, hi := bucketBounds(i)
+			return min(max(time.Duration(hi), s.Min), s.Max)
+		}
+	}
+	return s.Max
+}
+
+// Merge returns the combined distribution of s and other
+func (s Snapshot) Merge(other Snapshot) Snapshot {
+	switch {
+	case other.Count == 0:
+		return s
+	case s.Count == 0:
+		return other
+	}
+	merged := Snapshot{
+		Count:  s.Count + other.Count,
+		Sum:    s.Sum + other.Sum,
+		Min:    min(s.Min, other.Min),
+		Max:    max(s.Max, other.Max),
+		counts: make([]uint64, numBuckets),
+	}
+	for i := range merged.counts {
+		merged.counts[i] = s.counts[i] + other.counts[i]
+	}
+	return merged
+}
+
+// MarshalJSON encodes the summary statistics in seconds:
+//
+//	{"count":10,"sum":1.02,"min":0.1,"mean":0.102,"p50":0.101,"p90":0.104,"p99":0.105,"max":0.105}
+func (s Snapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Count uint64  `json:"count"`
+		Sum   float64 `json:"sum"`
+		Min   float64 `json:"min"`
+		Mean  float64 `json:"mean"`
+		P50   float64 `json:"p50"`
+		P90   float64 `json:"p90"`
+		P99   float64 `json:"p99"`
+		Max   float64 `json:"max"`
+	}{
+		Count: s.Count,
+		Sum:   s.Sum.Seconds(),
+		Min:   s.Min.Seconds(),
+		Mean:  s.Mean().Seconds(),
+		P50:   s.Quantile(0.5).Seconds(),
+		P90:   s.Quantile(0.9).Seconds(),
+		P99:   s.Quantile(0.99).Seconds(),
+		Max:   s.Max.Seconds(),
+	})
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"go-by-example/pkg/clock"
+)
+
+// Point is the number of events in one slot of a Meter
+type Point struct {
+	Start time.Time `json:"start"`
+	Count uint64    `json:"count"`
+}
+
+// Meter counts events in consecutive slots of equal length and keeps the
+// most recent ones, to report throughput over a sliding window and how it
+// changed over time
+type Meter struct {
+	mu     sync.Mutex
+	clk    clock.Clock
+	slot   time.Duration
+	origin time.Time
+	counts []uint64 // Ring of slots, indexed by slot number modulo len
+	head   int64    // Number of the newest slot since origin
+}
+
+// NewMeter returns a Meter keeping slots slots of the given length, so its
+// window is slots*slot long. A nil clock means the real one.
+func NewMeter(clk clock.Clock, slot time.Duration, slots int) *Meter {
+	if clk == nil {
+		clk = clock.New()
+	}
+	if slot <= 0 || slots < 1 {
+		panic("metrics: meter needs a positive slot length and count")
+	}
+	return &Meter{
+		clk:    clk,
+		slot:   slot,
+		origin: clk.Now(),
+		counts: make([]uint64, slots),
+	}
+}
+
+// Mark records n events now
+func (m *Meter) Mark(n uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.advance(m.clk.Now())
+	m.counts[m.head%int64(len(m.counts))] += n
+}
+
+// Rate returns the events per second over the window, or over the time
+// since the Meter started or was reset if that is shorter
+func (m *Meter) Rate() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.clk.Now()
+	m.advance(now)
+
+	slots := min(m.head+1, int64(len(m.counts)))
+	oldest := m.origin.Add(time.Duration(m.head-slots+1) * m.slot)
+	elapsed := now.Sub(oldest)
+	if elapsed <= 0 {
+		return 0
+	}
+	var total uint64
+	for _, n := range m.counts {
+		total += n
+	}
+	return float64(total) / elapsed.Seconds()
+}
+
+// Series returns the slots in the window, oldest first. The last one is
+// still filling up.
+func (m *Meter) Series() []Point {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.advance(m.clk.Now())
+
+	slots := min(m.head+1, int64(len(m.counts)))
+	points := make([]Point, 0, slots)
+	for n := m.head - slots + 1; n <= m.head; n++ {
+		points = append(points, Point{
+			Start: m.origin.Add(time.Duration(n) * m.slot),
+			Count: m.counts[n%int64(len(m.counts))],
+		})
+	}
+	return points
+}
+
+// Reset clears the Meter and starts its first slot now
+func (m *Meter) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.counts)
+	m.origin = m.clk.Now()
+	m.head = 0
+}
+
+// advance moves the head to the slot containing now, clearing the slots it
+// passes over. m.mu must be held.
+func (m *Meter) advance(now time.Time) {
+	n := int64(now.Sub(m.origin) / m.slot)
+	if n <= m.head {
+		return
+	}
+	if n-m.head >= int64(len(m.counts)) {
+		clear(m.counts)
+	} else {
+		for i := m.head + 1; i <= n; i++ {
+			m.counts[i%int64(len(m.counts))] = 0
+		}
+	}
+	m.head = n
+}
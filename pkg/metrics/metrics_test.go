@@ -0,0 +1,241 @@
+package metrics
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-by-example/pkg/clock"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+func TestBuckets(t *testing.T) {
+	// Every value lies within the bounds of its bucket, and buckets are
+	// contiguous
+	var prevHi uint64
+	for i := range numBuckets - subBuckets {
+		lo, hi := bucketBounds(i)
+		if i > 0 && lo != prevHi+1 {
+			t.Fatalf("bucket %d starts at %d, want %d", i, lo, prevHi+1)
+		}
+		if bucketOf(lo) != i || bucketOf(hi) != i {
+			t.Fatalf("bucket %d [%d, %d]: bucketOf gives %d and %d", i, lo, hi, bucketOf(lo), bucketOf(hi))
+		}
+		if lo >= subBuckets && float64(hi-lo)/float64(lo) > 1.0/subBuckets {
+			t.Fatalf("bucket %d [%d, %d] is too wide", i, lo, hi)
+		}
+		prevHi = hi
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	var h Histogram
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+	s := h.Snapshot()
+
+	tests := []struct {
+		name     string
+		q        float64
+		expected time.Duration
+	}{
+		{name: "min", q: 0, expected: time.Millisecond},
+		{name: "p50", q: 0.5, expected: 500 * time.Millisecond},
+		{name: "p90", q: 0.9, expected: 900 * time.Millisecond},
+		{name: "p99", q: 0.99, expected: 990 * time.Millisecond},
+		{name: "max", q: 1, expected: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.Quantile(tt.q)
+			if got < tt.expected || float64(got-tt.expected) > float64(tt.expected)/subBuckets {
+				t.Errorf("got %v, want %v within %.1f%%", got, tt.expected, 100.0/subBuckets)
+			}
+		})
+	}
+
+	if s.Count != 1000 || s.Min != time.Millisecond || s.Max != time.Second {
+		t.Errorf("got count %d, min %v, max %v, want 1000, 1ms, 1s", s.Count, s.Min, s.Max)
+	}
+	if got, want := s.Mean(), 500500*time.Microsecond; got != want {
+		t.Errorf("got mean %v, want %v", got, want)
+	}
+}
+
+func TestEmptySnapshot(t *testing.T) {
+	var h Histogram
+	s := h.Snapshot()
+	if s.Count != 0 || s.Min != 0 || s.Max != 0 || s.Quantile(0.5) != 0 || s.Mean() != 0 {
+		t.Errorf("got %+v, want all zero", s)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"count":0,"sum":0,"min":0,"mean":0,"p50":0,"p90":0,"p99":0,"max":0}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestResetCountsEveryValueOnce(t *testing.T) {
+	var h Histogram
+	const writers, perWriter = 4, 10000
+
+	var wg sync.WaitGroup
+	var sum atomic.Int64
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perWriter {
+				d := time.Duration(rand.IntN(1000)) * time.Microsecond
+				h.Record(d)
+				sum.Add(int64(d))
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	var total Snapshot
+	go func() {
+		defer close(done)
+		for range 100 {
+			total = total.Merge(h.Reset())
+		}
+	}()
+	wg.Wait()
+	<-done
+	total = total.Merge(h.Reset())
+
+	if total.Count != writers*perWriter {
+		t.Errorf("got %d values across resets, want %d", total.Count, writers*perWriter)
+	}
+	if total.Sum != time.Duration(sum.Load()) {
+		t.Errorf("got sum %v across resets, want %v", total.Sum, time.Duration(sum.Load()))
+	}
+	if h.Snapshot().Count != 0 {
+		t.Error("histogram not empty after the final Reset")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	var a, b Histogram
+	a.Record(10 * time.Millisecond)
+	a.Record(20 * time.Millisecond)
+	b.Record(5 * time.Millisecond)
+	b.Record(40 * time.Millisecond)
+
+	m := a.Snapshot().Merge(b.Snapshot())
+	if m.Count != 4 || m.Min != 5*time.Millisecond || m.Max != 40*time.Millisecond || m.Sum != 75*time.Millisecond {
+		t.Errorf("got %+v, want 4 values from 5ms to 40ms summing to 75ms", m)
+	}
+	if got := m.Quantile(0.5); got < 10*time.Millisecond || got > 11*time.Millisecond {
+		t.Errorf("got p50 %v, want about 10ms", got)
+	}
+}
+
+func TestMeter(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	m := NewMeter(clk, time.Second, 3)
+
+	m.Mark(4)
+	clk.Advance(500 * time.Millisecond)
+	if got := m.Rate(); got != 8 {
+		t.Errorf("got rate %v after half a second, want 8", got)
+	}
+
+	clk.Advance(time.Second) // Slot 1
+	m.Mark(2)
+	clk.Advance(time.Second) // Slot 2
+	m.Mark(6)
+	clk.Advance(time.Second) // Slot 3; slot 0 drops out of the window
+	m.Mark(1)
+
+	want := []Point{
+		{Start: epoch.Add(time.Second), Count: 2},
+		{Start: epoch.Add(2 * time.Second), Count: 6},
+		{Start: epoch.Add(3 * time.Second), Count: 1},
+	}
+	got := m.Series()
+	if len(got) != len(want) {
+		t.Fatalf("got series %v, want %v", got, want)
+	}
+	for i := range want {
+		if !got[i].Start.Equal(want[i].Start) || got[i].Count != want[i].Count {
+			t.Fatalf("got series %v, want %v", got, want)
+		}
+	}
+	// 9 events between 1s and 3.5s
+	if got := m.Rate(); got != 9/2.5 {
+		t.Errorf("got rate %v, want %v", got, 9/2.5)
+	}
+
+	// A long pause empties the window
+	clk.Advance(time.Minute)
+	if got := m.Rate(); got != 0 {
+		t.Errorf("got rate %v after a pause, want 0", got)
+	}
+
+	m.Reset()
+	if got := m.Series(); len(got) != 1 || got[0].Count != 0 || !got[0].Start.Equal(clk.Now()) {
+		t.Errorf("got series %v after Reset, want one empty slot starting now", got)
+	}
+}
+
+func TestExposition(t *testing.T) {
+	var h Histogram
+	h.Record(100 * time.Millisecond)
+	h.Record(300 * time.Millisecond)
+
+	var b strings.Builder
+	e := NewExposition(&b)
+	e.Family("jobs_total", "counter", "Jobs run.")
+	e.Sample("jobs_total", 2, Label{"queue", `a"b`})
+	e.Family("job_seconds", "summary", "Job duration.")
+	e.Summary("job_seconds", h.Snapshot(), Label{"queue", "a"})
+	if err := e.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP jobs_total Jobs run.
+# TYPE jobs_total counter
+jobs_total{queue="a\"b"} 2
+# HELP job_seconds Job duration.
+# TYPE job_seconds summary
+job_seconds{queue="a",quantile="0.5"} 0.1
+job_seconds{queue="a",quantile="0.9"} 0.3
+job_seconds{queue="a",quantile="0.99"} 0.3
+job_seconds{queue="a",quantile="1"} 0.3
+job_seconds_sum{queue="a"} 0.4
+job_seconds_count{queue="a"} 2
+`
+	// Quantiles are bucket bounds, so compare them within the bucket width
+	gotLines := strings.Split(b.String(), "\n")
+	wantLines := strings.Split(want, "\n")
+	if len(gotLines) != len(wantLines) {
+		t.Fatalf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+	for i, line := range wantLines {
+		name, value, _ := strings.Cut(line, " ")
+		gotName, gotValue, _ := strings.Cut(gotLines[i], " ")
+		if !strings.Contains(name, "quantile") {
+			if gotLines[i] != line {
+				t.Errorf("line %d: got %q, want %q", i+1, gotLines[i], line)
+			}
+			continue
+		}
+		w, _ := strconv.ParseFloat(value, 64)
+		g, err := strconv.ParseFloat(gotValue, 64)
+		if gotName != name || err != nil || g < w || g-w > w/subBuckets {
+			t.Errorf("line %d: got %q, want %q", i+1, gotLines[i], line)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Quantiles are the quantiles written for each summary
+var Quantiles = []float64{0.5, 0.9, 0.99, 1}
+
+// Label is a Prometheus label on a sample
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Exposition writes metrics in the Prometheus text format. Declare each
+// metric family with Family before writing its samples. The first write
+// error is kept and all later writes are skipped, so callers check Err once
+// at the end.
+type Exposition struct {
+	w   io.Writer
+	err error
+}
+
+// NewExposition returns an Exposition writing to w
+func NewExposition(w io.Writer) *Exposition {
+	return &Exposition{w: w}
+}
+
+// Family writes the HELP and TYPE lines of a metric family. typ is one of
+// "counter", "gauge" or "summary".
+func (e *Exposition) Family(name, typ, help string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	e.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// Sample writes one sample of a counter or gauge
+func (e *Exposition) Sample(name string, value float64, labels ...Label) {
+	e.printf("%s%s %s\n", name, formatLabels(labels), formatFloat(value))
+}
+
+// Summary writes a Snapshot as the samples of a summary, in seconds: one
+// per entry of Quantiles, then the sum and the count
+func (e *Exposition) Summary(name string, s Snapshot, labels ...Label) {
+	for _, q := range Quantiles {
+		ql := append(labels[:len(labels):len(labels)], Label{"quantile", formatFloat(q)})
+		e.Sample(name, s.Quantile(q).Seconds(), ql...)
+	}
+	e.Sample(name+"_sum", s.Sum.Seconds(), labels...)
+	e.Sample(name+"_count", float64(s.Count), labels...)
+}
+
+// Err returns the first error writing to the underlying writer
+func (e *Exposition) Err() error {
+	return e.err
+}
+
+func (e *Exposition) printf(format string, args ...any) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = fmt.Fprintf(e.w, format, args...)
+}
+
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `%s="%s"`, l.Name, escape.Replace(l.Value))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,294 @@
+// Package pipeline builds concurrent pipelines out of generic stages
+// connected by channels.
+//
+// Every stage takes a context and one or more input channels, starts the
+// goroutines it needs and returns its output channel right away. A stage
+// closes its output once its input is closed and drained, so closing the
+// source shuts the whole pipeline down from front to back. Cancelling the
+// context stops every stage without draining: each one stops receiving and
+// sending, closes its output and exits, so no goroutine is left blocked.
+//
+//	ctx, cancel := context.WithCancel(ctx)
+//	defer cancel()
+//	ids := pipeline.From(ctx, 1, 2, 3, 4, 5, 6)
+//	even := pipeline.Filter(ctx, ids, func(n int) bool { return n%2 == 0 })
+//	users := pipeline.FanOut(ctx, even, 4, fetchUser)
+//	for batch := range pipeline.Batch(ctx, users, pipeline.BatchOptions{Size: 2}) {
+//		...
+//	}
+//
+// Stages that stop reading early, like Take, leave the stages before them
+// blocked until the context is cancelled, so always cancel it once the
+// pipeline is no longer needed.
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-by-example/pkg/clock"
+)
+
+// send sends v on out, or returns false if ctx is done first
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// recv receives from in, or returns false once in is closed or ctx is done
+func recv[T any](ctx context.Context, in <-chan T) (T, bool) {
+	select {
+	case v, ok := <-in:
+		return v, ok
+	case <-ctx.Done():
+		var zero T
+		return zero, false
+	}
+}
+
+// Generator sends the values returned by next until it reports false
+func Generator[T any](ctx context.Context, next func() (T, bool)) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for {
+			v, ok := next()
+			if !ok || !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// From sends values one by one
+func From[T any](ctx context.Context, values ...T) <-chan T {
+	i := 0
+	return Generator(ctx, func() (T, bool) {
+		if i == len(values) {
+			var zero T
+			return zero, false
+		}
+		i++
+		return values[i-1], true
+	})
+}
+
+// Map sends fn of every value
+func Map[In, Out any](ctx context.Context, in <-chan In, fn func(In) Out) <-chan Out {
+	out := make(chan Out)
+	go func() {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok || !send(ctx, out, fn(v)) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Filter sends the values that keep returns true for
+func Filter[T any](ctx context.Context, in <-chan T, keep func(T) bool) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+			if keep(v) && !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// FlatMap sends every element of fn of every value
+func FlatMap[In, Out any](ctx context.Context, in <-chan In, fn func(In) []Out) <-chan Out {
+	out := make(chan Out)
+	go func() {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+			for _, o := range fn(v) {
+				if !send(ctx, out, o) {
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// BatchOptions configures Batch
+type BatchOptions struct {
+	Size  int           // Values in a full batch, at least 1
+	Wait  time.Duration // Longest a value waits for its batch to fill up; 0 waits until it is full
+	Clock clock.Clock   // Measures Wait, clock.New() if nil
+}
+
+// Batch groups values into slices of opts.Size. A batch is sent early if
+// opts.Wait has passed since its first value arrived, and the last batch
+// may be short when in is closed.
+func Batch[T any](ctx context.Context, in <-chan T, opts BatchOptions) <-chan []T {
+	size := max(opts.Size, 1)
+	clk := opts.Clock
+	if clk == nil {
+		clk = clock.New()
+	}
+
+	out := make(chan []T)
+	go func() {
+		defer close(out)
+		var batch []T
+		var timer clock.Timer
+		var expired <-chan time.Time // Set while a partial batch waits
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+				timer, expired = nil, nil
+			}
+			b := batch
+			batch = nil
+			return send(ctx, out, b)
+		}
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					if len(batch) > 0 {
+						flush()
+					}
+					return
+				}
+				batch = append(batch, v)
+				if len(batch) >= size {
+					if !flush() {
+						return
+					}
+				} else if len(batch) == 1 && opts.Wait > 0 {
+					timer = clk.NewTimer(opts.Wait)
+					expired = timer.C()
+				}
+			case <-expired:
+				timer, expired = nil, nil
+				if !flush() {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Merge sends the values of all inputs on one channel, in the order they
+// arrive, and closes it once every input is closed. It is the fan-in half
+// of fanning work out to several stages.
+func Merge[T any](ctx context.Context, ins ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	for _, in := range ins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				v, ok := recv(ctx, in)
+				if !ok || !send(ctx, out, v) {
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// FanOut runs fn on n workers that share in, and sends the results as they
+// finish, so they may come out in a different order than they came in
+func FanOut[In, Out any](ctx context.Context, in <-chan In, n int, fn func(In) Out) <-chan Out {
+	outs := make([]<-chan Out, max(n, 1))
+	for i := range outs {
+		outs[i] = Map(ctx, in, fn)
+	}
+	return Merge(ctx, outs...)
+}
+
+// Tee sends every value to both outputs. Each value waits until both have
+// taken it, so the slower reader sets the pace.
+func Tee[T any](ctx context.Context, in <-chan T) (<-chan T, <-chan T) {
+	out1, out2 := make(chan T), make(chan T)
+	go func() {
+		defer close(out1)
+		defer close(out2)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+			// Send to whichever is ready first, then to the other
+			o1, o2 := out1, out2
+			for range 2 {
+				select {
+				case o1 <- v:
+					o1 = nil
+				case o2 <- v:
+					o2 = nil
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out1, out2
+}
+
+// Take sends the first n values and then closes its output. It stops
+// reading in after n values; cancel ctx to stop the stages before it.
+func Take[T any](ctx context.Context, in <-chan T, n int) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for range n {
+			v, ok := recv(ctx, in)
+			if !ok || !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Collect receives every value until in is closed or ctx is done
+func Collect[T any](ctx context.Context, in <-chan T) []T {
+	var values []T
+	for {
+		v, ok := recv(ctx, in)
+		if !ok {
+			return values
+		}
+		values = append(values, v)
+	}
+}
@@ -0,0 +1,239 @@
+package pipeline
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/pkg/clock"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+// count generates 1, 2, 3... forever
+func count(ctx context.Context) <-chan int {
+	n := 0
+	return Generator(ctx, func() (int, bool) {
+		n++
+		return n, true
+	})
+}
+
+func square(n int) int      { return n * n }
+func isEven(n int) bool     { return n%2 == 0 }
+func twice(n int) []int     { return []int{n, n} }
+func flatten(b []int) []int { return b }
+
+func TestStages(t *testing.T) {
+	tests := []struct {
+		name     string
+		run      func(ctx context.Context) <-chan int
+		expected []int
+	}{
+		{
+			name:     "from",
+			run:      func(ctx context.Context) <-chan int { return From(ctx, 3, 1, 2) },
+			expected: []int{3, 1, 2},
+		},
+		{
+			name:     "map",
+			run:      func(ctx context.Context) <-chan int { return Map(ctx, From(ctx, 1, 2, 3), square) },
+			expected: []int{1, 4, 9},
+		},
+		{
+			name:     "filter",
+			run:      func(ctx context.Context) <-chan int { return Filter(ctx, From(ctx, 1, 2, 3, 4), isEven) },
+			expected: []int{2, 4},
+		},
+		{
+			name:     "flat map",
+			run:      func(ctx context.Context) <-chan int { return FlatMap(ctx, From(ctx, 1, 2), twice) },
+			expected: []int{1, 1, 2, 2},
+		},
+		{
+			name:     "take from an endless source",
+			run:      func(ctx context.Context) <-chan int { return Take(ctx, count(ctx), 4) },
+			expected: []int{1, 2, 3, 4},
+		},
+		{
+			name:     "take more than there is",
+			run:      func(ctx context.Context) <-chan int { return Take(ctx, From(ctx, 1, 2), 5) },
+			expected: []int{1, 2},
+		},
+		{
+			name: "chained",
+			run: func(ctx context.Context) <-chan int {
+				return Take(ctx, Map(ctx, Filter(ctx, count(ctx), isEven), square), 3)
+			},
+			expected: []int{4, 16, 36},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaktest.Check(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if got := Collect(ctx, tt.run(ctx)); !slices.Equal(got, tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnorderedStages(t *testing.T) {
+	tests := []struct {
+		name     string
+		run      func(ctx context.Context) <-chan int
+		expected []int
+	}{
+		{
+			name:     "merge",
+			run:      func(ctx context.Context) <-chan int { return Merge(ctx, From(ctx, 1, 3), From(ctx, 2), From[int](ctx)) },
+			expected: []int{1, 2, 3},
+		},
+		{
+			name:     "merge nothing",
+			run:      func(ctx context.Context) <-chan int { return Merge[int](ctx) },
+			expected: nil,
+		},
+		{
+			name:     "fan out",
+			run:      func(ctx context.Context) <-chan int { return FanOut(ctx, From(ctx, 1, 2, 3, 4, 5), 3, square) },
+			expected: []int{1, 4, 9, 16, 25},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaktest.Check(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			got := Collect(ctx, tt.run(ctx))
+			slices.Sort(got)
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTee(t *testing.T) {
+	leaktest.Check(t)
+	ctx := context.Background()
+	a, b := Tee(ctx, From(ctx, 1, 2, 3))
+
+	gotB := make(chan []int)
+	go func() { gotB <- Collect(ctx, b) }()
+	want := []int{1, 2, 3}
+	if got := Collect(ctx, a); !slices.Equal(got, want) {
+		t.Errorf("first output: got %v, want %v", got, want)
+	}
+	if got := <-gotB; !slices.Equal(got, want) {
+		t.Errorf("second output: got %v, want %v", got, want)
+	}
+}
+
+func TestBatchSize(t *testing.T) {
+	leaktest.Check(t)
+	ctx := context.Background()
+	got := Collect(ctx, Batch(ctx, From(ctx, 1, 2, 3, 4, 5, 6, 7), BatchOptions{Size: 3}))
+	want := [][]int{{1, 2, 3}, {4, 5, 6}, {7}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestBatchWait(t *testing.T) {
+	leaktest.Check(t)
+	clk := clock.NewFake(epoch)
+	ctx := context.Background()
+	in := make(chan int)
+	batches := Batch(ctx, in, BatchOptions{Size: 3, Wait: time.Second, Clock: clk})
+
+	// A partial batch goes out once its first value has waited long enough
+	in <- 1
+	in <- 2
+	clk.BlockUntil(1)
+	clk.Advance(time.Second)
+	if got := <-batches; !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("got %v, want [1 2]", got)
+	}
+
+	// A full batch doesn't wait, and stops its timer
+	in <- 3
+	in <- 4
+	in <- 5
+	if got := <-batches; !slices.Equal(got, []int{3, 4, 5}) {
+		t.Errorf("got %v, want [3 4 5]", got)
+	}
+	if n := clk.Waiters(); n != 0 {
+		t.Errorf("got %d timers, want 0", n)
+	}
+
+	in <- 6
+	close(in)
+	if got := <-batches; !slices.Equal(got, []int{6}) {
+		t.Errorf("got %v, want [6]", got)
+	}
+	if _, ok := <-batches; ok {
+		t.Error("output still open after the input closed")
+	}
+}
+
+func TestCancellation(t *testing.T) {
+	tests := []struct {
+		name  string
+		stage func(ctx context.Context, in <-chan int) <-chan int
+	}{
+		{name: "map", stage: func(ctx context.Context, in <-chan int) <-chan int { return Map(ctx, in, square) }},
+		{name: "filter", stage: func(ctx context.Context, in <-chan int) <-chan int { return Filter(ctx, in, isEven) }},
+		{name: "flat map", stage: func(ctx context.Context, in <-chan int) <-chan int { return FlatMap(ctx, in, twice) }},
+		{name: "take", stage: func(ctx context.Context, in <-chan int) <-chan int { return Take(ctx, in, 1000) }},
+		{name: "merge", stage: func(ctx context.Context, in <-chan int) <-chan int { return Merge(ctx, in, count(ctx)) }},
+		{name: "fan out", stage: func(ctx context.Context, in <-chan int) <-chan int { return FanOut(ctx, in, 4, square) }},
+		{
+			name: "tee with an idle reader",
+			stage: func(ctx context.Context, in <-chan int) <-chan int {
+				out, _ := Tee(ctx, in)
+				return out
+			},
+		},
+		{
+			name: "batch",
+			stage: func(ctx context.Context, in <-chan int) <-chan int {
+				return FlatMap(ctx, Batch(ctx, in, BatchOptions{Size: 2, Wait: time.Hour}), flatten)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaktest.Check(t)
+			ctx, cancel := context.WithCancel(context.Background())
+
+			// The stage reads an endless source and the test stops
+			// reading its output; only cancellation ends it
+			out := tt.stage(ctx, count(ctx))
+			<-out
+			cancel()
+			for range out {
+			}
+		})
+	}
+}
+
+func TestCancelledInputNeverCloses(t *testing.T) {
+	leaktest.Check(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int) // Nobody sends or closes
+	out := Map(ctx, in, square)
+	cancel()
+	if _, ok := <-out; ok {
+		t.Error("got a value from a cancelled stage")
+	}
+}
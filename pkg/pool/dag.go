@@ -0,0 +1,339 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go-by-example/pkg/clock"
+)
+
+// ErrSkipped is the error of a task that never ran, or was interrupted,
+// because a task it depends on failed
+var ErrSkipped = errors.New("pool: skipped")
+
+// FailurePolicy decides what a Graph does when a task fails
+type FailurePolicy int
+
+const (
+	// FailFast cancels running tasks and skips everything not yet started
+	FailFast FailurePolicy = iota
+	// SkipDependents skips the tasks that depend on the failure, directly
+	// or not, and runs everything else
+	SkipDependents
+)
+
+// TaskFunc runs one task of a Graph with the results of the tasks it depends
+// on, keyed by their names
+type TaskFunc[T any] func(ctx context.Context, deps map[string]T) (T, error)
+
+// CycleError is returned by Graph.Validate for tasks that depend on
+// themselves, directly or through others
+type CycleError struct {
+	Cycle []string // Task names around the cycle, the first repeated at the end
+}
+
+func (e *CycleError) Error() string {
+	return "pool: dependency cycle " + strings.Join(e.Cycle, " -> ")
+}
+
+// TaskError is a failed task in the error returned by Graph.Run
+type TaskError struct {
+	Name string
+	Err  error
+}
+
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("task %s: %v", e.Name, e.Err)
+}
+
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// TaskReport is the outcome of one task of a Graph
+type TaskReport[T any] struct {
+	Name     string
+	Value    T
+	Err      error // Error of the task, or wrapping ErrSkipped
+	Worker   int   // Worker that ran the task, 0 if it never ran
+	Start    time.Time
+	End      time.Time
+	Waited   time.Duration // Time ready but waiting for a worker
+	Attempts []Attempt
+}
+
+// Skipped reports whether the task was skipped because of another failure
+func (r TaskReport[T]) Skipped() bool {
+	return errors.Is(r.Err, ErrSkipped)
+}
+
+// Report is the outcome of Graph.Run
+type Report[T any] struct {
+	Tasks        map[string]TaskReport[T]
+	Elapsed      time.Duration // From the start of Run until the last task finished
+	CriticalPath []string      // The chain of dependent tasks that took longest, first to last
+	Critical     time.Duration // Time spent running the tasks on the critical path
+}
+
+// Graph is a set of tasks with dependencies between them. Run executes it
+// on a Pool: a task is submitted once every task it depends on succeeded,
+// so independent tasks run in parallel, and it receives their results.
+//
+// A Graph isn't safe for concurrent use while tasks are added, but it may be
+// run any number of times, concurrently as well.
+type Graph[T any] struct {
+	nodes map[string]*dagNode[T]
+	names []string // In the order they were added
+}
+
+type dagNode[T any] struct {
+	name string
+	fn   TaskFunc[T]
+	deps []string
+}
+
+// NewGraph returns an empty Graph
+func NewGraph[T any]() *Graph[T] {
+	return &Graph[T]{nodes: make(map[string]*dagNode[T])}
+}
+
+// Add adds a task that runs fn once the tasks named in deps have succeeded.
+// The dependencies may be added later, but must be there when the Graph runs.
+func (g *Graph[T]) Add(name string, fn TaskFunc[T], deps ...string) error {
+	if _, ok := g.nodes[name]; ok {
+		return fmt.Errorf("pool: task %s added twice", name)
+	}
+	g.nodes[name] = &dagNode[T]{name: name, fn: fn, deps: slices.Clone(deps)}
+	g.names = append(g.names, name)
+	return nil
+}
+
+// Validate checks that every dependency exists and that there are no
+// cycles, returning a *CycleError for the first cycle found
+func (g *Graph[T]) Validate() error {
+	_, err := g.sort()
+	return err
+}
+
+// sort returns the task names with every task after its dependencies
+func (g *Graph[T]) sort() ([]string, error) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(g.nodes))
+	order := make([]string, 0, len(g.nodes))
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			start := slices.Index(path, name)
+			return &CycleError{Cycle: append(slices.Clone(path[start:]), name)}
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range g.nodes[name].deps {
+			if _, ok := g.nodes[dep]; !ok {
+				return fmt.Errorf("pool: task %s depends on unknown task %s", name, dep)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range g.names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// dagTask is a task of a Graph on its way through the Pool
+type dagTask[T any] struct {
+	node  *dagNode[T]
+	deps  map[string]T
+	ready time.Time
+}
+
+// Run validates the Graph and executes it on a new Pool configured by
+// opts, whose QueueSize and Ordered are ignored. It returns once every task
+// has finished or been skipped, with a report of all of them and an error
+// joining a *TaskError for each task that failed.
+func (g *Graph[T]) Run(ctx context.Context, policy FailurePolicy, opts Options) (*Report[T], error) {
+	order, err := g.sort()
+	if err != nil {
+		return nil, err
+	}
+	clk := opts.Clock
+	if clk == nil {
+		clk = clock.New()
+		opts.Clock = clk
+	}
+	start := clk.Now()
+
+	// waiting counts the dependencies each task still waits for
+	waiting := make(map[string]int, len(order))
+	dependents := make(map[string][]string, len(order))
+	for _, name := range order {
+		n := g.nodes[name]
+		waiting[name] = len(n.deps)
+		for _, dep := range n.deps {
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	fn := func(ctx context.Context, t dagTask[T]) (T, error) {
+		return t.node.fn(ctx, t.deps)
+	}
+	opts.QueueSize = len(order) // Submit never blocks the loop below
+	opts.Ordered = false        // Nor waits on unrelated slow tasks
+	p := New(runCtx, fn, opts)
+
+	report := &Report[T]{Tasks: make(map[string]TaskReport[T], len(order))}
+	values := make(map[string]T, len(order))
+	var failures []error
+	var failed string // First failure, once fail fast has cancelled the run
+	running := 0
+
+	submit := func(name string) {
+		n := g.nodes[name]
+		deps := make(map[string]T, len(n.deps))
+		for _, dep := range n.deps {
+			deps[dep] = values[dep]
+		}
+		if err := p.Submit(runCtx, dagTask[T]{node: n, deps: deps, ready: clk.Now()}); err != nil {
+			// Only when ctx is done, as the queue has room for every task
+			report.Tasks[name] = TaskReport[T]{Name: name, Err: err}
+			failures = append(failures, &TaskError{Name: name, Err: err})
+			return
+		}
+		running++
+	}
+	// skip marks name and everything depending on it as skipped
+	var skip func(name, cause string)
+	skip = func(name, cause string) {
+		if _, ok := report.Tasks[name]; ok {
+			return
+		}
+		report.Tasks[name] = TaskReport[T]{Name: name, Err: fmt.Errorf("%w: %s failed", ErrSkipped, cause)}
+		for _, d := range dependents[name] {
+			skip(d, cause)
+		}
+	}
+
+	for _, name := range order {
+		if waiting[name] == 0 {
+			submit(name)
+		}
+	}
+	for running > 0 {
+		res := <-p.Results()
+		running--
+		name := res.Input.node.name
+		r := TaskReport[T]{
+			Name:     name,
+			Value:    res.Value,
+			Err:      res.Err,
+			Worker:   res.Worker,
+			Start:    res.Input.ready.Add(res.Waited),
+			Waited:   res.Waited,
+			Attempts: res.Attempts,
+		}
+		switch {
+		case res.Worker == 0:
+			r.Start = clk.Now() // Cancelled before it ran
+			r.End = r.Start
+		case len(res.Attempts) > 0:
+			last := res.Attempts[len(res.Attempts)-1]
+			r.End = last.Start.Add(last.Duration)
+		default:
+			r.End = r.Start.Add(res.Duration)
+		}
+
+		switch {
+		case res.Err == nil:
+			values[name] = res.Value
+			report.Tasks[name] = r
+			for _, d := range dependents[name] {
+				_, skipped := report.Tasks[d]
+				if waiting[d]--; waiting[d] == 0 && failed == "" && !skipped {
+					submit(d)
+				}
+			}
+		case failed != "" && runCtx.Err() != nil && ctx.Err() == nil:
+			// Cancelled by fail fast rather than failing on its own
+			r.Err = fmt.Errorf("%w: %s failed: %w", ErrSkipped, failed, res.Err)
+			report.Tasks[name] = r
+		default:
+			report.Tasks[name] = r
+			failures = append(failures, &TaskError{Name: name, Err: res.Err})
+			if policy == FailFast && failed == "" {
+				failed = name
+				cancel()
+			}
+			for _, d := range dependents[name] {
+				skip(d, name)
+			}
+		}
+	}
+	p.Close()
+	p.Wait()
+
+	// Fail fast leaves tasks that were never submitted
+	for _, name := range order {
+		if _, ok := report.Tasks[name]; !ok {
+			skip(name, failed)
+		}
+	}
+	report.Elapsed = clk.Now().Sub(start)
+	report.CriticalPath, report.Critical = criticalPath(g, order, report.Tasks)
+	return report, errors.Join(failures...)
+}
+
+// criticalPath returns the chain of dependent tasks with the longest total
+// run time. order lists every task after its dependencies.
+func criticalPath[T any](g *Graph[T], order []string, tasks map[string]TaskReport[T]) ([]string, time.Duration) {
+	total := make(map[string]time.Duration, len(order)) // Longest chain ending with the task
+	prev := make(map[string]string, len(order))
+	var last string
+	for _, name := range order {
+		var before time.Duration
+		for _, dep := range g.nodes[name].deps {
+			if total[dep] > before || prev[name] == "" {
+				before, prev[name] = total[dep], dep
+			}
+		}
+		r := tasks[name]
+		total[name] = before + r.End.Sub(r.Start)
+		if last == "" || total[name] > total[last] {
+			last = name
+		}
+	}
+	if last == "" {
+		return nil, 0
+	}
+
+	var path []string
+	for name := last; name != ""; name = prev[name] {
+		path = append(path, name)
+	}
+	slices.Reverse(path)
+	return path, total[last]
+}
@@ -0,0 +1,236 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go-by-example/pkg/clock"
+)
+
+var errBroken = errors.New("broken")
+
+// sum returns a task adding n to the results of its dependencies
+func sum(n int) TaskFunc[int] {
+	return func(ctx context.Context, deps map[string]int) (int, error) {
+		for _, v := range deps {
+			n += v
+		}
+		return n, nil
+	}
+}
+
+func broken(ctx context.Context, deps map[string]int) (int, error) {
+	return 0, errBroken
+}
+
+// sleep returns a task that takes d on clk
+func sleep(clk clock.Clock, d time.Duration) TaskFunc[int] {
+	return func(ctx context.Context, deps map[string]int) (int, error) {
+		select {
+		case <-clk.After(d):
+			return 0, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func TestGraphValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		edges    map[string][]string
+		order    []string
+		expected []string // Cycle, or nil
+		invalid  bool
+	}{
+		{
+			name:  "diamond",
+			edges: map[string][]string{"a": nil, "b": {"a"}, "c": {"a"}, "d": {"b", "c"}},
+			order: []string{"d", "c", "b", "a"},
+		},
+		{
+			name:     "cycle",
+			edges:    map[string][]string{"a": {"c"}, "b": {"a"}, "c": {"b"}},
+			order:    []string{"a", "b", "c"},
+			expected: []string{"a", "c", "b", "a"},
+		},
+		{
+			name:     "depends on itself",
+			edges:    map[string][]string{"a": nil, "b": {"a", "b"}},
+			order:    []string{"a", "b"},
+			expected: []string{"b", "b"},
+		},
+		{
+			name:    "unknown dependency",
+			edges:   map[string][]string{"a": {"missing"}},
+			order:   []string{"a"},
+			invalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGraph[int]()
+			for _, name := range tt.order {
+				g.Add(name, sum(1), tt.edges[name]...)
+			}
+			err := g.Validate()
+
+			var cycle *CycleError
+			switch {
+			case tt.expected != nil:
+				if !errors.As(err, &cycle) || !slices.Equal(cycle.Cycle, tt.expected) {
+					t.Errorf("got %v, want cycle %v", err, tt.expected)
+				}
+			case tt.invalid:
+				if err == nil || errors.As(err, &cycle) {
+					t.Errorf("got %v, want an unknown task error", err)
+				}
+			case err != nil:
+				t.Errorf("got %v, want no error", err)
+			}
+		})
+	}
+
+	g := NewGraph[int]()
+	g.Add("a", sum(1))
+	if err := g.Add("a", sum(2)); err == nil {
+		t.Error("got no error adding a task twice")
+	}
+}
+
+func TestGraphRun(t *testing.T) {
+	g := NewGraph[int]()
+	g.Add("d", sum(1000), "b", "c")
+	g.Add("a", sum(1))
+	g.Add("b", sum(10), "a")
+	g.Add("c", sum(100), "a")
+
+	report, err := g.Run(context.Background(), FailFast, Options{Workers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"a": 1, "b": 11, "c": 101, "d": 1112}
+	for name, value := range want {
+		if r := report.Tasks[name]; r.Value != value || r.Err != nil || r.Worker == 0 {
+			t.Errorf("task %s: got %d, error %v on worker %d, want %d", name, r.Value, r.Err, r.Worker, value)
+		}
+	}
+
+	g.Add("e", sum(0), "f")
+	g.Add("f", sum(0), "e")
+	if _, err := g.Run(context.Background(), FailFast, Options{}); !errors.As(err, new(*CycleError)) {
+		t.Errorf("got %v, want a cycle error", err)
+	}
+}
+
+func TestGraphFailurePolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    FailurePolicy
+		succeeded []string
+		skipped   []string
+	}{
+		{
+			name:      "skip dependents",
+			policy:    SkipDependents,
+			succeeded: []string{"fine", "independent"},
+			skipped:   []string{"child", "grandchild"},
+		},
+		{
+			name:      "fail fast",
+			policy:    FailFast,
+			succeeded: []string{"fine"},
+			skipped:   []string{"child", "grandchild", "independent", "after"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// independent is still running when broken fails. It finishes
+			// only if broken lets it, which it does unless failing fast.
+			started, release := make(chan struct{}), make(chan struct{})
+			g := NewGraph[int]()
+			g.Add("fine", sum(1))
+			g.Add("broken", func(ctx context.Context, deps map[string]int) (int, error) {
+				<-started
+				if tt.policy != FailFast {
+					close(release)
+				}
+				return broken(ctx, deps)
+			}, "fine")
+			g.Add("child", sum(1), "broken", "fine")
+			g.Add("grandchild", sum(1), "child")
+			g.Add("independent", func(ctx context.Context, deps map[string]int) (int, error) {
+				close(started)
+				select {
+				case <-release:
+					return 1, nil
+				case <-ctx.Done():
+					return 0, ctx.Err()
+				}
+			})
+			g.Add("after", sum(1), "independent")
+
+			report, err := g.Run(context.Background(), tt.policy, Options{Workers: 2})
+
+			var taskErr *TaskError
+			if !errors.As(err, &taskErr) || taskErr.Name != "broken" || !errors.Is(err, errBroken) {
+				t.Errorf("got %v, want task broken to fail", err)
+			}
+			for _, name := range tt.succeeded {
+				if r := report.Tasks[name]; r.Err != nil {
+					t.Errorf("task %s: got %v, want success", name, r.Err)
+				}
+			}
+			for _, name := range tt.skipped {
+				if r := report.Tasks[name]; !r.Skipped() {
+					t.Errorf("task %s: got %v, want skipped", name, r.Err)
+				}
+			}
+			if len(report.Tasks) != 6 {
+				t.Errorf("got %d tasks in the report, want 6", len(report.Tasks))
+			}
+		})
+	}
+}
+
+func TestGraphCriticalPath(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	g := NewGraph[int]()
+	g.Add("a", sleep(clk, 10*time.Millisecond))
+	g.Add("b", sleep(clk, 30*time.Millisecond), "a")
+	g.Add("c", sleep(clk, 20*time.Millisecond), "a")
+	g.Add("d", sleep(clk, 10*time.Millisecond), "b", "c")
+
+	done := make(chan *Report[int])
+	go func() {
+		report, err := g.Run(context.Background(), FailFast, Options{Workers: 2, Clock: clk})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- report
+	}()
+
+	clk.BlockUntil(1) // a
+	clk.Advance(10 * time.Millisecond)
+	clk.BlockUntil(2) // b and c
+	clk.Advance(20 * time.Millisecond)
+	clk.Advance(10 * time.Millisecond)
+	clk.BlockUntil(1) // d
+	clk.Advance(10 * time.Millisecond)
+	report := <-done
+
+	if want := []string{"a", "b", "d"}; !slices.Equal(report.CriticalPath, want) {
+		t.Errorf("got critical path %v, want %v", report.CriticalPath, want)
+	}
+	if report.Critical != 50*time.Millisecond || report.Elapsed != 50*time.Millisecond {
+		t.Errorf("got critical %v of %v elapsed, want 50ms of 50ms", report.Critical, report.Elapsed)
+	}
+	if b := report.Tasks["b"]; !b.Start.Equal(epoch.Add(10*time.Millisecond)) || !b.End.Equal(epoch.Add(40*time.Millisecond)) {
+		t.Errorf("got b from %v to %v, want 10ms to 40ms", b.Start.Sub(epoch), b.End.Sub(epoch))
+	}
+}
@@ -0,0 +1,275 @@
+package pool
+
+import (
+	"container/heap"
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"go-by-example/internal/broadcast"
+	"go-by-example/pkg/clock"
+)
+
+// FairOptions configures a FairQueue
+type FairOptions struct {
+	Capacity int            // Entries held before Push blocks; 0 means no limit
+	Aging    time.Duration  // Waiting this long raises an entry's priority by one; 0 disables aging
+	Weights  map[string]int // Share of the workers for each tenant; unlisted tenants weigh 1
+	Clock    clock.Clock    // Measures waiting for aging, clock.New() if nil; use the pool's clock
+}
+
+// FairQueue is a Queue that serves tasks by priority and shares the
+// workers fairly between tenants.
+//
+// Pop picks the highest priority among the tasks at the front of each
+// tenant's line. Every Aging that a task waits adds one to its priority, so
+// low-priority tasks are delayed by a bounded amount rather than starved.
+// When several tenants have a task at the top priority, weighted fair
+// queuing picks between them: each tenant is served in proportion to its
+// weight, so one tenant submitting a flood of tasks only delays the others
+// by its share. Within a tenant, tasks of equal priority run in the order
+// they were pushed.
+type FairQueue[In any] struct {
+	mu       sync.Mutex
+	classify func(In) (priority int, tenant string)
+	opts     FairOptions
+	clk      clock.Clock
+	tenants  map[string]*tenantQueue[In] // Tenants with entries waiting
+	vclock   float64                     // Virtual time of the last tenant served
+	size     int
+	seq      uint64
+	closed   bool
+	changed  broadcast.Signal
+}
+
+// NewFairQueue returns a FairQueue that asks classify for the priority and
+// tenant of each task. Higher priorities are served first.
+func NewFairQueue[In any](classify func(In) (priority int, tenant string), opts FairOptions) *FairQueue[In] {
+	clk := opts.Clock
+	if clk == nil {
+		clk = clock.New()
+	}
+	return &FairQueue[In]{
+		classify: classify,
+		opts:     opts,
+		clk:      clk,
+		tenants:  make(map[string]*tenantQueue[In]),
+	}
+}
+
+// Push adds an entry, blocking while the queue is at capacity
+func (q *FairQueue[In]) Push(ctx context.Context, e Entry[In]) error {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return ErrClosed
+		}
+		if q.opts.Capacity <= 0 || q.size < q.opts.Capacity {
+			q.push(e)
+			q.mu.Unlock()
+			return nil
+		}
+		changed := q.changed.Chan()
+		q.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// TryPush adds an entry, or returns ErrQueueFull at capacity
+func (q *FairQueue[In]) TryPush(e Entry[In]) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	switch {
+	case q.closed:
+		return ErrClosed
+	case q.opts.Capacity > 0 && q.size >= q.opts.Capacity:
+		return ErrQueueFull
+	}
+	q.push(e)
+	return nil
+}
+
+// Pop removes the entry to serve next, blocking until there is one
+func (q *FairQueue[In]) Pop(ctx context.Context) (Entry[In], error) {
+	for {
+		q.mu.Lock()
+		if q.size > 0 {
+			e := q.pop()
+			q.mu.Unlock()
+			return e, nil
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return Entry[In]{}, ErrClosed
+		}
+		changed := q.changed.Chan()
+		q.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return Entry[In]{}, ctx.Err()
+		}
+	}
+}
+
+// Close stops Push and lets Pop drain the remaining entries
+func (q *FairQueue[In]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		q.changed.Notify()
+	}
+}
+
+// Len returns the number of entries waiting
+func (q *FairQueue[In]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// push adds an entry to its tenant's line. q.mu must be held.
+func (q *FairQueue[In]) push(e Entry[In]) {
+	priority, name := q.classify(e.Value)
+	t, ok := q.tenants[name]
+	if !ok {
+		weight := 1
+		if w, ok := q.opts.Weights[name]; ok && w > 0 {
+			weight = w
+		}
+		// A tenant that was idle starts level with the others instead of
+		// catching up on the service it missed
+		t = &tenantQueue[In]{name: name, weight: float64(weight), vtime: q.vclock}
+		q.tenants[name] = t
+	}
+
+	q.seq++
+	heap.Push(&t.entries, fairEntry[In]{Entry: e, priority: priority, seq: q.seq, aging: q.opts.Aging})
+	q.size++
+	q.changed.Notify()
+}
+
+// pop removes the next entry. q.mu must be held and the queue not empty.
+func (q *FairQueue[In]) pop() Entry[In] {
+	now := q.clk.Now()
+	var best *tenantQueue[In]
+	var bestLevel int64
+	for _, t := range q.tenants {
+		level := t.entries[0].level(now)
+		if best == nil || level > bestLevel || level == bestLevel && t.before(best) {
+			best, bestLevel = t, level
+		}
+	}
+
+	e := heap.Pop(&best.entries).(fairEntry[In])
+	q.vclock = best.vtime
+	best.vtime += 1 / best.weight
+	if len(best.entries) == 0 {
+		delete(q.tenants, best.name)
+	}
+	q.size--
+	q.changed.Notify()
+	return e.Entry
+}
+
+// tenantQueue is one tenant's line of entries
+type tenantQueue[In any] struct {
+	name    string
+	weight  float64
+	vtime   float64 // Service received, scaled by weight
+	entries fairHeap[In]
+}
+
+// before reports whether t is due before other at the same priority: the
+// tenant that received less weighted service goes first
+func (t *tenantQueue[In]) before(other *tenantQueue[In]) bool {
+	if t.vtime != other.vtime {
+		return t.vtime < other.vtime
+	}
+	return t.name < other.name
+}
+
+type fairEntry[In any] struct {
+	Entry[In]
+	priority int
+	seq      uint64
+	aging    time.Duration
+}
+
+// level returns the priority of the entry after aging, saturating rather
+// than wrapping around for priorities near the limits of int
+func (e fairEntry[In]) level(now time.Time) int64 {
+	if e.aging <= 0 {
+		return int64(e.priority)
+	}
+	return addSat(int64(e.priority), int64(now.Sub(e.Enqueued)/e.aging))
+}
+
+// ahead reports whether e is served before other of the same tenant. Since
+// every entry ages at the same rate, the order doesn't change over time: an
+// entry is ahead if its priority, converted to waiting time, plus the time
+// it has waited is larger. Priorities worth more waiting time than an int64
+// holds count as the most that it does.
+func (e fairEntry[In]) ahead(other fairEntry[In]) bool {
+	if e.aging > 0 {
+		a := addSat(mulSat(int64(e.priority), int64(e.aging)), -e.Enqueued.UnixNano())
+		b := addSat(mulSat(int64(other.priority), int64(other.aging)), -other.Enqueued.UnixNano())
+		if a != b {
+			return a > b
+		}
+	} else if e.priority != other.priority {
+		return e.priority > other.priority
+	}
+	return e.seq < other.seq
+}
+
+// addSat returns a+b, clamped to the range of int64
+func addSat(a, b int64) int64 {
+	s := a + b
+	switch {
+	case a > 0 && b > 0 && s < 0:
+		return math.MaxInt64
+	case a < 0 && b < 0 && s >= 0:
+		return math.MinInt64
+	}
+	return s
+}
+
+// mulSat returns a*b, clamped to the range of int64
+func mulSat(a, b int64) int64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	p := a * b
+	if p/b != a || b == -1 && a == math.MinInt64 {
+		if (a < 0) != (b < 0) {
+			return math.MinInt64
+		}
+		return math.MaxInt64
+	}
+	return p
+}
+
+// fairHeap implements heap.Interface with the next entry first
+type fairHeap[In any] []fairEntry[In]
+
+func (h fairHeap[In]) Len() int           { return len(h) }
+func (h fairHeap[In]) Less(i, j int) bool { return h[i].ahead(h[j]) }
+func (h fairHeap[In]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *fairHeap[In]) Push(x any)        { *h = append(*h, x.(fairEntry[In])) }
+
+func (h *fairHeap[In]) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
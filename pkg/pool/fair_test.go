@@ -0,0 +1,231 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go-by-example/pkg/clock"
+)
+
+// job is a task for the fair queue tests, written as "tenant/name:priority"
+type job string
+
+func classifyJob(j job) (int, string) {
+	tenant, rest, _ := strings.Cut(string(j), "/")
+	_, prio, _ := strings.Cut(rest, ":")
+	var p int
+	fmt.Sscan(prio, &p)
+	return p, tenant
+}
+
+// drain pops everything from q
+func drain(t *testing.T, q Queue[job]) []job {
+	t.Helper()
+	q.Close()
+	var out []job
+	for {
+		e, err := q.Pop(context.Background())
+		if errors.Is(err, ErrClosed) {
+			return out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, e.Value)
+	}
+}
+
+func TestFairQueueOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     FairOptions
+		push     []job
+		expected []job
+	}{
+		{
+			name:     "priority then FIFO",
+			push:     []job{"a/1:0", "a/2:5", "a/3:0", "a/4:5"},
+			expected: []job{"a/2:5", "a/4:5", "a/1:0", "a/3:0"},
+		},
+		{
+			name:     "round robin between equal tenants",
+			push:     []job{"a/1", "a/2", "a/3", "a/4", "b/1", "b/2"},
+			expected: []job{"a/1", "b/1", "a/2", "b/2", "a/3", "a/4"},
+		},
+		{
+			name:     "weights",
+			opts:     FairOptions{Weights: map[string]int{"a": 3}},
+			push:     []job{"a/1", "a/2", "a/3", "a/4", "a/5", "a/6", "b/1", "b/2"},
+			expected: []job{"a/1", "b/1", "a/2", "a/3", "a/4", "b/2", "a/5", "a/6"},
+		},
+		{
+			name:     "priority beats fairness",
+			push:     []job{"a/1", "a/2", "b/1:1", "b/2:1"},
+			expected: []job{"b/1:1", "b/2:1", "a/1", "a/2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewFairQueue(classifyJob, tt.opts)
+			for _, j := range tt.push {
+				if err := q.Push(context.Background(), Entry[job]{Value: j}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := drain(t, q); !slices.Equal(got, tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFairQueueAging(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	q := NewFairQueue(classifyJob, FairOptions{Aging: time.Second, Clock: clk})
+	push := func(j job) {
+		q.Push(context.Background(), Entry[job]{Value: j, Enqueued: clk.Now()})
+	}
+
+	push("a/old:0")
+	clk.Advance(2 * time.Second)
+	push("a/new:1")  // Behind old, which is at level 2 by now
+	push("b/new:2")  // Level 2 as well, but after a, which hasn't been served either
+	push("b/next:1") // Level 1, after everything that has aged
+
+	want := []job{"a/old:0", "b/new:2", "a/new:1", "b/next:1"}
+	if got := drain(t, q); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFairQueueExtremePriorities(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	push := func(q *FairQueue[job], jobs ...job) {
+		for _, j := range jobs {
+			q.Push(context.Background(), Entry[job]{Value: j, Enqueued: clk.Now()})
+		}
+	}
+	maxJob := func(tenant string) job { return job(fmt.Sprintf("%s/max:%d", tenant, math.MaxInt)) }
+	minJob := func(tenant string) job { return job(fmt.Sprintf("%s/min:%d", tenant, math.MinInt)) }
+
+	// Converted to waiting time, the priorities overflow an int64
+	q := NewFairQueue(classifyJob, FairOptions{Aging: time.Hour, Clock: clk})
+	push(q, minJob("a"), "a/one:1", maxJob("a"), "a/zero:0")
+	want := []job{maxJob("a"), "a/one:1", "a/zero:0", minJob("a")}
+	if got := drain(t, q); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// So long an aging step that no wait reaches it
+	q = NewFairQueue(classifyJob, FairOptions{Aging: time.Duration(math.MaxInt64), Clock: clk})
+	push(q, minJob("a"), "a/zero:0", maxJob("a"))
+	want = []job{maxJob("a"), "a/zero:0", minJob("a")}
+	if got := drain(t, q); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Aging raises the level of the largest priority past int
+	q = NewFairQueue(classifyJob, FairOptions{Aging: time.Second, Clock: clk})
+	push(q, maxJob("a"), minJob("c"))
+	clk.Advance(time.Duration(math.MaxInt64))
+	push(q, "b/zero:0")
+	want = []job{maxJob("a"), "b/zero:0", minJob("c")}
+	if got := drain(t, q); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFairQueueNoisyTenant(t *testing.T) {
+	q := NewFairQueue(classifyJob, FairOptions{})
+	for i := range 100 {
+		q.Push(context.Background(), Entry[job]{Value: job(fmt.Sprintf("noisy/%d", i))})
+	}
+
+	// A quiet tenant arriving late waits for the noisy task being served,
+	// not for the other ninety-nine
+	first, _ := q.Pop(context.Background())
+	q.Push(context.Background(), Entry[job]{Value: "quiet/1"})
+	second, _ := q.Pop(context.Background())
+	if first.Value != "noisy/0" || second.Value != "quiet/1" {
+		t.Errorf("got %v then %v, want noisy/0 then quiet/1", first.Value, second.Value)
+	}
+}
+
+func TestFairQueueCapacity(t *testing.T) {
+	q := NewFairQueue(classifyJob, FairOptions{Capacity: 1})
+	if err := q.TryPush(Entry[job]{Value: "a/1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.TryPush(Entry[job]{Value: "a/2"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("TryPush when full: got %v, want %v", err, ErrQueueFull)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Push(ctx, Entry[job]{Value: "a/2"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Push when full: got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// A blocked Push goes through once Pop makes room
+	pushed := make(chan error)
+	go func() { pushed <- q.Push(context.Background(), Entry[job]{Value: "a/3"}) }()
+	if e, _ := q.Pop(context.Background()); e.Value != "a/1" {
+		t.Fatalf("got %v, want a/1", e.Value)
+	}
+	if err := <-pushed; err != nil {
+		t.Fatal(err)
+	}
+	if q.Len() != 1 {
+		t.Errorf("got length %d, want 1", q.Len())
+	}
+
+	// Pop gives up with its context, and fails once closed and drained
+	q.Pop(context.Background())
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := q.Pop(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Pop on empty queue: got %v, want %v", err, context.Canceled)
+	}
+	q.Close()
+	if _, err := q.Pop(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("Pop after Close: got %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestPoolWithFairQueue(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var order []job
+	fn := func(ctx context.Context, j job) (job, error) {
+		if j == "gate/0" {
+			close(started)
+			<-release
+		}
+		order = append(order, j) // One worker, so no data race
+		return j, nil
+	}
+
+	q := NewFairQueue(classifyJob, FairOptions{Capacity: 10})
+	p := NewWithQueue(context.Background(), fn, q, Options{Workers: 1})
+	p.Submit(context.Background(), "gate/0")
+	<-started // The worker is busy while the others queue up
+	for _, j := range []job{"a/1", "a/2", "a/3", "b/1", "c/1:9"} {
+		if err := p.Submit(context.Background(), j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(release)
+	p.Close()
+	collect(p)
+
+	want := []job{"gate/0", "c/1:9", "a/1", "b/1", "a/2", "a/3"}
+	if !slices.Equal(order, want) {
+		t.Errorf("got %v, want %v", order, want)
+	}
+}
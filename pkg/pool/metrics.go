@@ -0,0 +1,153 @@
+package pool
+
+import (
+	"cmp"
+	"io"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go-by-example/pkg/metrics"
+)
+
+const (
+	throughputSlot  = time.Second // Resolution of Metrics.Series
+	throughputSlots = 60          // Length of Metrics.Series
+)
+
+// Metrics describes the tasks a pool ran since it started or since the last
+// ResetMetrics. Skipped tasks are not included.
+type Metrics struct {
+	Since      time.Time        `json:"since"`
+	Time       time.Time        `json:"time"`
+	Completed  uint64           `json:"completed"`
+	Failed     uint64           `json:"failed"`
+	Throughput float64          `json:"throughput"` // Tasks per second over the last minute
+	Series     []metrics.Point  `json:"series"`     // Tasks finished in each second of the last minute
+	Wait       metrics.Snapshot `json:"wait"`       // Time tasks spent in the queue
+	Run        metrics.Snapshot `json:"run"`        // Time tasks spent in the Func
+	Workers    []WorkerMetrics  `json:"workers"`    // By worker ID
+}
+
+// WorkerMetrics is the share of one worker in Metrics
+type WorkerMetrics struct {
+	ID        int              `json:"id"`
+	Running   bool             `json:"running"` // False once the worker has exited
+	Completed uint64           `json:"completed"`
+	Failed    uint64           `json:"failed"`
+	Wait      metrics.Snapshot `json:"wait"`
+	Run       metrics.Snapshot `json:"run"`
+}
+
+// workerMetrics is written only by its worker, without locks
+type workerMetrics struct {
+	completed atomic.Uint64
+	failed    atomic.Uint64
+	wait      metrics.Histogram
+	run       metrics.Histogram
+	exited    atomic.Bool
+}
+
+func (w *workerMetrics) record(waited, elapsed time.Duration, err error) {
+	w.wait.Record(waited)
+	w.run.Record(elapsed)
+	if err != nil {
+		w.failed.Add(1)
+	} else {
+		w.completed.Add(1)
+	}
+}
+
+// Metrics returns latency percentiles and throughput so far
+func (p *Pool[In, Out]) Metrics() Metrics {
+	return p.metrics(false)
+}
+
+// ResetMetrics returns the same as Metrics and starts a new interval, so
+// that calling it periodically reports every task exactly once. Workers
+// that have exited are dropped after being reported. Stats is not reset.
+func (p *Pool[In, Out]) ResetMetrics() Metrics {
+	return p.metrics(true)
+}
+
+func (p *Pool[In, Out]) metrics(reset bool) Metrics {
+	p.scaleMu.Lock()
+	defer p.scaleMu.Unlock()
+
+	m := Metrics{
+		Since:      p.since,
+		Time:       p.clk.Now(),
+		Throughput: p.meter.Rate(),
+		Series:     p.meter.Series(),
+		Workers:    make([]WorkerMetrics, 0, len(p.perWorker)),
+	}
+	for id, w := range p.perWorker {
+		wm := WorkerMetrics{ID: id, Running: !w.exited.Load()}
+		if reset {
+			wm.Completed = w.completed.Swap(0)
+			wm.Failed = w.failed.Swap(0)
+			wm.Wait = w.wait.Reset()
+			wm.Run = w.run.Reset()
+			if !wm.Running {
+				delete(p.perWorker, id)
+			}
+		} else {
+			wm.Completed = w.completed.Load()
+			wm.Failed = w.failed.Load()
+			wm.Wait = w.wait.Snapshot()
+			wm.Run = w.run.Snapshot()
+		}
+		m.Completed += wm.Completed
+		m.Failed += wm.Failed
+		m.Wait = m.Wait.Merge(wm.Wait)
+		m.Run = m.Run.Merge(wm.Run)
+		m.Workers = append(m.Workers, wm)
+	}
+	slices.SortFunc(m.Workers, func(a, b WorkerMetrics) int { return cmp.Compare(a.ID, b.ID) })
+
+	if reset {
+		p.since = m.Time
+		p.meter.Reset()
+	}
+	return m
+}
+
+// WritePrometheus writes m in the Prometheus text format, with metric names
+// starting with namespace. Counts and sums only grow if the pool's metrics
+// are never reset, which is what Prometheus expects.
+func (m Metrics) WritePrometheus(w io.Writer, namespace string) error {
+	e := metrics.NewExposition(w)
+	result := func(r string) metrics.Label { return metrics.Label{Name: "result", Value: r} }
+
+	e.Family(namespace+"_tasks_total", "counter", "Tasks run by the pool.")
+	e.Sample(namespace+"_tasks_total", float64(m.Completed), result("completed"))
+	e.Sample(namespace+"_tasks_total", float64(m.Failed), result("failed"))
+
+	e.Family(namespace+"_throughput", "gauge", "Tasks per second over the last minute.")
+	e.Sample(namespace+"_throughput", m.Throughput)
+
+	e.Family(namespace+"_queue_wait_seconds", "summary", "Time tasks spent in the queue.")
+	e.Summary(namespace+"_queue_wait_seconds", m.Wait)
+
+	e.Family(namespace+"_task_duration_seconds", "summary", "Time tasks spent running.")
+	e.Summary(namespace+"_task_duration_seconds", m.Run)
+
+	e.Family(namespace+"_worker_tasks_total", "counter", "Tasks run by each worker.")
+	for _, wm := range m.Workers {
+		worker := metrics.Label{Name: "worker", Value: strconv.Itoa(wm.ID)}
+		e.Sample(namespace+"_worker_tasks_total", float64(wm.Completed), worker, result("completed"))
+		e.Sample(namespace+"_worker_tasks_total", float64(wm.Failed), worker, result("failed"))
+	}
+
+	e.Family(namespace+"_worker_queue_wait_seconds", "summary", "Time tasks spent in the queue, by the worker that took them.")
+	for _, wm := range m.Workers {
+		e.Summary(namespace+"_worker_queue_wait_seconds", wm.Wait, metrics.Label{Name: "worker", Value: strconv.Itoa(wm.ID)})
+	}
+
+	e.Family(namespace+"_worker_task_duration_seconds", "summary", "Time tasks spent running, by worker.")
+	for _, wm := range m.Workers {
+		e.Summary(namespace+"_worker_task_duration_seconds", wm.Run, metrics.Label{Name: "worker", Value: strconv.Itoa(wm.ID)})
+	}
+	return e.Err()
+}
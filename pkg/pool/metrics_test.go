@@ -0,0 +1,127 @@
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"go-by-example/pkg/clock"
+)
+
+func TestMetrics(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	// Task n takes n*10ms; odd tasks fail
+	p := New(context.Background(), func(ctx context.Context, n int) (int, error) {
+		<-clk.After(time.Duration(n) * 10 * time.Millisecond)
+		return double(ctx, n)
+	}, Options{Workers: 1, QueueSize: 3, Clock: clk})
+
+	for n := 1; n <= 3; n++ {
+		if err := p.Submit(context.Background(), n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for n := 1; n <= 3; n++ {
+		clk.BlockUntil(1)
+		clk.Advance(time.Duration(n) * 10 * time.Millisecond)
+		<-p.Results()
+	}
+
+	m := p.Metrics()
+	if m.Completed != 1 || m.Failed != 2 {
+		t.Errorf("got %d completed and %d failed, want 1 and 2", m.Completed, m.Failed)
+	}
+	// The single worker runs the tasks back to back, so they wait 0, 10 and 30ms
+	if m.Wait.Count != 3 || m.Wait.Min != 0 || m.Wait.Max != 30*time.Millisecond {
+		t.Errorf("got queue wait %+v, want 3 tasks from 0 to 30ms", m.Wait)
+	}
+	if m.Run.Min != 10*time.Millisecond || m.Run.Max != 30*time.Millisecond || m.Run.Sum != 60*time.Millisecond {
+		t.Errorf("got run time %+v, want 10ms to 30ms summing to 60ms", m.Run)
+	}
+	if p50 := m.Run.Quantile(0.5); p50 < 20*time.Millisecond || p50 > 21*time.Millisecond {
+		t.Errorf("got p50 run time %v, want about 20ms", p50)
+	}
+	if m.Throughput != 50 {
+		t.Errorf("got throughput %v, want 3 tasks in 60ms = 50/s", m.Throughput)
+	}
+	if len(m.Workers) != 1 || m.Workers[0].ID != 1 || !m.Workers[0].Running || m.Workers[0].Run.Count != 3 {
+		t.Errorf("got workers %+v, want worker 1 running with 3 tasks", m.Workers)
+	}
+
+	// ResetMetrics reports the same interval and starts a new one
+	clk.Advance(40 * time.Millisecond)
+	if got := p.ResetMetrics(); got.Run.Count != 3 || !got.Since.Equal(epoch) {
+		t.Errorf("got %d tasks since %v from ResetMetrics, want 3 since %v", got.Run.Count, got.Since, epoch)
+	}
+	m = p.Metrics()
+	if m.Completed != 0 || m.Run.Count != 0 || !m.Since.Equal(epoch.Add(100*time.Millisecond)) {
+		t.Errorf("got %+v after reset, want an empty interval from 100ms", m)
+	}
+	if got := p.Stats().Completed; got != 1 {
+		t.Errorf("got %d completed in Stats after reset, want 1", got)
+	}
+
+	// Workers that have exited are reported once more, then dropped
+	p.Close()
+	p.Wait()
+	if got := p.ResetMetrics().Workers; len(got) != 1 || got[0].Running {
+		t.Errorf("got workers %+v after Close, want worker 1 stopped", got)
+	}
+	if got := p.Metrics().Workers; len(got) != 0 {
+		t.Errorf("got workers %+v after the second reset, want none", got)
+	}
+}
+
+func TestMetricsExport(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	p := New(context.Background(), func(ctx context.Context, n int) (int, error) {
+		<-clk.After(100 * time.Millisecond)
+		return n, nil
+	}, Options{Workers: 2, QueueSize: 2, Clock: clk})
+	p.Submit(context.Background(), 1)
+	p.Submit(context.Background(), 2)
+	clk.BlockUntil(2)
+	clk.Advance(100 * time.Millisecond)
+	p.Close()
+	collect(p)
+	m := p.Metrics()
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Completed uint64
+		Run       struct{ P99, Max float64 }
+		Workers   []struct {
+			ID  int
+			Run struct{ Count int }
+		}
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Completed != 2 || decoded.Run.Max != 0.1 || decoded.Run.P99 != 0.1 || len(decoded.Workers) != 2 {
+		t.Errorf("got JSON %s, want 2 tasks of 0.1s on 2 workers", data)
+	}
+
+	var b strings.Builder
+	if err := m.WritePrometheus(&b, "pool"); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"# TYPE pool_tasks_total counter",
+		`pool_tasks_total{result="completed"} 2`,
+		`pool_task_duration_seconds{quantile="0.99"} 0.1`,
+		"pool_task_duration_seconds_count 2",
+		`pool_worker_task_duration_seconds_count{worker="1"} 1`,
+		`pool_worker_task_duration_seconds_count{worker="2"} 1`,
+		`pool_worker_tasks_total{worker="2",result="failed"} 0`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, b.String())
+		}
+	}
+}
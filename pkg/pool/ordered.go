@@ -0,0 +1,87 @@
+package pool
+
+import "context"
+
+// sequenced is a Result on its way to the reorder buffer of an ordered pool
+type sequenced[In, Out any] struct {
+	seq    uint64
+	result Result[In, Out]
+	skip   bool // The task never made it into the queue, so has no result
+}
+
+// acquire takes a place in the order window for a task, blocking while the
+// window is full, and returns the task's sequence number. It returns 0 for
+// a pool that isn't ordered.
+func (p *Pool[In, Out]) acquire(ctx context.Context) (uint64, error) {
+	if p.slots == nil {
+		return 0, nil
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return p.seq.Add(1), nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// tryAcquire is like acquire, but returns ErrQueueFull instead of blocking
+func (p *Pool[In, Out]) tryAcquire() (uint64, error) {
+	if p.slots == nil {
+		return 0, nil
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return p.seq.Add(1), nil
+	default:
+		return 0, ErrQueueFull
+	}
+}
+
+// skip gives up the sequence number of a task that couldn't be queued, so
+// the reorder buffer doesn't wait for it
+func (p *Pool[In, Out]) skip(seq uint64) {
+	if seq != 0 {
+		p.ordered <- sequenced[In, Out]{seq: seq, skip: true}
+	}
+}
+
+// send delivers the result of the task with sequence number seq
+func (p *Pool[In, Out]) send(seq uint64, r Result[In, Out]) {
+	if p.ordered == nil {
+		p.results <- r
+		return
+	}
+	p.ordered <- sequenced[In, Out]{seq: seq, result: r}
+}
+
+// reorder holds back results that finish early until the results of every
+// task submitted before them have been delivered. The order window bounds
+// how many it holds: when a slow task holds up the others, Submit blocks
+// rather than letting the buffer grow.
+func (p *Pool[In, Out]) reorder() {
+	defer close(p.results)
+	held := make(map[uint64]sequenced[In, Out])
+	next := uint64(1)
+	for s := range p.ordered {
+		if s.seq == 0 {
+			// From a queue that made its own entries rather than from Submit,
+			// so it has no place in the order
+			p.results <- s.result
+			continue
+		}
+
+		held[s.seq] = s
+		for {
+			h, ok := held[next]
+			if !ok {
+				break
+			}
+			delete(held, next)
+			next++
+			if !h.skip {
+				p.results <- h.result
+			}
+			<-p.slots
+		}
+	}
+}
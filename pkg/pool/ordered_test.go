@@ -0,0 +1,163 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// gated returns a Func that holds back the inputs that have a gate until
+// the gate is closed
+func gated(gates map[int]chan struct{}) Func[int, int] {
+	return func(ctx context.Context, n int) (int, error) {
+		if gate, ok := gates[n]; ok {
+			<-gate
+		}
+		return n, nil
+	}
+}
+
+func TestOrderedResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		queue   int
+	}{
+		{name: "one worker", workers: 1, queue: 0},
+		{name: "more workers than tasks", workers: 30, queue: 0},
+		{name: "buffered queue", workers: 4, queue: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(context.Background(), func(ctx context.Context, n int) (int, error) {
+				return n * n, nil
+			}, Options{Workers: tt.workers, QueueSize: tt.queue, Ordered: true})
+			go func() {
+				for i := 1; i <= 20; i++ {
+					p.Submit(context.Background(), i)
+				}
+				p.Close()
+			}()
+
+			var got []int
+			for r := range p.Results() {
+				got = append(got, r.Input)
+			}
+			want := make([]int, 20)
+			for i := range want {
+				want[i] = i + 1
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestOrderWindow(t *testing.T) {
+	slow := make(chan struct{})
+	p := New(context.Background(), gated(map[int]chan struct{}{1: slow}), Options{
+		Workers:     3,
+		QueueSize:   10,
+		Ordered:     true,
+		OrderWindow: 3,
+	})
+
+	// Task 1 holds up the head, so the window fills with tasks 2 and 3
+	// even though they are done
+	for i := 1; i <= 3; i++ {
+		if err := p.TrySubmit(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.TrySubmit(4); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("TrySubmit with a full window: got %v, want %v", err, ErrQueueFull)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Submit(ctx, 4); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Submit with a full window: got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// Delivering task 1 makes room for one more
+	close(slow)
+	if r := <-p.Results(); r.Input != 1 {
+		t.Fatalf("got task %d first, want 1", r.Input)
+	}
+	if err := p.Submit(context.Background(), 4); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	var got []int
+	for r := range p.Results() {
+		got = append(got, r.Input)
+	}
+	if want := []int{2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestOrderedSkipsFailedSubmit(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	p := New(context.Background(), func(ctx context.Context, n int) (int, error) {
+		if n == 1 {
+			close(started)
+			<-release
+		}
+		return n, nil
+	}, Options{Workers: 1, Ordered: true, OrderWindow: 5})
+
+	p.Submit(context.Background(), 1)
+	<-started
+
+	// No queue and a busy worker, so task 2 never gets in
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Submit(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+	go func() {
+		p.Submit(context.Background(), 3)
+		p.Close()
+	}()
+	close(release)
+
+	var got []int
+	for r := range p.Results() {
+		got = append(got, r.Input)
+	}
+	if want := []int{1, 3}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestAll(t *testing.T) {
+	p := New(context.Background(), double, Options{Workers: 2, QueueSize: 10, Ordered: true})
+	for i := 2; i <= 20; i += 2 {
+		p.Submit(context.Background(), i)
+	}
+	p.Close()
+
+	var got []int
+	for r := range p.All() {
+		got = append(got, r.Value)
+		if len(got) == 3 {
+			break
+		}
+	}
+	if want := []int{4, 8, 12}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Breaking out early doesn't leave the workers blocked
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if s := p.Stats(); s.Completed != 10 {
+		t.Errorf("got %d completed, want 10", s.Completed)
+	}
+}
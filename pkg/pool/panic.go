@@ -0,0 +1,37 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the error of a task whose Func panicked. The worker
+// recovers, so the panic costs the task but not the pool.
+type PanicError struct {
+	Value any    // What was passed to panic
+	Stack []byte // Stack trace of the worker at the panic
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("pool: task panicked: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error, so errors.Is and
+// errors.As see through a panic(err)
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// call runs the Func once, turning a panic into a *PanicError
+func (p *Pool[In, Out]) call(ctx context.Context, in In) (out Out, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.panics.Add(1)
+			var zero Out
+			out, err = zero, &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return p.fn(ctx, in)
+}
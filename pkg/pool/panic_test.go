@@ -0,0 +1,84 @@
+package pool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+var errBoom = errors.New("boom")
+
+// explode panics for inputs divisible by three, with an error for multiples
+// of six
+func explode(ctx context.Context, n int) (int, error) {
+	switch {
+	case n%6 == 0:
+		panic(errBoom)
+	case n%3 == 0:
+		panic(n)
+	}
+	return n, nil
+}
+
+func TestPanicIsolation(t *testing.T) {
+	p := New(context.Background(), explode, Options{Workers: 1, QueueSize: 10})
+	for i := 1; i <= 6; i++ {
+		p.Submit(context.Background(), i)
+	}
+	p.Close()
+
+	results := collect(p)
+	if len(results) != 6 {
+		t.Fatalf("got %d results, want 6", len(results))
+	}
+	for _, r := range results {
+		var pe *PanicError
+		switch {
+		case r.Input%3 != 0:
+			if r.Err != nil || r.Value != r.Input {
+				t.Errorf("task %d: got %d, %v, want %d", r.Input, r.Value, r.Err, r.Input)
+			}
+		case !errors.As(r.Err, &pe):
+			t.Errorf("task %d: got %v, want a *PanicError", r.Input, r.Err)
+		case !bytes.Contains(pe.Stack, []byte("pool.explode")):
+			t.Errorf("task %d: stack doesn't show the panicking function:\n%s", r.Input, pe.Stack)
+		case r.Input == 3 && pe.Value != 3:
+			t.Errorf("task 3: got panic value %v, want 3", pe.Value)
+		case r.Input == 6 && !errors.Is(r.Err, errBoom):
+			t.Errorf("task 6: got %v, want it to wrap %v", r.Err, errBoom)
+		}
+	}
+
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	s := p.Stats()
+	if s.Panics != 2 || s.Failed != 2 || s.Completed != 4 {
+		t.Errorf("got %+v, want 2 panics, 2 failed and 4 completed", s)
+	}
+}
+
+func TestPanicRetried(t *testing.T) {
+	calls := 0
+	fn := func(ctx context.Context, n int) (int, error) {
+		if calls++; calls == 1 {
+			panic("first call")
+		}
+		return n, nil
+	}
+	p := New(context.Background(), fn, Options{Workers: 1, QueueSize: 1, Retry: &RetryPolicy{MaxAttempts: 2}})
+	p.Submit(context.Background(), 7)
+	p.Close()
+
+	r := <-p.Results()
+	if r.Err != nil || r.Value != 7 || len(r.Attempts) != 2 {
+		t.Fatalf("got %d, %v after %d attempts, want 7 after 2", r.Value, r.Err, len(r.Attempts))
+	}
+	if _, ok := r.Attempts[0].Err.(*PanicError); !ok {
+		t.Errorf("got first attempt error %v, want a *PanicError", r.Attempts[0].Err)
+	}
+	if s := p.Stats(); s.Panics != 1 || s.Retried != 1 {
+		t.Errorf("got %d panics and %d retries, want 1 and 1", s.Panics, s.Retried)
+	}
+}
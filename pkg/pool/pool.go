@@ -0,0 +1,468 @@
+// Package pool runs tasks on worker goroutines fed from a bounded queue.
+//
+// A Pool is built around a single function, func(ctx, In) (Out, error).
+// Every task handed to Submit produces exactly one Result on the Results
+// channel, carrying the function's output or error. Cancelling the pool's
+// context stops the workers mid-queue: tasks still waiting are reported with
+// the context's error instead of being run.
+//
+// The number of workers is fixed unless Options.Autoscale is set, in which
+// case it follows the load between a minimum and a maximum.
+//
+// Workers take tasks from a Queue: a FIFO by default, or any other given to
+// NewWithQueue, such as a FairQueue that orders them by priority and shares
+// the workers between tenants.
+//
+// A task whose Func panics fails with a *PanicError holding the panic value
+// and stack trace; the worker recovers and goes on with the next task.
+//
+// With a RetryPolicy, in Options.Retry or on the task itself, failed tasks
+// are run again after a backoff. Tasks that fail for good are kept in the
+// pool's DeadLetters with their attempt history.
+//
+// Results arrive as tasks finish. With Options.Ordered they arrive in the
+// order the tasks were submitted instead, held back in a buffer bounded by
+// Options.OrderWindow. All ranges over the results as an iterator.
+//
+// A Graph runs tasks that depend on each other's results: each task is
+// submitted to a pool once its dependencies have succeeded, and the report
+// shows the timings and the critical path.
+//
+// Stats counts tasks over the pool's lifetime. Metrics adds latency
+// percentiles for queue wait and run time, throughput and a per-worker
+// breakdown, and can be reset to report one interval at a time.
+//
+//	p := pool.New(ctx, resize, pool.Options{Workers: 4, QueueSize: 16})
+//	go func() {
+//		for _, img := range images {
+//			p.Submit(ctx, img)
+//		}
+//		p.Close()
+//	}()
+//	for res := range p.Results() {
+//		...
+//	}
+//	err := p.Wait()
+package pool
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/metrics"
+)
+
+var (
+	// ErrClosed is returned by Submit after Close
+	ErrClosed = errors.New("pool: closed")
+	// ErrQueueFull is returned by TrySubmit when no queue slot is free
+	ErrQueueFull = errors.New("pool: queue full")
+)
+
+// Func processes one task. ctx is the pool's context, so long-running tasks
+// should watch it to stop early when the pool is cancelled.
+type Func[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+// Options configures a Pool
+type Options struct {
+	Workers   int         // Goroutines running tasks, at least 1; the minimum when autoscaling
+	QueueSize int         // Tasks that wait for a worker before Submit blocks
+	Clock     clock.Clock // Measures task durations, clock.New() if nil
+	Autoscale *Autoscale  // Grow and shrink the workers with the load; nil for a fixed pool
+
+	Retry           *RetryPolicy // Retries for tasks without a Policy of their own; nil never retries
+	DeadLetterLimit int          // Dead letters kept, dropping the oldest; 0 keeps them all
+
+	Ordered     bool // Deliver results in the order the tasks were submitted
+	OrderWindow int  // Tasks submitted but not yet delivered before Submit blocks, when Ordered; default Workers+QueueSize
+}
+
+// Result is the outcome of one task
+type Result[In, Out any] struct {
+	Input    In
+	Value    Out
+	Err      error         // Error from the Func, or the context error for skipped tasks
+	Worker   int           // Worker that ran the task, from 1; 0 if it was skipped
+	Waited   time.Duration // Time spent in the queue
+	Duration time.Duration // Time spent in the Func, over all attempts
+	Attempts []Attempt     // Every run of the Func, if a retry policy applied
+}
+
+// Stats counts tasks over the lifetime of a Pool
+type Stats struct {
+	Submitted uint64        // Tasks accepted by Submit
+	Completed uint64        // Tasks whose Func returned nil
+	Failed    uint64        // Tasks whose Func returned an error
+	Canceled  uint64        // Tasks skipped because the context was done
+	Retried   uint64        // Attempts after the first
+	Dead      uint64        // Tasks given up on under a retry policy
+	Panics    uint64        // Calls of the Func that panicked, reported as a *PanicError
+	Busy      time.Duration // Total time spent in the Func across workers
+	Workers   int           // Workers running now
+	Peak      int           // Most workers running at once
+}
+
+// Pool runs a Func on a set of workers
+type Pool[In, Out any] struct {
+	ctx     context.Context
+	fn      Func[In, Out]
+	clk     clock.Clock
+	queue   Queue[In]
+	results chan Result[In, Out]
+	stopped chan struct{} // Closed once every worker has exited
+
+	ordered chan sequenced[In, Out] // Results on their way to the reorder buffer; nil unless Ordered
+	slots   chan struct{}           // Holds a token for every task in the order window
+	seq     atomic.Uint64
+
+	retry   *RetryPolicy
+	dead    *DeadLetters[In]
+	scale   *Autoscale // nil for a fixed pool
+	window  waitWindow
+	scaleMu sync.Mutex
+	min     int // Workers never retired
+	live    int // Workers running
+	peak    int
+	nextID  int
+
+	since     time.Time // Start of the current Metrics interval
+	meter     *metrics.Meter
+	perWorker map[int]*workerMetrics
+
+	mu           sync.Mutex
+	closed       bool
+	submitting   sync.WaitGroup // Submit calls that passed the closed check
+	stopCancel   func() bool    // Unregisters the close-on-cancel hook
+	workers      sync.WaitGroup
+	submitted    atomic.Uint64
+	completed    atomic.Uint64
+	failed       atomic.Uint64
+	canceled     atomic.Uint64
+	retried      atomic.Uint64
+	deadLettered atomic.Uint64
+	panics       atomic.Uint64
+	busy         atomic.Int64
+}
+
+// New starts opts.Workers workers running fn, fed from a FIFO queue of
+// opts.QueueSize tasks. The pool is closed automatically when ctx is done.
+func New[In, Out any](ctx context.Context, fn Func[In, Out], opts Options) *Pool[In, Out] {
+	return NewWithQueue(ctx, fn, NewFIFO[In](opts.QueueSize), opts)
+}
+
+// NewWithQueue is like New, but the workers take their tasks from queue
+// instead of a FIFO, and opts.QueueSize is ignored. The pool owns the
+// queue from then on and closes it when the pool is closed.
+func NewWithQueue[In, Out any](ctx context.Context, fn Func[In, Out], queue Queue[In], opts Options) *Pool[In, Out] {
+	workers := max(opts.Workers, 1)
+	clk := opts.Clock
+	if clk == nil {
+		clk = clock.New()
+	}
+
+	p := &Pool[In, Out]{
+		ctx:     ctx,
+		fn:      fn,
+		clk:     clk,
+		queue:   queue,
+		results: make(chan Result[In, Out], workers),
+		stopped: make(chan struct{}),
+		min:     workers,
+		retry:   opts.Retry,
+		dead:    newDeadLetters[In](opts.DeadLetterLimit),
+
+		since:     clk.Now(),
+		meter:     metrics.NewMeter(clk, throughputSlot, throughputSlots),
+		perWorker: make(map[int]*workerMetrics),
+	}
+	if opts.Ordered {
+		window := opts.OrderWindow
+		if window <= 0 {
+			window = workers + max(opts.QueueSize, 0)
+		}
+		p.ordered = make(chan sequenced[In, Out], workers)
+		p.slots = make(chan struct{}, window)
+		go p.reorder()
+	}
+	if opts.Autoscale != nil && opts.Autoscale.Max > workers {
+		scale := opts.Autoscale.withDefaults()
+		p.scale = &scale
+		p.window.span = scale.Window
+	}
+	p.stopCancel = context.AfterFunc(ctx, p.Close)
+
+	p.scaleMu.Lock()
+	for range workers {
+		p.startWorker()
+	}
+	p.scaleMu.Unlock()
+
+	go func() {
+		p.workers.Wait()
+		if p.ordered != nil {
+			close(p.ordered) // The reorder buffer closes results once it is empty
+		} else {
+			close(p.results)
+		}
+		close(p.stopped)
+	}()
+	if p.scale != nil {
+		go p.autoscale()
+	}
+	return p
+}
+
+// Submit queues a task, blocking while the queue is full, or in an ordered
+// pool while the order window is. It fails with ErrClosed once the pool is
+// closed, or with the error of ctx or the pool's context if either is done
+// before the task fits into the queue.
+func (p *Pool[In, Out]) Submit(ctx context.Context, in In) error {
+	if err := p.begin(); err != nil {
+		return err
+	}
+	defer p.submitting.Done()
+
+	// Stop waiting for room when either context is done
+	pushCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(p.ctx, cancel)
+	defer stop()
+
+	seq, err := p.acquire(pushCtx)
+	if err == nil {
+		err = p.queue.Push(pushCtx, Entry[In]{Value: in, Enqueued: p.clk.Now(), seq: seq})
+		if err != nil {
+			p.skip(seq)
+		}
+	}
+	if err != nil {
+		if ctx.Err() == nil && p.ctx.Err() != nil {
+			return p.ctx.Err()
+		}
+		return err
+	}
+	p.submitted.Add(1)
+	return nil
+}
+
+// TrySubmit queues a task if there is room, in the queue and in the order
+// window of an ordered pool, and returns ErrQueueFull otherwise, without
+// blocking
+func (p *Pool[In, Out]) TrySubmit(in In) error {
+	if err := p.begin(); err != nil {
+		return err
+	}
+	defer p.submitting.Done()
+
+	seq, err := p.tryAcquire()
+	if err != nil {
+		return err
+	}
+	if err := p.queue.TryPush(Entry[In]{Value: in, Enqueued: p.clk.Now(), seq: seq}); err != nil {
+		p.skip(seq)
+		return err
+	}
+	p.submitted.Add(1)
+	return nil
+}
+
+// begin registers a Submit call unless the pool is closed
+func (p *Pool[In, Out]) begin() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.ctx.Err(); err != nil {
+		return err
+	}
+	if p.closed {
+		return ErrClosed
+	}
+	p.submitting.Add(1)
+	return nil
+}
+
+// Results returns the channel every task's Result is sent on, as tasks
+// finish or, in an ordered pool, in the order they were submitted. It is
+// closed once the pool is closed and the workers have finished. Results
+// must be drained, or the workers block once its small buffer is full.
+func (p *Pool[In, Out]) Results() <-chan Result[In, Out] {
+	return p.results
+}
+
+// All returns an iterator over the results, for use with range as an
+// alternative to Results. Breaking out of the loop early discards the
+// remaining results, so the workers don't block on them.
+func (p *Pool[In, Out]) All() iter.Seq[Result[In, Out]] {
+	return func(yield func(Result[In, Out]) bool) {
+		for r := range p.results {
+			if !yield(r) {
+				go func() {
+					for range p.results {
+					}
+				}()
+				return
+			}
+		}
+	}
+}
+
+// Close stops the pool from accepting tasks. Tasks already queued still run
+// unless the context is cancelled. Close may be called more than once.
+func (p *Pool[In, Out]) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	p.stopCancel()
+
+	// Submit calls already past the closed check may still be sending
+	go func() {
+		p.submitting.Wait()
+		p.queue.Close()
+	}()
+}
+
+// Wait blocks until the pool is closed and every worker has finished. It
+// returns the context's error if cancellation left tasks unrun, and nil
+// otherwise; errors of individual tasks are only reported in their Result.
+func (p *Pool[In, Out]) Wait() error {
+	p.workers.Wait()
+	if p.canceled.Load() > 0 {
+		return p.ctx.Err()
+	}
+	return nil
+}
+
+// Stats returns the task counters so far and the current worker count
+func (p *Pool[In, Out]) Stats() Stats {
+	p.scaleMu.Lock()
+	defer p.scaleMu.Unlock()
+	return Stats{
+		Submitted: p.submitted.Load(),
+		Completed: p.completed.Load(),
+		Failed:    p.failed.Load(),
+		Canceled:  p.canceled.Load(),
+		Retried:   p.retried.Load(),
+		Dead:      p.deadLettered.Load(),
+		Panics:    p.panics.Load(),
+		Busy:      time.Duration(p.busy.Load()),
+		Workers:   p.live,
+		Peak:      p.peak,
+	}
+}
+
+// startWorker starts a worker and returns its ID. p.scaleMu must be held.
+func (p *Pool[In, Out]) startWorker() int {
+	p.live++
+	p.peak = max(p.peak, p.live)
+	p.nextID++
+	id := p.nextID
+	wm := &workerMetrics{}
+	p.perWorker[id] = wm
+
+	p.workers.Add(1)
+	go p.worker(id, wm)
+	return id
+}
+
+func (p *Pool[In, Out]) worker(id int, wm *workerMetrics) {
+	defer p.workers.Done()
+	defer wm.exited.Store(true)
+
+	for {
+		e, ok := p.next(id)
+		if !ok {
+			return
+		}
+		p.run(id, wm, e)
+	}
+}
+
+// next waits for the worker's next task. It returns false when the worker
+// should exit, because the queue is closed and empty or, when autoscaling,
+// because the worker was idle for too long.
+func (p *Pool[In, Out]) next(id int) (Entry[In], bool) {
+	for {
+		ctx, stop := p.idleContext()
+		e, err := p.queue.Pop(ctx)
+		idle := ctx.Err() != nil
+		stop()
+
+		switch {
+		case err == nil:
+			return e, true
+		case idle && !errors.Is(err, ErrClosed):
+			if p.retire(id, p.scale.IdleTimeout) {
+				return e, false
+			}
+		default:
+			// The queue is closed and empty, or broken
+			p.scaleMu.Lock()
+			p.live--
+			p.scaleMu.Unlock()
+			return e, false
+		}
+	}
+}
+
+// idleContext returns the context for a worker waiting on the queue. When
+// autoscaling, it is cancelled once the worker has waited for the idle
+// timeout, measured on the pool's clock.
+func (p *Pool[In, Out]) idleContext() (context.Context, func()) {
+	if p.scale == nil {
+		return context.Background(), func() {}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	idle := p.clk.NewTimer(p.scale.IdleTimeout)
+	go func() {
+		select {
+		case <-idle.C():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		idle.Stop()
+		cancel()
+	}
+}
+
+// run executes one task and sends its Result
+func (p *Pool[In, Out]) run(id int, wm *workerMetrics, e Entry[In]) {
+	if err := p.ctx.Err(); err != nil {
+		p.canceled.Add(1)
+		p.send(e.seq, Result[In, Out]{Input: e.Value, Err: err})
+		return
+	}
+
+	start := p.clk.Now()
+	waited := start.Sub(e.Enqueued)
+	if p.scale != nil {
+		p.window.add(start, waited)
+	}
+	o := p.attempt(e.Value)
+
+	p.busy.Add(int64(o.busy))
+	wm.record(waited, o.busy, o.err)
+	p.meter.Mark(1)
+	if o.err != nil {
+		p.failed.Add(1)
+	} else {
+		p.completed.Add(1)
+	}
+	p.send(e.seq, Result[In, Out]{
+		Input:    e.Value,
+		Value:    o.out,
+		Err:      o.err,
+		Worker:   id,
+		Waited:   waited,
+		Duration: o.busy,
+		Attempts: o.attempts,
+	})
+}
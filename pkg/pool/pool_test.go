@@ -0,0 +1,226 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go-by-example/pkg/clock"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+var errOdd = errors.New("odd input")
+
+func double(ctx context.Context, n int) (int, error) {
+	if n%2 != 0 {
+		return 0, errOdd
+	}
+	return n * 2, nil
+}
+
+// collect drains the pool's results
+func collect[In, Out any](p *Pool[In, Out]) []Result[In, Out] {
+	var results []Result[In, Out]
+	for r := range p.Results() {
+		results = append(results, r)
+	}
+	return results
+}
+
+func TestPool(t *testing.T) {
+	tests := []struct {
+		name      string
+		workers   int
+		queueSize int
+	}{
+		{name: "single worker unbuffered", workers: 1, queueSize: 0},
+		{name: "several workers", workers: 4, queueSize: 2},
+		{name: "zero workers means one", workers: 0, queueSize: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			p := New(ctx, double, Options{Workers: tt.workers, QueueSize: tt.queueSize})
+			go func() {
+				for i := 1; i <= 10; i++ {
+					if err := p.Submit(ctx, i); err != nil {
+						t.Errorf("Submit(%d): %v", i, err)
+					}
+				}
+				p.Close()
+			}()
+
+			results := collect(p)
+			if err := p.Wait(); err != nil {
+				t.Fatalf("Wait: %v", err)
+			}
+
+			sort.Slice(results, func(i, j int) bool { return results[i].Input < results[j].Input })
+			if len(results) != 10 {
+				t.Fatalf("got %d results, want 10", len(results))
+			}
+			for i, r := range results {
+				n := i + 1
+				switch {
+				case r.Input != n:
+					t.Errorf("got input %d, want %d", r.Input, n)
+				case n%2 == 0 && (r.Err != nil || r.Value != n*2):
+					t.Errorf("task %d: got %d, %v, want %d", n, r.Value, r.Err, n*2)
+				case n%2 != 0 && !errors.Is(r.Err, errOdd):
+					t.Errorf("task %d: got error %v, want %v", n, r.Err, errOdd)
+				case r.Worker < 1 || r.Worker > max(tt.workers, 1):
+					t.Errorf("task %d: got worker %d", n, r.Worker)
+				}
+			}
+
+			want := Stats{Submitted: 10, Completed: 5, Failed: 5}
+			if got := p.Stats(); got.Submitted != want.Submitted || got.Completed != want.Completed || got.Failed != want.Failed || got.Canceled != 0 {
+				t.Errorf("got stats %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestSubmitAfterClose(t *testing.T) {
+	p := New(context.Background(), double, Options{Workers: 1})
+	p.Close()
+	p.Close() // Closing twice is fine
+
+	if err := p.Submit(context.Background(), 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit: got %v, want %v", err, ErrClosed)
+	}
+	if err := p.TrySubmit(1); !errors.Is(err, ErrClosed) {
+		t.Errorf("TrySubmit: got %v, want %v", err, ErrClosed)
+	}
+	if results := collect(p); len(results) != 0 {
+		t.Errorf("got %d results, want none", len(results))
+	}
+}
+
+func TestBoundedQueue(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	block := func(ctx context.Context, n int) (int, error) {
+		started <- struct{}{}
+		<-release
+		return n, nil
+	}
+
+	p := New(context.Background(), block, Options{Workers: 1, QueueSize: 2})
+	if err := p.TrySubmit(1); err != nil {
+		t.Fatal(err)
+	}
+	<-started // The worker holds task 1, leaving the queue empty
+
+	for i := 2; i <= 3; i++ {
+		if err := p.TrySubmit(i); err != nil {
+			t.Fatalf("TrySubmit(%d): %v", i, err)
+		}
+	}
+	if err := p.TrySubmit(4); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("TrySubmit on a full queue: got %v, want %v", err, ErrQueueFull)
+	}
+
+	// A blocked Submit gives up when its own context does
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Submit(ctx, 4); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Submit on a full queue: got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	p.Close()
+	go func() {
+		for range 2 {
+			<-started
+		}
+	}()
+	close(release)
+	if results := collect(p); len(results) != 3 {
+		t.Errorf("got %d results, want 3", len(results))
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	slow := func(ctx context.Context, n int) (int, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+
+	p := New(ctx, slow, Options{Workers: 2, QueueSize: 10})
+	for i := 1; i <= 10; i++ {
+		if err := p.Submit(ctx, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-started
+	<-started
+	cancel()
+
+	results := collect(p)
+	if err := p.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait: got %v, want %v", err, context.Canceled)
+	}
+	if len(results) != 10 {
+		t.Fatalf("got %d results, want one per task", len(results))
+	}
+
+	ran := 0
+	for _, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("task %d: got %v, want %v", r.Input, r.Err, context.Canceled)
+		}
+		if r.Worker != 0 {
+			ran++
+		}
+	}
+	if ran != 2 {
+		t.Errorf("got %d tasks started, want 2", ran)
+	}
+	if got := p.Stats(); got.Canceled != 8 || got.Failed != 2 {
+		t.Errorf("got stats %+v, want 8 canceled and 2 failed", got)
+	}
+
+	if err := p.Submit(context.Background(), 11); !errors.Is(err, context.Canceled) {
+		t.Errorf("Submit after cancel: got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	sleep := func(ctx context.Context, d time.Duration) (time.Duration, error) {
+		clk.Sleep(d)
+		return d, nil
+	}
+
+	p := New(context.Background(), sleep, Options{Workers: 1, Clock: clk})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, d := range []time.Duration{100 * time.Millisecond, 250 * time.Millisecond} {
+			p.Submit(context.Background(), d)
+		}
+		p.Close()
+	}()
+
+	for _, d := range []time.Duration{100 * time.Millisecond, 250 * time.Millisecond} {
+		clk.BlockUntil(1)
+		clk.Advance(d)
+		if r := <-p.Results(); r.Duration != d {
+			t.Errorf("got duration %v, want %v", r.Duration, d)
+		}
+	}
+	wg.Wait()
+
+	if got := p.Stats().Busy; got != 350*time.Millisecond {
+		t.Errorf("got busy time %v, want %v", got, 350*time.Millisecond)
+	}
+}
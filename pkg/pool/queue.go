@@ -0,0 +1,76 @@
+package pool
+
+import (
+	"context"
+	"time"
+)
+
+// Entry is a task waiting in a Queue
+type Entry[In any] struct {
+	Value    In
+	Enqueued time.Time // When Submit handed it to the queue
+
+	seq uint64 // Submission order in an ordered pool, 0 otherwise
+}
+
+// Queue holds submitted tasks until a worker takes them. New uses a FIFO
+// of Options.QueueSize; NewWithQueue takes any other, such as a FairQueue.
+//
+// A Queue must be safe for concurrent use. The pool never calls Push or
+// TryPush after Close. A Queue should hand out the entries it was given
+// rather than copies of their fields, which would lose the place of the
+// task in an ordered pool.
+type Queue[In any] interface {
+	// Push adds an entry, blocking while the queue is full until ctx is done
+	Push(ctx context.Context, e Entry[In]) error
+	// TryPush adds an entry if there is room, or returns ErrQueueFull
+	TryPush(e Entry[In]) error
+	// Pop removes the next entry, blocking until there is one or ctx is
+	// done. It returns ErrClosed once the queue is closed and empty.
+	Pop(ctx context.Context) (Entry[In], error)
+	// Close lets Pop drain the remaining entries and then fail
+	Close()
+	// Len returns the number of entries waiting
+	Len() int
+}
+
+// NewFIFO returns a first-in first-out Queue holding up to size entries.
+// With size 0, Push blocks until a worker is ready to take the entry.
+func NewFIFO[In any](size int) Queue[In] {
+	return fifo[In](make(chan Entry[In], max(size, 0)))
+}
+
+type fifo[In any] chan Entry[In]
+
+func (q fifo[In]) Push(ctx context.Context, e Entry[In]) error {
+	select {
+	case q <- e:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q fifo[In]) TryPush(e Entry[In]) error {
+	select {
+	case q <- e:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (q fifo[In]) Pop(ctx context.Context) (Entry[In], error) {
+	select {
+	case e, ok := <-q:
+		if !ok {
+			return e, ErrClosed
+		}
+		return e, nil
+	case <-ctx.Done():
+		return Entry[In]{}, ctx.Err()
+	}
+}
+
+func (q fifo[In]) Close()   { close(q) }
+func (q fifo[In]) Len() int { return len(q) }
@@ -0,0 +1,273 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"go-by-example/internal/broadcast"
+)
+
+// RetryPolicy decides whether and when a failed task runs again.
+//
+// Retries happen on the worker that ran the task: it waits out the backoff
+// and calls the Func again, so a task in backoff occupies its worker. The
+// delay before retry n (from 1) is Initial * Multiplier^(n-1), capped at Max,
+// and then reduced by a random fraction of up to Jitter so that tasks failing
+// together don't retry in lockstep.
+type RetryPolicy struct {
+	MaxAttempts int           // Runs in total, including the first; 1 or less never retries
+	Initial     time.Duration // Delay before the first retry
+	Max         time.Duration // Upper bound on the delay; 0 means no bound
+	Multiplier  float64       // Growth of the delay per retry, default 2
+	Jitter      float64       // Fraction of the delay to randomize, from 0 to 1
+
+	// Retryable reports whether an error is worth retrying. Nil retries every
+	// error. See RetryIs and RetryAs.
+	Retryable func(error) bool
+}
+
+// Policy lets a task input carry its own RetryPolicy, which overrides
+// Options.Retry. A nil policy never retries.
+type Policy interface {
+	RetryPolicy() *RetryPolicy
+}
+
+// RetryIs returns a Retryable func that accepts errors matching any of
+// targets under errors.Is
+func RetryIs(targets ...error) func(error) bool {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RetryAs returns a Retryable func that accepts errors with an E in their
+// chain, found with errors.As, for which match returns true. A nil match
+// accepts any E.
+func RetryAs[E error](match func(E) bool) func(error) bool {
+	return func(err error) bool {
+		var target E
+		return errors.As(err, &target) && (match == nil || match(target))
+	}
+}
+
+// Backoff returns the delay before retry n, counting from 1
+func (r *RetryPolicy) Backoff(n int) time.Duration {
+	mult := r.Multiplier
+	if mult <= 0 {
+		mult = 2
+	}
+	d := float64(r.Initial)
+	for i := 1; i < n; i++ {
+		d *= mult
+		if r.Max > 0 && d >= float64(r.Max) {
+			break
+		}
+	}
+	if r.Max > 0 {
+		d = min(d, float64(r.Max))
+	}
+	if r.Jitter > 0 {
+		d -= d * min(r.Jitter, 1) * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// retries reports whether attempt n, which failed with err, should be
+// followed by another
+func (r *RetryPolicy) retries(n int, err error) bool {
+	return r != nil && n < r.MaxAttempts && (r.Retryable == nil || r.Retryable(err))
+}
+
+// Attempt is one run of a task's Func
+type Attempt struct {
+	Number   int           // From 1
+	Start    time.Time     // When the Func was called
+	Duration time.Duration // Time spent in the Func
+	Err      error         // What the Func returned
+	Backoff  time.Duration // Wait before the next attempt; 0 for the last
+}
+
+// attemptKey is the context key of the attempt number
+type attemptKey struct{}
+
+// AttemptFromContext returns the number of the attempt a Func is running,
+// from 1, or 0 if ctx doesn't come from a pool
+func AttemptFromContext(ctx context.Context) int {
+	n, _ := ctx.Value(attemptKey{}).(int)
+	return n
+}
+
+// ExhaustedError is the error of a task that failed on every attempt its
+// retry policy allowed, or with an error the policy doesn't retry
+type ExhaustedError struct {
+	Attempts int
+	Err      error // The error of the last attempt
+}
+
+func (e *ExhaustedError) Error() string {
+	return fmt.Sprintf("pool: gave up after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *ExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+// DeadLetter is a task that failed for good under a retry policy
+type DeadLetter[In any] struct {
+	Input    In
+	Err      error // The last error
+	Attempts []Attempt
+	Time     time.Time // When the task was given up on
+}
+
+// DeadLetters stores tasks that failed for good so they can be inspected
+// or resubmitted later. It keeps the most recent Options.DeadLetterLimit
+// entries, counting the ones it had to drop.
+type DeadLetters[In any] struct {
+	mu      sync.Mutex
+	limit   int
+	letters []DeadLetter[In]
+	dropped uint64
+	added   broadcast.Signal
+}
+
+func newDeadLetters[In any](limit int) *DeadLetters[In] {
+	return &DeadLetters[In]{limit: limit}
+}
+
+func (d *DeadLetters[In]) add(letter DeadLetter[In]) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.limit > 0 && len(d.letters) >= d.limit {
+		d.letters = slices.Delete(d.letters, 0, 1)
+		d.dropped++
+	}
+	d.letters = append(d.letters, letter)
+	d.added.Notify()
+}
+
+// List returns the stored dead letters, oldest first
+func (d *DeadLetters[In]) List() []DeadLetter[In] {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.letters)
+}
+
+// Drain removes and returns the stored dead letters, oldest first
+func (d *DeadLetters[In]) Drain() []DeadLetter[In] {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	letters := d.letters
+	d.letters = nil
+	return letters
+}
+
+// Len returns the number of stored dead letters
+func (d *DeadLetters[In]) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.letters)
+}
+
+// Dropped returns how many dead letters were discarded to respect the limit
+func (d *DeadLetters[In]) Dropped() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.dropped
+}
+
+// Added returns a channel that is closed the next time a dead letter is
+// stored, for consumers that want to react instead of polling
+func (d *DeadLetters[In]) Added() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.added.Chan()
+}
+
+// policy returns the retry policy for a task
+func (p *Pool[In, Out]) policy(in In) *RetryPolicy {
+	if pp, ok := any(in).(Policy); ok {
+		return pp.RetryPolicy()
+	}
+	return p.retry
+}
+
+// outcome is what running a task, with its retries, came to
+type outcome[Out any] struct {
+	out      Out
+	err      error
+	busy     time.Duration // Time spent in the Func over all attempts
+	attempts []Attempt     // Only kept under a retry policy
+}
+
+// attempt runs the Func for a task until it succeeds or its retry policy
+// gives up
+func (p *Pool[In, Out]) attempt(in In) outcome[Out] {
+	policy := p.policy(in)
+	var o outcome[Out]
+	for n := 1; ; n++ {
+		start := p.clk.Now()
+		o.out, o.err = p.call(context.WithValue(p.ctx, attemptKey{}, n), in)
+		elapsed := p.clk.Now().Sub(start)
+		o.busy += elapsed
+		if policy == nil {
+			return o
+		}
+
+		o.attempts = append(o.attempts, Attempt{Number: n, Start: start, Duration: elapsed, Err: o.err})
+		if o.err == nil {
+			return o
+		}
+		if !policy.retries(n, o.err) {
+			p.deadLetter(in, o.err, o.attempts)
+			o.err = &ExhaustedError{Attempts: n, Err: o.err}
+			return o
+		}
+
+		backoff := policy.Backoff(n)
+		o.attempts[n-1].Backoff = backoff
+		if !p.backoff(backoff) {
+			// The pool is stopping: report the last error, but the task
+			// didn't use up its retries, so it isn't a dead letter
+			o.attempts[n-1].Backoff = 0
+			return o
+		}
+		p.retried.Add(1)
+	}
+}
+
+// backoff waits for d, or returns false if the pool's context is done first
+func (p *Pool[In, Out]) backoff(d time.Duration) bool {
+	if d <= 0 {
+		return p.ctx.Err() == nil
+	}
+	timer := p.clk.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-p.ctx.Done():
+		return false
+	}
+}
+
+func (p *Pool[In, Out]) deadLetter(in In, err error, attempts []Attempt) {
+	p.deadLettered.Add(1)
+	p.dead.add(DeadLetter[In]{Input: in, Err: err, Attempts: attempts, Time: p.clk.Now()})
+}
+
+// DeadLetters returns the store of tasks that failed for good under a retry
+// policy
+func (p *Pool[In, Out]) DeadLetters() *DeadLetters[In] {
+	return p.dead
+}
@@ -0,0 +1,224 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-by-example/pkg/clock"
+)
+
+var errTemporary = errors.New("temporary failure")
+
+// statusError is an error type for RetryAs
+type statusError struct{ code int }
+
+func (e *statusError) Error() string { return fmt.Sprintf("status %d", e.code) }
+
+func TestBackoff(t *testing.T) {
+	policy := &RetryPolicy{Initial: 100 * time.Millisecond, Max: time.Second}
+
+	tests := []struct {
+		retry    int
+		expected time.Duration
+	}{
+		{retry: 1, expected: 100 * time.Millisecond},
+		{retry: 2, expected: 200 * time.Millisecond},
+		{retry: 3, expected: 400 * time.Millisecond},
+		{retry: 4, expected: 800 * time.Millisecond},
+		{retry: 5, expected: time.Second},
+		{retry: 100, expected: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.retry), func(t *testing.T) {
+			if got := policy.Backoff(tt.retry); got != tt.expected {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	jittered := &RetryPolicy{Initial: 100 * time.Millisecond, Multiplier: 3, Jitter: 0.5}
+	seen := make(map[time.Duration]bool)
+	for range 100 {
+		d := jittered.Backoff(2)
+		if d < 150*time.Millisecond || d > 300*time.Millisecond {
+			t.Fatalf("got jittered backoff %v, want between 150ms and 300ms", d)
+		}
+		seen[d] = true
+	}
+	if len(seen) < 2 {
+		t.Error("jitter produced the same backoff every time")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	// Fails on the first two attempts
+	flaky := func(ctx context.Context, n int) (int, error) {
+		if AttemptFromContext(ctx) < 3 {
+			return 0, errTemporary
+		}
+		return n * 2, nil
+	}
+
+	p := New(context.Background(), flaky, Options{
+		Workers: 1,
+		Clock:   clk,
+		Retry:   &RetryPolicy{MaxAttempts: 5, Initial: 100 * time.Millisecond},
+	})
+	go p.Submit(context.Background(), 21)
+
+	clk.BlockUntil(1)
+	clk.Advance(99 * time.Millisecond)
+	if clk.Waiters() != 1 {
+		t.Fatal("retried before the backoff was over")
+	}
+	clk.Advance(time.Millisecond)
+	clk.BlockUntil(1)
+	clk.Advance(200 * time.Millisecond)
+
+	res := <-p.Results()
+	if res.Err != nil || res.Value != 42 {
+		t.Fatalf("got %d, %v, want 42", res.Value, res.Err)
+	}
+	wantBackoff := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 0}
+	if len(res.Attempts) != 3 {
+		t.Fatalf("got %d attempts, want 3", len(res.Attempts))
+	}
+	for i, a := range res.Attempts {
+		if a.Number != i+1 || a.Backoff != wantBackoff[i] || (i < 2) != (a.Err != nil) {
+			t.Errorf("attempt %d: got %+v, want backoff %v", i+1, a, wantBackoff[i])
+		}
+	}
+	if got := res.Attempts[2].Start; !got.Equal(epoch.Add(300 * time.Millisecond)) {
+		t.Errorf("got third attempt at %v, want 300ms after the first", got.Sub(epoch))
+	}
+
+	p.Close()
+	p.Wait()
+	if got := p.Stats(); got.Completed != 1 || got.Retried != 2 || got.Dead != 0 {
+		t.Errorf("got stats %+v, want 1 completed after 2 retries", got)
+	}
+}
+
+func TestRetryClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable func(error) bool
+		attempts  int
+	}{
+		{name: "any error", err: errOdd, retryable: nil, attempts: 3},
+		{name: "wrapped sentinel", err: fmt.Errorf("fetch: %w", errTemporary), retryable: RetryIs(errTemporary), attempts: 3},
+		{name: "other sentinel", err: errOdd, retryable: RetryIs(errTemporary), attempts: 1},
+		{name: "matching type", err: fmt.Errorf("fetch: %w", &statusError{503}), retryable: RetryAs[*statusError](nil), attempts: 3},
+		{name: "type with filter", err: &statusError{503}, retryable: RetryAs(func(e *statusError) bool { return e.code >= 500 }), attempts: 3},
+		{name: "filtered out", err: &statusError{404}, retryable: RetryAs(func(e *statusError) bool { return e.code >= 500 }), attempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failing := func(ctx context.Context, n int) (int, error) { return 0, tt.err }
+			p := New(context.Background(), failing, Options{
+				Workers: 1,
+				Retry:   &RetryPolicy{MaxAttempts: 3, Retryable: tt.retryable},
+			})
+			p.Submit(context.Background(), 7)
+			p.Close()
+			res := <-p.Results()
+
+			var exhausted *ExhaustedError
+			if !errors.As(res.Err, &exhausted) || exhausted.Attempts != tt.attempts || !errors.Is(res.Err, tt.err) {
+				t.Fatalf("got error %v, want %v after %d attempts", res.Err, tt.err, tt.attempts)
+			}
+			if len(res.Attempts) != tt.attempts {
+				t.Errorf("got %d attempts in the result, want %d", len(res.Attempts), tt.attempts)
+			}
+
+			letters := p.DeadLetters().List()
+			if len(letters) != 1 || letters[0].Input != 7 || letters[0].Err != tt.err || len(letters[0].Attempts) != tt.attempts {
+				t.Errorf("got dead letters %+v, want task 7 with %d attempts", letters, tt.attempts)
+			}
+		})
+	}
+}
+
+// picky carries its own retry policy
+type picky int
+
+func (p picky) RetryPolicy() *RetryPolicy {
+	if p < 0 {
+		return nil
+	}
+	return &RetryPolicy{MaxAttempts: int(p)}
+}
+
+func TestTaskPolicy(t *testing.T) {
+	failing := func(ctx context.Context, p picky) (int, error) { return 0, errTemporary }
+	p := New(context.Background(), failing, Options{
+		Workers:         1,
+		QueueSize:       3,
+		Retry:           &RetryPolicy{MaxAttempts: 10},
+		DeadLetterLimit: 1,
+	})
+	for _, in := range []picky{2, -1, 4} {
+		p.Submit(context.Background(), in)
+	}
+	p.Close()
+
+	attempts := make(map[picky]int)
+	for res := range p.Results() {
+		attempts[res.Input] = len(res.Attempts)
+	}
+	// A nil policy means a single run without history
+	want := map[picky]int{2: 2, -1: 0, 4: 4}
+	for in, n := range want {
+		if attempts[in] != n {
+			t.Errorf("task %d: got %d attempts, want %d", in, attempts[in], n)
+		}
+	}
+
+	// Only the latest dead letter fits
+	dead := p.DeadLetters()
+	if dead.Len() != 1 || dead.Dropped() != 1 {
+		t.Fatalf("got %d dead letters and %d dropped, want 1 and 1", dead.Len(), dead.Dropped())
+	}
+	if letters := dead.Drain(); letters[0].Input != 4 {
+		t.Errorf("got dead letter for task %d, want 4", letters[0].Input)
+	}
+	if dead.Len() != 0 {
+		t.Error("Drain left dead letters behind")
+	}
+}
+
+func TestCancelDuringBackoff(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	ctx, cancel := context.WithCancel(context.Background())
+	failing := func(ctx context.Context, n int) (int, error) { return 0, errTemporary }
+
+	p := New(ctx, failing, Options{
+		Workers: 1,
+		Clock:   clk,
+		Retry:   &RetryPolicy{MaxAttempts: 3, Initial: time.Minute},
+	})
+	p.Submit(ctx, 1)
+	added := p.DeadLetters().Added()
+	clk.BlockUntil(1)
+	cancel()
+
+	res := <-p.Results()
+	if !errors.Is(res.Err, errTemporary) || len(res.Attempts) != 1 || res.Attempts[0].Backoff != 0 {
+		t.Errorf("got %v after %+v, want the last error after one attempt", res.Err, res.Attempts)
+	}
+	select {
+	case <-added:
+		t.Error("a task interrupted by cancellation was dead-lettered")
+	default:
+	}
+	if got := p.Stats(); got.Retried != 0 || got.Dead != 0 {
+		t.Errorf("got stats %+v, want no retries and no dead letters", got)
+	}
+}
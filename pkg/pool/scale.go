@@ -0,0 +1,172 @@
+package pool
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Autoscale lets a pool grow from Options.Workers up to Max workers while
+// tasks pile up, and shrink back as workers find nothing to do.
+//
+// Every Interval the pool looks at how many tasks are waiting and at the
+// average time tasks started during the last Window spent in the queue. If
+// either is above its threshold, one worker is added. Workers that stay idle
+// for IdleTimeout exit, down to Options.Workers.
+type Autoscale struct {
+	Max         int           // Upper bound on workers
+	QueueDepth  int           // Scale up when more tasks than this are waiting
+	MaxWait     time.Duration // Scale up when the average queue wait exceeds this; 0 disables the check
+	Window      time.Duration // Span of the queue wait average, default 1s
+	Interval    time.Duration // How often to check the load, default 100ms
+	IdleTimeout time.Duration // Idle time before a worker exits, default 1s
+
+	// OnScale, if set, is called for every worker added or removed. It runs
+	// on a pool goroutine, so it should return quickly.
+	OnScale func(ScaleEvent)
+}
+
+// ScaleEvent records a change in the number of workers and why it happened
+type ScaleEvent struct {
+	Time      time.Time
+	From, To  int           // Worker count before and after
+	Reason    string        // Human readable cause, e.g. "queue depth 7 > 2"
+	QueueLen  int           // Tasks waiting when the decision was made
+	AvgWait   time.Duration // Average queue wait over the window
+	WorkerID  int           // Worker that was started or stopped
+	ScaleDown bool
+}
+
+func (e ScaleEvent) String() string {
+	verb := "up"
+	if e.ScaleDown {
+		verb = "down"
+	}
+	return fmt.Sprintf("scaled %s %d -> %d: %s", verb, e.From, e.To, e.Reason)
+}
+
+// withDefaults fills in the zero fields
+func (a Autoscale) withDefaults() Autoscale {
+	if a.Window <= 0 {
+		a.Window = time.Second
+	}
+	if a.Interval <= 0 {
+		a.Interval = 100 * time.Millisecond
+	}
+	if a.IdleTimeout <= 0 {
+		a.IdleTimeout = time.Second
+	}
+	return a
+}
+
+// waitWindow keeps the queue waits of recently started tasks
+type waitWindow struct {
+	mu      sync.Mutex
+	span    time.Duration
+	samples []waitSample
+}
+
+type waitSample struct {
+	at   time.Time
+	wait time.Duration
+}
+
+func (w *waitWindow) add(at time.Time, wait time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.samples = append(w.samples, waitSample{at, wait})
+}
+
+// average returns the mean wait of the samples taken since now-span and
+// forgets older ones
+func (w *waitWindow) average(now time.Time) time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	cutoff := now.Add(-w.span)
+	i := 0
+	for i < len(w.samples) && !w.samples[i].at.After(cutoff) {
+		i++
+	}
+	w.samples = append(w.samples[:0], w.samples[i:]...)
+
+	if len(w.samples) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, s := range w.samples {
+		total += s.wait
+	}
+	return total / time.Duration(len(w.samples))
+}
+
+// autoscale checks the load every Interval until the workers are gone
+func (p *Pool[In, Out]) autoscale() {
+	ticker := p.clk.NewTicker(p.scale.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+		case <-p.stopped:
+			return
+		}
+
+		now := p.clk.Now()
+		depth := p.queue.Len()
+		avg := p.window.average(now)
+
+		var reason string
+		switch {
+		case depth > p.scale.QueueDepth:
+			reason = fmt.Sprintf("queue depth %d > %d", depth, p.scale.QueueDepth)
+		case p.scale.MaxWait > 0 && avg > p.scale.MaxWait:
+			reason = fmt.Sprintf("average queue wait %v > %v over %v", avg, p.scale.MaxWait, p.scale.Window)
+		default:
+			continue
+		}
+		p.grow(ScaleEvent{Time: now, Reason: reason, QueueLen: depth, AvgWait: avg})
+	}
+}
+
+// grow starts another worker unless the pool is at its maximum or its
+// workers have already finished
+func (p *Pool[In, Out]) grow(ev ScaleEvent) {
+	p.scaleMu.Lock()
+	if p.live == 0 || p.live >= p.scale.Max {
+		p.scaleMu.Unlock()
+		return
+	}
+	ev.From, ev.To = p.live, p.live+1
+	ev.WorkerID = p.startWorker()
+	p.scaleMu.Unlock()
+
+	if p.scale.OnScale != nil {
+		p.scale.OnScale(ev)
+	}
+}
+
+// retire lets an idle worker exit if the pool is above its minimum
+func (p *Pool[In, Out]) retire(id int, idle time.Duration) bool {
+	p.scaleMu.Lock()
+	if p.live <= p.min {
+		p.scaleMu.Unlock()
+		return false
+	}
+	p.live--
+	ev := ScaleEvent{
+		Time:      p.clk.Now(),
+		From:      p.live + 1,
+		To:        p.live,
+		Reason:    fmt.Sprintf("worker %d idle for %v", id, idle),
+		QueueLen:  p.queue.Len(),
+		WorkerID:  id,
+		ScaleDown: true,
+	}
+	p.scaleMu.Unlock()
+
+	if p.scale.OnScale != nil {
+		p.scale.OnScale(ev)
+	}
+	return true
+}
@@ -0,0 +1,169 @@
+package pool
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go-by-example/pkg/clock"
+)
+
+// blockingTask returns a Func that signals started and then waits for
+// release, so tests decide when workers become free
+func blockingTask() (fn Func[int, int], started <-chan int, release chan<- struct{}) {
+	s := make(chan int, 100)
+	r := make(chan struct{})
+	return func(ctx context.Context, n int) (int, error) {
+		s <- n
+		<-r
+		return n, nil
+	}, s, r
+}
+
+func expectEvent(t *testing.T, events <-chan ScaleEvent, from, to int, reason string) {
+	t.Helper()
+	select {
+	case ev := <-events:
+		if ev.From != from || ev.To != to || !strings.Contains(ev.Reason, reason) {
+			t.Fatalf("got event %v, want %d -> %d because of %q", ev, from, to, reason)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for scale event %d -> %d", from, to)
+	}
+}
+
+func expectNoEvent(t *testing.T, events <-chan ScaleEvent) {
+	t.Helper()
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected scale event %v", ev)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestAutoscaleQueueDepth(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	events := make(chan ScaleEvent, 10)
+	fn, started, release := blockingTask()
+
+	p := New(context.Background(), fn, Options{
+		Workers:   1,
+		QueueSize: 10,
+		Clock:     clk,
+		Autoscale: &Autoscale{
+			Max:         3,
+			QueueDepth:  1,
+			Interval:    100 * time.Millisecond,
+			IdleTimeout: 500 * time.Millisecond,
+			OnScale:     func(ev ScaleEvent) { events <- ev },
+		},
+	})
+	for i := 1; i <= 5; i++ {
+		if err := p.Submit(context.Background(), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-started // Worker 1 is busy, four tasks wait
+
+	clk.BlockUntil(1) // The autoscale ticker
+	clk.Advance(100 * time.Millisecond)
+	expectEvent(t, events, 1, 2, "queue depth 4 > 1")
+	<-started
+
+	clk.Advance(100 * time.Millisecond)
+	expectEvent(t, events, 2, 3, "queue depth 3 > 1")
+	<-started
+
+	// At the maximum, a long queue adds nobody
+	clk.Advance(100 * time.Millisecond)
+	expectNoEvent(t, events)
+	if got := p.Stats().Workers; got != 3 {
+		t.Fatalf("got %d workers, want 3", got)
+	}
+
+	// Let everything finish; then all three workers wait idle
+	close(release)
+	for range 5 {
+		<-p.Results()
+	}
+	clk.BlockUntil(4) // Ticker plus three idle timers
+	clk.Advance(500 * time.Millisecond)
+	expectEvent(t, events, 3, 2, "idle for 500ms")
+	expectEvent(t, events, 2, 1, "idle for 500ms")
+	expectNoEvent(t, events) // Never below Options.Workers
+
+	p.Close()
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Stats(); got.Peak != 3 || got.Workers != 0 || got.Completed != 5 {
+		t.Errorf("got stats %+v, want 5 completed with a peak of 3 workers", got)
+	}
+}
+
+func TestAutoscaleQueueWait(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	events := make(chan ScaleEvent, 10)
+	fn, started, release := blockingTask()
+
+	p := New(context.Background(), fn, Options{
+		Workers:   1,
+		QueueSize: 10,
+		Clock:     clk,
+		Autoscale: &Autoscale{
+			Max:        2,
+			QueueDepth: 100, // Only latency matters here
+			MaxWait:    50 * time.Millisecond,
+			Window:     time.Second,
+			Interval:   100 * time.Millisecond,
+			OnScale:    func(ev ScaleEvent) { events <- ev },
+		},
+	})
+	defer func() {
+		close(release)
+		p.Close()
+		for range p.Results() {
+		}
+	}()
+
+	p.Submit(context.Background(), 1)
+	<-started
+	p.Submit(context.Background(), 2)
+
+	// Task 2 waits 200ms for worker 1, but the queue is short
+	clk.BlockUntil(1)
+	clk.Advance(200 * time.Millisecond)
+	expectNoEvent(t, events)
+
+	release <- struct{}{}
+	<-p.Results()
+	<-started // Task 2 starts after waiting 200ms: the average is 100ms
+
+	clk.Advance(100 * time.Millisecond)
+	expectEvent(t, events, 1, 2, "average queue wait 100ms > 50ms")
+}
+
+func TestWaitWindow(t *testing.T) {
+	w := waitWindow{span: time.Second}
+	w.add(epoch, 100*time.Millisecond)
+	w.add(epoch.Add(500*time.Millisecond), 300*time.Millisecond)
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected time.Duration
+	}{
+		{name: "both samples", now: epoch.Add(900 * time.Millisecond), expected: 200 * time.Millisecond},
+		{name: "first expired", now: epoch.Add(1200 * time.Millisecond), expected: 300 * time.Millisecond},
+		{name: "all expired", now: epoch.Add(2 * time.Second), expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.average(tt.now); got != tt.expected {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+// Package pubsub is an in-process publish/subscribe broker built on
+// channels.
+//
+// Publishers send values to topics such as "orders.eu.created", and every
+// subscription whose pattern matches the topic gets a copy on its own
+// buffered channel. Patterns may use wildcards: "orders.*.created" matches
+// one segment in the middle, and "orders.>" everything below orders.
+//
+// A subscriber that doesn't keep up fills its buffer. What happens next is
+// up to its Policy: Publish waits for room, drops the oldest or the newest
+// message, or disconnects the subscriber. Only Block slows down the
+// publisher; with the other policies, a slow subscriber never holds up the
+// others.
+//
+//	b := pubsub.New[Order]()
+//	sub, _ := b.Subscribe("orders.>", pubsub.SubOptions{Buffer: 64, Policy: pubsub.DropOldest})
+//	go func() {
+//		for msg := range sub.C() {
+//			...
+//		}
+//	}()
+//	b.Publish(ctx, "orders.eu.created", order)
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrClosed is returned after the broker is closed, and by Err of its
+	// subscriptions
+	ErrClosed = errors.New("pubsub: broker closed")
+	// ErrSlowConsumer is returned by Err of a subscription that was
+	// disconnected for falling behind
+	ErrSlowConsumer = errors.New("pubsub: slow consumer disconnected")
+	// ErrUnsubscribed is returned by Err after Unsubscribe
+	ErrUnsubscribed = errors.New("pubsub: unsubscribed")
+)
+
+// Policy decides what Publish does when a subscriber's buffer is full
+type Policy int
+
+const (
+	// Block makes Publish wait until there is room or its context is done
+	Block Policy = iota
+	// DropOldest discards the oldest buffered message to make room
+	DropOldest
+	// DropNewest discards the message being published
+	DropNewest
+	// Disconnect ends the subscription, closing its channel
+	Disconnect
+)
+
+func (p Policy) String() string {
+	switch p {
+	case Block:
+		return "block"
+	case DropOldest:
+		return "drop oldest"
+	case DropNewest:
+		return "drop newest"
+	case Disconnect:
+		return "disconnect"
+	}
+	return "unknown"
+}
+
+// Message is a value published to a topic
+type Message[T any] struct {
+	Topic string
+	Value T
+}
+
+// SubOptions configures a Subscription
+type SubOptions struct {
+	Buffer int    // Messages held for the subscriber; 0 hands each one over directly
+	Policy Policy // What happens when the buffer is full
+}
+
+// Stats counts messages over the lifetime of a Broker
+type Stats struct {
+	Published    uint64 // Calls to Publish that were accepted
+	Delivered    uint64 // Copies put into subscriber buffers
+	Dropped      uint64 // Copies discarded by DropOldest and DropNewest
+	Disconnected uint64 // Subscribers disconnected for being slow
+	Subscribers  int    // Active subscriptions now
+}
+
+// Broker routes published messages to matching subscriptions. It is safe
+// for concurrent use.
+type Broker[T any] struct {
+	mu     sync.RWMutex
+	subs   map[uint64]*Subscription[T]
+	nextID uint64
+	closed bool
+
+	published    atomic.Uint64
+	delivered    atomic.Uint64
+	dropped      atomic.Uint64
+	disconnected atomic.Uint64
+}
+
+// New returns a Broker without subscribers
+func New[T any]() *Broker[T] {
+	return &Broker[T]{subs: make(map[uint64]*Subscription[T])}
+}
+
+// Subscribe starts delivering messages whose topic matches pattern
+func (b *Broker[T]) Subscribe(pattern string, opts SubOptions) (*Subscription[T], error) {
+	if err := validate(pattern, true); err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	b.nextID++
+	s := &Subscription[T]{
+		broker:  b,
+		id:      b.nextID,
+		pattern: pattern,
+		policy:  opts.Policy,
+		ch:      make(chan Message[T], max(opts.Buffer, 0)),
+		done:    make(chan struct{}),
+	}
+	b.subs[s.id] = s
+	return s, nil
+}
+
+// Publish sends v to every subscription matching topic and returns how many
+// got it. Subscriptions with the Block policy make it wait for room in
+// their buffer; if ctx is done first, Publish returns its error and the
+// remaining subscriptions may miss the message.
+func (b *Broker[T]) Publish(ctx context.Context, topic string, v T) (int, error) {
+	if err := validate(topic, false); err != nil {
+		return 0, err
+	}
+
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return 0, ErrClosed
+	}
+	var matched []*Subscription[T]
+	for _, s := range b.subs {
+		if Match(s.pattern, topic) {
+			matched = append(matched, s)
+		}
+	}
+	b.mu.RUnlock()
+	b.published.Add(1)
+
+	m := Message[T]{Topic: topic, Value: v}
+	delivered := 0
+	for _, s := range matched {
+		ok, err := s.deliver(ctx, m)
+		if err != nil {
+			return delivered, err
+		}
+		if ok {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// Close ends every subscription, closing their channels once their buffered
+// messages are read, and makes Publish and Subscribe fail with ErrClosed
+func (b *Broker[T]) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	subs := b.subs
+	b.subs = make(map[uint64]*Subscription[T])
+	b.mu.Unlock()
+
+	for _, s := range subs {
+		s.close(ErrClosed)
+	}
+}
+
+// Stats returns the counters so far
+func (b *Broker[T]) Stats() Stats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return Stats{
+		Published:    b.published.Load(),
+		Delivered:    b.delivered.Load(),
+		Dropped:      b.dropped.Load(),
+		Disconnected: b.disconnected.Load(),
+		Subscribers:  len(b.subs),
+	}
+}
+
+// remove forgets a subscription
+func (b *Broker[T]) remove(id uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs, id)
+}
+
+// SubStats counts the messages of one Subscription
+type SubStats struct {
+	Delivered uint64 // Messages put into the buffer
+	Dropped   uint64 // Messages discarded because the buffer was full
+	Pending   int    // Messages in the buffer now
+}
+
+// Subscription receives the messages published to matching topics
+type Subscription[T any] struct {
+	broker  *Broker[T]
+	id      uint64
+	pattern string
+	policy  Policy
+	ch      chan Message[T]
+
+	// Senders hold mu for reading; closing ch takes it for writing, after
+	// closing done to wake senders blocked on a full buffer
+	mu       sync.RWMutex
+	done     chan struct{}
+	stopOnce sync.Once
+	closed   bool
+	err      error
+
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// C returns the channel messages are delivered on. It is closed when the
+// subscription ends, after the messages already buffered.
+func (s *Subscription[T]) C() <-chan Message[T] {
+	return s.ch
+}
+
+// Pattern returns the topic pattern the subscription was made with
+func (s *Subscription[T]) Pattern() string {
+	return s.pattern
+}
+
+// Unsubscribe ends the subscription. It may be called more than once.
+func (s *Subscription[T]) Unsubscribe() {
+	s.broker.remove(s.id)
+	s.close(ErrUnsubscribed)
+}
+
+// Err returns why the subscription ended: ErrUnsubscribed, ErrSlowConsumer
+// or ErrClosed. It returns nil while the subscription is active.
+func (s *Subscription[T]) Err() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.err
+}
+
+// Stats returns the counters of the subscription
+func (s *Subscription[T]) Stats() SubStats {
+	return SubStats{
+		Delivered: s.delivered.Load(),
+		Dropped:   s.dropped.Load(),
+		Pending:   len(s.ch),
+	}
+}
+
+// deliver puts m into the buffer according to the policy. It reports
+// whether m was delivered, and fails only if ctx is done while blocked.
+func (s *Subscription[T]) deliver(ctx context.Context, m Message[T]) (bool, error) {
+	ok, slow, err := s.send(ctx, m)
+	if slow {
+		s.broker.disconnected.Add(1)
+		s.broker.remove(s.id)
+		s.close(ErrSlowConsumer)
+	}
+	return ok, err
+}
+
+// send does the work of deliver while holding s.mu, and reports whether
+// the subscriber should be disconnected
+func (s *Subscription[T]) send(ctx context.Context, m Message[T]) (ok, slow bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return false, false, nil
+	}
+
+	select {
+	case s.ch <- m:
+		s.count(true)
+		return true, false, nil
+	default:
+	}
+
+	switch s.policy {
+	case DropOldest:
+		if cap(s.ch) == 0 {
+			// Nothing buffered to drop, so the new message goes instead
+			s.count(false)
+			return false, false, nil
+		}
+		// Other publishers may refill the buffer between the two steps
+		for {
+			select {
+			case <-s.ch:
+				s.count(false)
+			default:
+			}
+			select {
+			case s.ch <- m:
+				s.count(true)
+				return true, false, nil
+			default:
+			}
+		}
+	case DropNewest:
+		s.count(false)
+		return false, false, nil
+	case Disconnect:
+		return false, true, nil
+	default:
+		select {
+		case s.ch <- m:
+			s.count(true)
+			return true, false, nil
+		case <-s.done:
+			return false, false, nil
+		case <-ctx.Done():
+			return false, false, ctx.Err()
+		}
+	}
+}
+
+// count records a delivered or dropped message
+func (s *Subscription[T]) count(delivered bool) {
+	if delivered {
+		s.delivered.Add(1)
+		s.broker.delivered.Add(1)
+	} else {
+		s.dropped.Add(1)
+		s.broker.dropped.Add(1)
+	}
+}
+
+// close ends the subscription with err, unless it already ended
+func (s *Subscription[T]) close(err error) {
+	s.stopOnce.Do(func() { close(s.done) })
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		s.err = err
+		close(s.ch)
+	}
+}
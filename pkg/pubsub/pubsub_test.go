@@ -0,0 +1,269 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// drain reads everything left on a subscription after it ended
+func drain[T any](s *Subscription[T]) []T {
+	var values []T
+	for m := range s.C() {
+		values = append(values, m.Value)
+	}
+	return values
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		topic    string
+		expected bool
+	}{
+		{pattern: "orders.created", topic: "orders.created", expected: true},
+		{pattern: "orders.created", topic: "orders.deleted", expected: false},
+		{pattern: "orders.created", topic: "orders", expected: false},
+		{pattern: "orders", topic: "orders.created", expected: false},
+		{pattern: "orders.*", topic: "orders.created", expected: true},
+		{pattern: "orders.*", topic: "orders.eu.created", expected: false},
+		{pattern: "orders.*.created", topic: "orders.eu.created", expected: true},
+		{pattern: "orders.*.created", topic: "orders.eu.deleted", expected: false},
+		{pattern: "*", topic: "orders", expected: true},
+		{pattern: "orders.>", topic: "orders.eu.created", expected: true},
+		{pattern: "orders.>", topic: "orders.created", expected: true},
+		{pattern: "orders.>", topic: "orders", expected: false},
+		{pattern: ">", topic: "anything.at.all", expected: true},
+		{pattern: "*.eu.>", topic: "orders.eu.created.late", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.topic, func(t *testing.T) {
+			if got := Match(tt.pattern, tt.topic); got != tt.expected {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInvalidTopics(t *testing.T) {
+	b := New[int]()
+	for _, pattern := range []string{"", "orders.", ".orders", "orders..created", "orders.>.created"} {
+		if _, err := b.Subscribe(pattern, SubOptions{}); !errors.Is(err, ErrInvalidTopic) {
+			t.Errorf("Subscribe(%q): got %v, want %v", pattern, err, ErrInvalidTopic)
+		}
+	}
+	for _, topic := range []string{"", "orders.*", "orders.>"} {
+		if _, err := b.Publish(context.Background(), topic, 1); !errors.Is(err, ErrInvalidTopic) {
+			t.Errorf("Publish(%q): got %v, want %v", topic, err, ErrInvalidTopic)
+		}
+	}
+}
+
+func TestRouting(t *testing.T) {
+	b := New[string]()
+	patterns := []string{"orders.*.created", "orders.>", "users.signup", ">"}
+	subs := make(map[string]*Subscription[string])
+	for _, p := range patterns {
+		s, err := b.Subscribe(p, SubOptions{Buffer: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		subs[p] = s
+	}
+
+	publish := map[string]int{
+		"orders.eu.created": 3,
+		"orders.eu.shipped": 2,
+		"users.signup":      2,
+		"users.deleted":     1,
+	}
+	for _, topic := range []string{"orders.eu.created", "orders.eu.shipped", "users.signup", "users.deleted"} {
+		n, err := b.Publish(context.Background(), topic, topic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != publish[topic] {
+			t.Errorf("Publish(%q): got %d subscribers, want %d", topic, n, publish[topic])
+		}
+	}
+	b.Close()
+
+	want := map[string][]string{
+		"orders.*.created": {"orders.eu.created"},
+		"orders.>":         {"orders.eu.created", "orders.eu.shipped"},
+		"users.signup":     {"users.signup"},
+		">":                {"orders.eu.created", "orders.eu.shipped", "users.signup", "users.deleted"},
+	}
+	for p, s := range subs {
+		if got := drain(s); !slices.Equal(got, want[p]) {
+			t.Errorf("%s: got %v, want %v", p, got, want[p])
+		}
+	}
+	if s := b.Stats(); s.Published != 4 || s.Delivered != 8 || s.Subscribers != 0 {
+		t.Errorf("got %+v, want 4 published, 8 delivered and no subscribers", s)
+	}
+}
+
+func TestSlowConsumerPolicies(t *testing.T) {
+	tests := []struct {
+		policy   Policy
+		expected []int
+		dropped  uint64
+		err      error
+	}{
+		{policy: DropOldest, expected: []int{4, 5}, dropped: 3, err: ErrClosed},
+		{policy: DropNewest, expected: []int{1, 2}, dropped: 3, err: ErrClosed},
+		{policy: Disconnect, expected: []int{1, 2}, dropped: 0, err: ErrSlowConsumer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			b := New[int]()
+			slow, _ := b.Subscribe("events", SubOptions{Buffer: 2, Policy: tt.policy})
+			fast, _ := b.Subscribe("events", SubOptions{Buffer: 5})
+
+			// Nobody reads slow, but it never holds up the others
+			for i := 1; i <= 5; i++ {
+				if _, err := b.Publish(context.Background(), "events", i); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if s := slow.Stats(); s.Dropped != tt.dropped || s.Pending != 2 {
+				t.Errorf("got %+v, want %d dropped and 2 pending", s, tt.dropped)
+			}
+			b.Close()
+
+			if got := drain(slow); !slices.Equal(got, tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+			if !errors.Is(slow.Err(), tt.err) {
+				t.Errorf("got error %v, want %v", slow.Err(), tt.err)
+			}
+			if got := drain(fast); len(got) != 5 {
+				t.Errorf("other subscriber got %v, want all 5", got)
+			}
+		})
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	b := New[int]()
+	s, _ := b.Subscribe("events", SubOptions{Buffer: 1, Policy: Disconnect})
+	b.Publish(context.Background(), "events", 1)
+	if n, _ := b.Publish(context.Background(), "events", 2); n != 0 {
+		t.Errorf("got %d subscribers, want 0", n)
+	}
+
+	if got := drain(s); !slices.Equal(got, []int{1}) {
+		t.Errorf("got %v, want [1]", got)
+	}
+	if st := b.Stats(); st.Disconnected != 1 || st.Subscribers != 0 {
+		t.Errorf("got %+v, want 1 disconnected and no subscribers", st)
+	}
+}
+
+func TestBlock(t *testing.T) {
+	b := New[int]()
+	s, _ := b.Subscribe("events", SubOptions{Buffer: 1})
+	b.Publish(context.Background(), "events", 1)
+
+	// A full buffer makes Publish wait, until its context gives up...
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := b.Publish(ctx, "events", 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// ...or the subscriber makes room
+	published := make(chan int)
+	go func() {
+		n, _ := b.Publish(context.Background(), "events", 3)
+		published <- n
+	}()
+	if m := <-s.C(); m.Value != 1 {
+		t.Errorf("got %d, want 1", m.Value)
+	}
+	if n := <-published; n != 1 {
+		t.Errorf("got %d subscribers, want 1", n)
+	}
+
+	// Closing the broker releases a blocked publisher, or makes a late one
+	// fail; either way the message isn't delivered
+	go func() {
+		n, _ := b.Publish(context.Background(), "events", 4)
+		published <- n
+	}()
+	b.Close()
+	if n := <-published; n != 0 {
+		t.Errorf("got %d subscribers after Close, want 0", n)
+	}
+	if got := drain(s); len(got) != 1 || got[0] != 3 {
+		t.Errorf("got %v, want [3]", got)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	b := New[int]()
+	s, _ := b.Subscribe("events", SubOptions{Buffer: 1})
+	b.Publish(context.Background(), "events", 1)
+	s.Unsubscribe()
+	s.Unsubscribe()
+
+	if n, _ := b.Publish(context.Background(), "events", 2); n != 0 {
+		t.Errorf("got %d subscribers after Unsubscribe, want 0", n)
+	}
+	if got := drain(s); !slices.Equal(got, []int{1}) {
+		t.Errorf("got %v, want [1]", got)
+	}
+	if !errors.Is(s.Err(), ErrUnsubscribed) {
+		t.Errorf("got %v, want %v", s.Err(), ErrUnsubscribed)
+	}
+
+	b.Close()
+	if _, err := b.Subscribe("events", SubOptions{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Subscribe after Close: got %v, want %v", err, ErrClosed)
+	}
+	if _, err := b.Publish(context.Background(), "events", 3); !errors.Is(err, ErrClosed) {
+		t.Errorf("Publish after Close: got %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestConcurrentPublishers(t *testing.T) {
+	b := New[int]()
+	s, _ := b.Subscribe("events.>", SubOptions{Buffer: 4})
+	lossy, _ := b.Subscribe(">", SubOptions{Buffer: 1, Policy: DropOldest})
+
+	got := make(chan int)
+	go func() {
+		sum := 0
+		for m := range s.C() {
+			sum += m.Value
+		}
+		got <- sum
+	}()
+
+	var wg sync.WaitGroup
+	for p := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= 100; i++ {
+				b.Publish(context.Background(), "events.p"+string(rune('a'+p)), i)
+			}
+		}()
+	}
+	wg.Wait()
+	b.Close()
+
+	if sum := <-got; sum != 4*5050 {
+		t.Errorf("got sum %d, want %d", sum, 4*5050)
+	}
+	ls := lossy.Stats()
+	if ls.Delivered != 400 || ls.Dropped != 399 {
+		t.Errorf("got %+v, want 400 delivered and 399 dropped", ls)
+	}
+}
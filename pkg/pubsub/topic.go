@@ -0,0 +1,53 @@
+package pubsub
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidTopic is returned for an empty topic or segment, a wildcard in
+// a published topic, or a ">" that isn't the last segment of a pattern
+var ErrInvalidTopic = errors.New("pubsub: invalid topic")
+
+// validate checks a topic, or a pattern if wildcards are allowed
+func validate(topic string, wildcards bool) error {
+	if topic == "" {
+		return ErrInvalidTopic
+	}
+	segments := strings.Split(topic, ".")
+	for i, s := range segments {
+		switch {
+		case s == "":
+			return ErrInvalidTopic
+		case (s == "*" || s == ">") && !wildcards:
+			return ErrInvalidTopic
+		case s == ">" && i != len(segments)-1:
+			return ErrInvalidTopic
+		}
+	}
+	return nil
+}
+
+// Match reports whether topic matches pattern. Topics are made of segments
+// separated by dots; in a pattern, "*" matches exactly one segment and a
+// final ">" matches one or more.
+//
+//	Match("orders.*", "orders.created")       // true
+//	Match("orders.*", "orders.eu.created")    // false
+//	Match("orders.>", "orders.eu.created")    // true
+//	Match("orders.>", "orders")               // false
+func Match(pattern, topic string) bool {
+	for {
+		p, pRest, pMore := strings.Cut(pattern, ".")
+		t, tRest, tMore := strings.Cut(topic, ".")
+		switch {
+		case p == ">":
+			return t != ""
+		case p != "*" && p != t:
+			return false
+		case !pMore || !tMore:
+			return pMore == tMore
+		}
+		pattern, topic = pRest, tRest
+	}
+}
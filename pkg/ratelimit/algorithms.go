@@ -0,0 +1,152 @@
+package ratelimit
+
+import (
+	"slices"
+	"time"
+
+	"go-by-example/pkg/clock"
+)
+
+// NewTokenBucket returns a Limiter with a bucket of burst tokens, refilled
+// at perSecond tokens per second. Each event takes a token; the bucket
+// starts full. A nil clock means the real one.
+func NewTokenBucket(clk clock.Clock, perSecond float64, burst int) *Limiter {
+	l := newLimiter(clk, nil)
+	l.alg = &tokenBucket{
+		interval: perEvent(perSecond),
+		burst:    float64(max(burst, 1)),
+		tokens:   float64(max(burst, 1)),
+		last:     l.clk.Now(),
+	}
+	return l
+}
+
+type tokenBucket struct {
+	interval time.Duration // Time to refill one token
+	burst    float64
+	tokens   float64 // Negative while events are reserved ahead
+	last     time.Time
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens = min(b.burst, b.tokens+float64(now.Sub(b.last))/float64(b.interval))
+		b.last = now
+	}
+}
+
+func (b *tokenBucket) take(now time.Time, maxWait time.Duration) (time.Time, bool) {
+	b.refill(now)
+	var wait time.Duration
+	if b.tokens < 1 {
+		wait = time.Duration((1 - b.tokens) * float64(b.interval))
+	}
+	if wait > maxWait {
+		return time.Time{}, false
+	}
+	b.tokens--
+	return now.Add(wait), true
+}
+
+func (b *tokenBucket) give(at time.Time) {
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+func (b *tokenBucket) rested() time.Time {
+	return b.last.Add(time.Duration((b.burst - b.tokens) * float64(b.interval)))
+}
+
+// NewGCRA returns a Limiter using the generic cell rate algorithm: events
+// are spaced 1/perSecond apart, but up to burst of them may come early. It
+// behaves like NewTokenBucket with the same arguments. A nil clock means the
+// real one.
+func NewGCRA(clk clock.Clock, perSecond float64, burst int) *Limiter {
+	l := newLimiter(clk, nil)
+	interval := perEvent(perSecond)
+	l.alg = &gcra{
+		interval:  interval,
+		tolerance: time.Duration(max(burst, 1)-1) * interval,
+		tat:       l.clk.Now(),
+	}
+	return l
+}
+
+type gcra struct {
+	interval  time.Duration // Emission interval between events
+	tolerance time.Duration // How early an event may come
+	tat       time.Time     // Theoretical arrival time of the next event
+}
+
+func (g *gcra) take(now time.Time, maxWait time.Duration) (time.Time, bool) {
+	tat := g.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	at := tat.Add(-g.tolerance)
+	if at.Before(now) {
+		at = now
+	}
+	if at.Sub(now) > maxWait {
+		return time.Time{}, false
+	}
+	g.tat = tat.Add(g.interval)
+	return at, true
+}
+
+func (g *gcra) give(at time.Time) {
+	g.tat = g.tat.Add(-g.interval)
+}
+
+func (g *gcra) rested() time.Time {
+	return g.tat
+}
+
+// NewSlidingLog returns a Limiter that allows at most limit events in any
+// window of the given length, remembering the time of each event. It is
+// exact where a token bucket is smooth, at the cost of memory proportional
+// to limit. A nil clock means the real one.
+func NewSlidingLog(clk clock.Clock, limit int, window time.Duration) *Limiter {
+	if limit < 1 || window <= 0 {
+		panic("ratelimit: sliding log needs a positive limit and window")
+	}
+	return newLimiter(clk, &slidingLog{limit: limit, window: window})
+}
+
+type slidingLog struct {
+	limit  int
+	window time.Duration
+	log    []time.Time // Times of events in the current window, and reserved ones, ascending
+}
+
+func (s *slidingLog) take(now time.Time, maxWait time.Duration) (time.Time, bool) {
+	cutoff := now.Add(-s.window)
+	i := 0
+	for i < len(s.log) && !s.log[i].After(cutoff) {
+		i++
+	}
+	s.log = append(s.log[:0], s.log[i:]...)
+
+	at := now
+	if len(s.log) >= s.limit {
+		// The slot frees up when the limit-th latest event leaves the window
+		at = s.log[len(s.log)-s.limit].Add(s.window)
+	}
+	if at.Sub(now) > maxWait {
+		return time.Time{}, false
+	}
+	s.log = append(s.log, at)
+	return at, true
+}
+
+func (s *slidingLog) give(at time.Time) {
+	if i := slices.Index(s.log, at); i >= 0 {
+		s.log = slices.Delete(s.log, i, i+1)
+	}
+}
+
+func (s *slidingLog) rested() time.Time {
+	if len(s.log) == 0 {
+		return time.Time{}
+	}
+	return s.log[len(s.log)-1].Add(s.window)
+}
@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-by-example/pkg/clock"
+)
+
+// Keyed keeps a separate Limiter per key, such as a user ID or host name,
+// created on first use. Limiters that have been at rest, with nothing
+// reserved and fully recovered, for IdleTimeout are dropped, so the map
+// doesn't grow with every key ever seen. Dropping a rested limiter is
+// invisible to callers: a new one starts in the same state.
+//
+// A limiter handed out by Get also counts as busy for the idle timeout, so
+// it isn't dropped between Get and its use while a new one takes its place
+// with a full allowance. Allow and Reserve use the limiter before another
+// call can drop it.
+type Keyed[K comparable] struct {
+	mu         sync.Mutex
+	clk        clock.Clock
+	idle       time.Duration
+	newLimiter func() *Limiter
+	limiters   map[K]*keyedLimiter
+	lastSweep  time.Time
+}
+
+// keyedLimiter is a Limiter kept by Keyed
+type keyedLimiter struct {
+	*Limiter
+	lastGet time.Time // When the limiter was last handed out
+}
+
+// NewKeyed returns a Keyed that creates limiters with newLimiter and drops
+// them after idle at rest. A nil clock means the real one; it should be the
+// clock the limiters use.
+func NewKeyed[K comparable](clk clock.Clock, idle time.Duration, newLimiter func() *Limiter) *Keyed[K] {
+	if clk == nil {
+		clk = clock.New()
+	}
+	return &Keyed[K]{
+		clk:        clk,
+		idle:       idle,
+		newLimiter: newLimiter,
+		limiters:   make(map[K]*keyedLimiter),
+		lastSweep:  clk.Now(),
+	}
+}
+
+// Get returns the Limiter for key, creating it if needed. The limiter is
+// kept for at least the idle timeout from now.
+func (k *Keyed[K]) Get(key K) *Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.get(key)
+}
+
+// get is Get with k.mu held
+func (k *Keyed[K]) get(key K) *Limiter {
+	now := k.clk.Now()
+	if now.Sub(k.lastSweep) >= k.idle {
+		k.sweep(now)
+	}
+
+	lim, ok := k.limiters[key]
+	if !ok {
+		lim = &keyedLimiter{Limiter: k.newLimiter()}
+		k.limiters[key] = lim
+	}
+	lim.lastGet = now
+	return lim.Limiter
+}
+
+// Allow reports whether an event for key may happen now
+func (k *Keyed[K]) Allow(key K) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.get(key).Allow()
+}
+
+// Reserve claims the next slot for key
+func (k *Keyed[K]) Reserve(key K) *Reservation {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.get(key).Reserve()
+}
+
+// Wait blocks until an event for key may happen
+func (k *Keyed[K]) Wait(ctx context.Context, key K) error {
+	return k.Get(key).Wait(ctx)
+}
+
+// Len returns the number of limiters currently kept
+func (k *Keyed[K]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.limiters)
+}
+
+// Evict drops the limiters that have been at rest for the idle timeout.
+// Get does this on its own from time to time; Evict is for callers that
+// want to free memory at a moment of their choosing.
+func (k *Keyed[K]) Evict() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.sweep(k.clk.Now())
+}
+
+// sweep drops the limiters that have been neither busy nor handed out for
+// the idle timeout. k.mu must be held.
+func (k *Keyed[K]) sweep(now time.Time) {
+	for key, lim := range k.limiters {
+		since := lim.rested()
+		if lim.lastGet.After(since) {
+			since = lim.lastGet
+		}
+		if !now.Before(since.Add(k.idle)) {
+			delete(k.limiters, key)
+		}
+	}
+	k.lastSweep = now
+}
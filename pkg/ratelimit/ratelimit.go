@@ -0,0 +1,154 @@
+// Package ratelimit limits how often events may happen, with one Limiter
+// type backed by a choice of algorithm:
+//
+//   - NewTokenBucket: a bucket of burst tokens refilled at a steady rate.
+//     Idle time builds up credit for a burst.
+//   - NewGCRA: the generic cell rate algorithm, which allows the same bursts
+//     as a token bucket but keeps a single timestamp instead of a counter.
+//   - NewSlidingLog: at most limit events in any window of time, tracked
+//     exactly by remembering when each event happened.
+//
+// A Limiter is safe for concurrent use, so one Limiter shared by all
+// workers limits them together. Keyed keeps a Limiter per key, for example
+// per user or per host, and forgets the ones that fall idle.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"go-by-example/pkg/clock"
+)
+
+// ErrExceedsDeadline is returned by Wait when the context's deadline would
+// pass before the event is allowed
+var ErrExceedsDeadline = errors.New("ratelimit: wait would exceed context deadline")
+
+// forever is the largest delay Reserve accepts
+const forever = time.Duration(math.MaxInt64)
+
+// algorithm decides when events may happen. Limiter serializes the calls.
+type algorithm interface {
+	// take reserves the earliest slot at or after now, unless that is more
+	// than maxWait away, and returns the time the slot may be used
+	take(now time.Time, maxWait time.Duration) (time.Time, bool)
+	// give returns the slot reserved for at, which won't be used
+	give(at time.Time)
+	// rested returns the time from which the algorithm is back in its
+	// initial state if nothing else is taken
+	rested() time.Time
+}
+
+// Limiter controls how often events may happen
+type Limiter struct {
+	mu  sync.Mutex
+	clk clock.Clock
+	alg algorithm
+}
+
+func newLimiter(clk clock.Clock, alg algorithm) *Limiter {
+	if clk == nil {
+		clk = clock.New()
+	}
+	return &Limiter{clk: clk, alg: alg}
+}
+
+// Allow reports whether an event may happen now, and if so uses it up
+func (l *Limiter) Allow() bool {
+	_, ok := l.reserve(0)
+	return ok
+}
+
+// Reserve claims the next slot for an event, however far away, and returns
+// a Reservation saying when it may happen. Cancel the Reservation to give
+// the slot back if the event won't happen after all.
+func (l *Limiter) Reserve() *Reservation {
+	r, _ := l.reserve(forever)
+	return r
+}
+
+// Wait blocks until an event may happen. It fails with the context's error
+// if the context is done first, or straight away with ErrExceedsDeadline if
+// the context's deadline comes before the event would be allowed.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	maxWait := forever
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = deadline.Sub(l.clk.Now())
+	}
+	r, ok := l.reserve(maxWait)
+	if !ok {
+		return ErrExceedsDeadline
+	}
+
+	delay := r.Delay()
+	if delay <= 0 {
+		return nil
+	}
+	timer := l.clk.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+func (l *Limiter) reserve(maxWait time.Duration) (*Reservation, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	at, ok := l.alg.take(l.clk.Now(), maxWait)
+	if !ok {
+		return nil, false
+	}
+	return &Reservation{lim: l, At: at}, true
+}
+
+// rested returns the time from which the limiter is as good as new
+func (l *Limiter) rested() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.alg.rested()
+}
+
+// Reservation is a slot claimed with Reserve
+type Reservation struct {
+	lim      *Limiter
+	At       time.Time // When the event may happen
+	canceled bool
+}
+
+// Delay returns how long to wait before the event may happen
+func (r *Reservation) Delay() time.Duration {
+	return max(r.At.Sub(r.lim.clk.Now()), 0)
+}
+
+// Cancel gives the slot back if its time hasn't come yet, so later events
+// don't have to wait for it
+func (r *Reservation) Cancel() {
+	r.lim.mu.Lock()
+	defer r.lim.mu.Unlock()
+	if r.canceled || !r.At.After(r.lim.clk.Now()) {
+		return
+	}
+	r.canceled = true
+	r.lim.alg.give(r.At)
+}
+
+// perEvent returns the time between events at perSecond events per second
+func perEvent(perSecond float64) time.Duration {
+	if perSecond <= 0 {
+		panic("ratelimit: rate must be positive")
+	}
+	return time.Duration(float64(time.Second) / perSecond)
+}
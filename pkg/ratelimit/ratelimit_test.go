@@ -0,0 +1,349 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-by-example/pkg/clock"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+// step is an action on a limiter: advance the clock, then try an event
+type step struct {
+	advance time.Duration
+	allowed bool
+}
+
+func TestAllow(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit func(clk clock.Clock) *Limiter
+		steps []step
+	}{
+		{
+			name:  "token bucket",
+			limit: func(clk clock.Clock) *Limiter { return NewTokenBucket(clk, 5, 3) },
+			steps: []step{
+				{0, true}, {0, true}, {0, true}, // Burst of 3
+				{0, false},
+				{199 * time.Millisecond, false},
+				{time.Millisecond, true}, // One token every 200ms
+				{0, false},
+				{time.Hour, true}, {0, true}, {0, true}, // Refilled to the burst, no more
+				{0, false},
+			},
+		},
+		{
+			name:  "gcra",
+			limit: func(clk clock.Clock) *Limiter { return NewGCRA(clk, 5, 3) },
+			steps: []step{
+				{0, true}, {0, true}, {0, true},
+				{0, false},
+				{199 * time.Millisecond, false},
+				{time.Millisecond, true},
+				{0, false},
+				{time.Hour, true}, {0, true}, {0, true},
+				{0, false},
+			},
+		},
+		{
+			name:  "sliding log",
+			limit: func(clk clock.Clock) *Limiter { return NewSlidingLog(clk, 3, time.Second) },
+			steps: []step{
+				{0, true},
+				{400 * time.Millisecond, true},
+				{400 * time.Millisecond, true},
+				{0, false},
+				{199 * time.Millisecond, false},
+				{time.Millisecond, true}, // The first event left the window
+				{0, false},
+				{400 * time.Millisecond, true}, // And the second
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(epoch)
+			lim := tt.limit(clk)
+			for i, s := range tt.steps {
+				clk.Advance(s.advance)
+				if got := lim.Allow(); got != s.allowed {
+					t.Fatalf("step %d at %v: got %v, want %v", i, clk.Now().Sub(epoch), got, s.allowed)
+				}
+			}
+		})
+	}
+}
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit func(clk clock.Clock) *Limiter
+	}{
+		{"token bucket", func(clk clock.Clock) *Limiter { return NewTokenBucket(clk, 5, 1) }},
+		{"gcra", func(clk clock.Clock) *Limiter { return NewGCRA(clk, 5, 1) }},
+		{"sliding log", func(clk clock.Clock) *Limiter { return NewSlidingLog(clk, 1, 200*time.Millisecond) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(epoch)
+			lim := tt.limit(clk)
+
+			var delays []time.Duration
+			var last *Reservation
+			for range 3 {
+				last = lim.Reserve()
+				delays = append(delays, last.Delay())
+			}
+			want := []time.Duration{0, 200 * time.Millisecond, 400 * time.Millisecond}
+			for i := range want {
+				if delays[i] != want[i] {
+					t.Fatalf("got delays %v, want %v", delays, want)
+				}
+			}
+
+			// Cancelling the last reservation frees its slot for the next one
+			last.Cancel()
+			last.Cancel() // Only gives back once
+			if got := lim.Reserve().Delay(); got != 400*time.Millisecond {
+				t.Errorf("after Cancel: got delay %v, want %v", got, 400*time.Millisecond)
+			}
+
+			// A reservation whose time has come can't be cancelled
+			clk.Advance(time.Second)
+			r := lim.Reserve()
+			r.Cancel()
+			if lim.Allow() {
+				t.Error("Cancel of a due reservation gave its slot back")
+			}
+		})
+	}
+}
+
+func TestWait(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	lim := NewTokenBucket(clk, 5, 1)
+
+	if err := lim.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait: %v", err)
+	}
+
+	done := make(chan error)
+	go func() { done <- lim.Wait(context.Background()) }()
+	clk.BlockUntil(1)
+	clk.Advance(199 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("Wait returned before a token was available")
+	default:
+	}
+	clk.Advance(time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatalf("second Wait: %v", err)
+	}
+
+	// Cancelling a Wait returns its token
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { done <- lim.Wait(ctx) }()
+	clk.BlockUntil(1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled Wait: got %v, want %v", err, context.Canceled)
+	}
+	if got := lim.Reserve().Delay(); got != 200*time.Millisecond {
+		t.Errorf("after cancelled Wait: got delay %v, want %v", got, 200*time.Millisecond)
+	}
+}
+
+// startWait calls Wait in a goroutine. If Wait blocks on clk, it returns
+// the channel Wait's error will be sent on; otherwise it returns the error.
+func startWait(ctx context.Context, clk *clock.Fake, lim *Limiter) (<-chan error, error) {
+	done := make(chan error, 1)
+	go func() { done <- lim.Wait(ctx) }()
+	blocked := make(chan struct{})
+	go func() {
+		clk.BlockUntil(1)
+		close(blocked)
+	}()
+	select {
+	case err := <-done:
+		return nil, err
+	case <-blocked:
+		return done, nil
+	}
+}
+
+func TestWaitDeadline(t *testing.T) {
+	// Deadlines are compared on the limiter's clock, so these fake clocks
+	// start an hour away from real time, where the context can see them
+
+	// A deadline that comes first fails without waiting
+	clk := clock.NewFake(time.Now().Add(time.Hour))
+	lim := NewSlidingLog(clk, 1, time.Second)
+	lim.Allow()
+	ctx, cancel := context.WithDeadline(context.Background(), clk.Now().Add(100*time.Millisecond))
+	defer cancel()
+	if done, err := startWait(ctx, clk, lim); done != nil || !errors.Is(err, ErrExceedsDeadline) {
+		t.Errorf("Wait past the deadline: got %v and blocked %t, want %v", err, done != nil, ErrExceedsDeadline)
+	}
+
+	// A deadline two hours away on the limiter's clock, but only one in
+	// real time, leaves room for an event allowed in an hour and a half
+	clk = clock.NewFake(time.Now().Add(-time.Hour))
+	lim = NewSlidingLog(clk, 1, 90*time.Minute)
+	lim.Allow()
+	ctx, cancel = context.WithDeadline(context.Background(), clk.Now().Add(2*time.Hour))
+	defer cancel()
+	done, err := startWait(ctx, clk, lim)
+	if done == nil {
+		t.Fatalf("Wait within the deadline: got %v before the event was allowed", err)
+	}
+	clk.Advance(90 * time.Minute)
+	if err := <-done; err != nil {
+		t.Errorf("Wait within the deadline: got %v, want nil", err)
+	}
+}
+
+func TestSharedLimiter(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	lim := NewGCRA(clk, 1, 10)
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if lim.Allow() {
+					allowed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := allowed.Load(); got != 10 {
+		t.Errorf("got %d events allowed across goroutines, want the burst of 10", got)
+	}
+}
+
+func TestKeyed(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	keyed := NewKeyed[string](clk, time.Minute, func() *Limiter {
+		return NewTokenBucket(clk, 1, 2)
+	})
+
+	// Keys don't share tokens
+	for _, key := range []string{"alice", "alice", "bob", "bob"} {
+		if !keyed.Allow(key) {
+			t.Fatalf("Allow(%q) within the burst: got false", key)
+		}
+	}
+	if keyed.Allow("alice") {
+		t.Fatal("Allow(alice) beyond the burst: got true")
+	}
+	if got := keyed.Len(); got != 2 {
+		t.Fatalf("got %d limiters, want 2", got)
+	}
+
+	// Both buckets refill after 2s; bob is used again meanwhile
+	clk.Advance(30 * time.Second)
+	keyed.Allow("bob")
+
+	// alice has been rested for a minute, bob for less
+	clk.Advance(32 * time.Second)
+	keyed.Evict()
+	if got := keyed.Len(); got != 1 {
+		t.Fatalf("got %d limiters after eviction, want 1", got)
+	}
+
+	// A limiter that's still recovering is kept however long ago it was used
+	slow := NewKeyed[string](clk, time.Second, func() *Limiter {
+		return NewTokenBucket(clk, 0.01, 1) // 100s per token
+	})
+	slow.Allow("carol")
+	clk.Advance(50 * time.Second)
+	slow.Evict()
+	if slow.Len() != 1 {
+		t.Fatal("evicted a limiter that had not recovered")
+	}
+	clk.Advance(51 * time.Second)
+	slow.Allow("dave") // Sweeps as a side effect
+	if got := slow.Len(); got != 1 {
+		t.Errorf("got %d limiters, want only dave", got)
+	}
+}
+
+func TestKeyedGetBeforeSweep(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	keyed := NewKeyed[string](clk, time.Minute, func() *Limiter {
+		return NewTokenBucket(clk, 0.01, 1)
+	})
+
+	// alice has been at rest for almost a minute when a caller gets her
+	// limiter, and a sweep runs before the caller uses it
+	keyed.Get("alice")
+	clk.Advance(59 * time.Second)
+	held := keyed.Get("alice")
+	clk.Advance(time.Second)
+	keyed.Evict()
+
+	if !held.Allow() {
+		t.Fatal("Allow on the held limiter: got false")
+	}
+	if keyed.Allow("alice") {
+		t.Error("alice got a second token from a new limiter")
+	}
+}
+
+func TestKeyedConcurrentSweep(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	keyed := NewKeyed[string](clk, time.Second, func() *Limiter {
+		return NewTokenBucket(clk, 1, 1)
+	})
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if keyed.Allow("alice") {
+					allowed.Add(1)
+				}
+				runtime.Gosched()
+			}
+		}()
+	}
+
+	// Sweep while the workers take tokens, for 10s in all
+	for i := 0; i < 100; i++ {
+		clk.Advance(100 * time.Millisecond)
+		keyed.Evict()
+		keyed.Get("bob") // Sweeps too, once a second
+		runtime.Gosched()
+	}
+	close(stop)
+	wg.Wait()
+
+	// One token to start with and one per second
+	if got := allowed.Load(); got > 11 {
+		t.Errorf("got %d events allowed in 10s, want at most 11", got)
+	}
+}
@@ -0,0 +1,268 @@
+// Package ringbuf is a bounded FIFO queue that works like a buffered
+// channel, but lets the sender choose what happens when it is full.
+//
+// A buffered channel can only make the sender wait. A Buffer can also
+// overwrite its oldest value, discard the new one or fail, so a producer
+// such as a telemetry exporter never waits for a consumer that fell
+// behind. Stats tell how far behind: the values dropped so far and the
+// most values ever held at once.
+//
+//	buf := ringbuf.New[Sample](ringbuf.Options{Capacity: 1024, Policy: ringbuf.DropOldest})
+//	go func() {
+//		for s := range buf.All(ctx) {
+//			export(s)
+//		}
+//	}()
+//	buf.Send(ctx, sample) // Never waits with DropOldest
+package ringbuf
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+
+	"go-by-example/internal/broadcast"
+)
+
+var (
+	// ErrClosed is returned by Send after Close, and by Recv once the
+	// buffer is closed and empty
+	ErrClosed = errors.New("ringbuf: closed")
+	// ErrFull is returned by Send with the Error policy, and by TrySend,
+	// when there is no room
+	ErrFull = errors.New("ringbuf: full")
+)
+
+// Policy decides what Send does when the buffer is full
+type Policy int
+
+const (
+	// Block makes Send wait until there is room or its context is done
+	Block Policy = iota
+	// DropOldest overwrites the oldest value to make room
+	DropOldest
+	// DropNewest discards the value being sent
+	DropNewest
+	// Error makes Send return ErrFull
+	Error
+)
+
+func (p Policy) String() string {
+	switch p {
+	case Block:
+		return "block"
+	case DropOldest:
+		return "drop oldest"
+	case DropNewest:
+		return "drop newest"
+	case Error:
+		return "error"
+	}
+	return "unknown"
+}
+
+// Options configures a Buffer
+type Options struct {
+	Capacity int    // Values held at most, at least 1
+	Policy   Policy // What Send does when the buffer is full
+}
+
+// Stats describes a Buffer at one moment
+type Stats struct {
+	Len       int    // Values held now
+	Cap       int    // Values held at most
+	Sent      uint64 // Values accepted by Send and TrySend
+	Received  uint64 // Values taken by Recv and TryRecv
+	Dropped   uint64 // Values lost to a full buffer: overwritten, discarded or refused
+	HighWater int    // Most values held at once
+}
+
+// Buffer is a bounded FIFO queue. It is safe for concurrent use.
+type Buffer[T any] struct {
+	mu      sync.Mutex
+	policy  Policy
+	values  []T
+	head    int // Index of the oldest value
+	n       int
+	closed  bool
+	changed broadcast.Signal
+
+	sent, received, dropped uint64
+	highWater               int
+}
+
+// New returns an empty Buffer
+func New[T any](opts Options) *Buffer[T] {
+	return &Buffer[T]{
+		policy: opts.Policy,
+		values: make([]T, max(opts.Capacity, 1)),
+	}
+}
+
+// Send adds v according to the policy. Only Block waits; it returns the
+// error of ctx if ctx is done first.
+func (b *Buffer[T]) Send(ctx context.Context, v T) error {
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return ErrClosed
+		}
+		if b.n < len(b.values) {
+			b.push(v)
+			b.mu.Unlock()
+			return nil
+		}
+
+		switch b.policy {
+		case DropOldest:
+			b.pop()
+			b.dropped++
+			b.push(v)
+			b.mu.Unlock()
+			return nil
+		case DropNewest:
+			b.dropped++
+			b.mu.Unlock()
+			return nil
+		case Error:
+			b.dropped++
+			b.mu.Unlock()
+			return ErrFull
+		}
+		changed := b.changed.Chan()
+		b.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// TrySend adds v if there is room, or returns ErrFull. It never waits nor
+// drops buffered values, whatever the policy.
+func (b *Buffer[T]) TrySend(v T) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.closed:
+		return ErrClosed
+	case b.n == len(b.values):
+		b.dropped++
+		return ErrFull
+	}
+	b.push(v)
+	return nil
+}
+
+// Recv removes the oldest value, waiting until there is one or ctx is
+// done. It returns ErrClosed once the buffer is closed and empty.
+func (b *Buffer[T]) Recv(ctx context.Context) (T, error) {
+	for {
+		b.mu.Lock()
+		if b.n > 0 {
+			v := b.pop()
+			b.received++
+			b.mu.Unlock()
+			return v, nil
+		}
+		if b.closed {
+			b.mu.Unlock()
+			var zero T
+			return zero, ErrClosed
+		}
+		changed := b.changed.Chan()
+		b.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// TryRecv removes the oldest value if there is one
+func (b *Buffer[T]) TryRecv() (T, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.n == 0 {
+		var zero T
+		return zero, false
+	}
+	b.received++
+	return b.pop(), true
+}
+
+// All returns an iterator that receives values until the buffer is closed
+// and empty or ctx is done, like ranging over a channel
+func (b *Buffer[T]) All(ctx context.Context) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			v, err := b.Recv(ctx)
+			if err != nil || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Close stops Send and lets Recv drain the remaining values
+func (b *Buffer[T]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		b.changed.Notify()
+	}
+}
+
+// Len returns the number of values held
+func (b *Buffer[T]) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.n
+}
+
+// Cap returns the number of values held at most
+func (b *Buffer[T]) Cap() int {
+	return len(b.values)
+}
+
+// Stats returns the current length and the counters so far
+func (b *Buffer[T]) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return Stats{
+		Len:       b.n,
+		Cap:       len(b.values),
+		Sent:      b.sent,
+		Received:  b.received,
+		Dropped:   b.dropped,
+		HighWater: b.highWater,
+	}
+}
+
+// push appends v. b.mu must be held and the buffer not full.
+func (b *Buffer[T]) push(v T) {
+	b.values[(b.head+b.n)%len(b.values)] = v
+	b.n++
+	b.sent++
+	b.highWater = max(b.highWater, b.n)
+	b.changed.Notify()
+}
+
+// pop removes the oldest value. b.mu must be held and the buffer not empty.
+func (b *Buffer[T]) pop() T {
+	var zero T
+	v := b.values[b.head]
+	b.values[b.head] = zero // Don't keep it reachable
+	b.head = (b.head + 1) % len(b.values)
+	b.n--
+	b.changed.Notify()
+	return v
+}
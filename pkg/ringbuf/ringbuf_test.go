@@ -0,0 +1,224 @@
+package ringbuf
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// drain takes everything left in b without waiting
+func drain[T any](b *Buffer[T]) []T {
+	var values []T
+	for {
+		v, ok := b.TryRecv()
+		if !ok {
+			return values
+		}
+		values = append(values, v)
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	tests := []struct {
+		policy   Policy
+		expected []int
+		sent     uint64
+		errs     int // Sends that failed with ErrFull
+	}{
+		{policy: DropOldest, expected: []int{3, 4, 5}, sent: 5},
+		{policy: DropNewest, expected: []int{1, 2, 3}, sent: 3},
+		{policy: Error, expected: []int{1, 2, 3}, sent: 3, errs: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			b := New[int](Options{Capacity: 3, Policy: tt.policy})
+			errs := 0
+			for i := 1; i <= 5; i++ {
+				err := b.Send(context.Background(), i)
+				switch {
+				case errors.Is(err, ErrFull):
+					errs++
+				case err != nil:
+					t.Fatal(err)
+				}
+			}
+			if errs != tt.errs {
+				t.Errorf("got %d ErrFull, want %d", errs, tt.errs)
+			}
+
+			want := Stats{Len: 3, Cap: 3, Sent: tt.sent, Dropped: 2, HighWater: 3}
+			if got := b.Stats(); got != want {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+			if got := drain(b); !slices.Equal(got, tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWrapAround(t *testing.T) {
+	b := New[int](Options{Capacity: 3, Policy: DropOldest})
+	var got []int
+	for i := 1; i <= 10; i++ {
+		b.Send(context.Background(), i)
+		if i%2 == 0 {
+			v, _ := b.TryRecv()
+			got = append(got, v)
+		}
+	}
+	got = append(got, drain(b)...)
+
+	// Once full, each send pushes out the oldest value
+	want := []int{1, 2, 4, 6, 8, 9, 10}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if s := b.Stats(); s.Dropped != 3 || s.HighWater != 3 || s.Received != 7 {
+		t.Errorf("got %+v, want 3 dropped, high-water 3 and 7 received", s)
+	}
+}
+
+func TestBlock(t *testing.T) {
+	b := New[int](Options{Capacity: 1})
+	b.Send(context.Background(), 1)
+
+	// A full buffer makes Send wait, until its context gives up...
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Send(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := b.TrySend(2); !errors.Is(err, ErrFull) {
+		t.Errorf("TrySend: got %v, want %v", err, ErrFull)
+	}
+
+	// ...or a receiver makes room
+	sent := make(chan error)
+	go func() { sent <- b.Send(context.Background(), 3) }()
+	if v, err := b.Recv(context.Background()); v != 1 || err != nil {
+		t.Errorf("got %d, %v, want 1", v, err)
+	}
+	if err := <-sent; err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+
+	// Close releases a blocked sender, or makes a late one fail
+	go func() { sent <- b.Send(context.Background(), 4) }()
+	b.Close()
+	if err := <-sent; !errors.Is(err, ErrClosed) {
+		t.Errorf("got %v, want %v", err, ErrClosed)
+	}
+	if got := drain(b); !slices.Equal(got, []int{3}) {
+		t.Errorf("got %v, want [3]", got)
+	}
+}
+
+func TestRecv(t *testing.T) {
+	b := New[string](Options{Capacity: 2})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := b.Recv(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("empty buffer: got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	received := make(chan string)
+	go func() {
+		v, _ := b.Recv(context.Background())
+		received <- v
+	}()
+	b.Send(context.Background(), "a")
+	if v := <-received; v != "a" {
+		t.Errorf("got %q, want %q", v, "a")
+	}
+
+	// Closing keeps the values already sent
+	b.Send(context.Background(), "b")
+	b.Close()
+	if err := b.Send(context.Background(), "c"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Send after Close: got %v, want %v", err, ErrClosed)
+	}
+	if v, err := b.Recv(context.Background()); v != "b" || err != nil {
+		t.Errorf("got %q, %v, want %q", v, err, "b")
+	}
+	if _, err := b.Recv(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("got %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestAll(t *testing.T) {
+	b := New[int](Options{Capacity: 4})
+	for i := 1; i <= 4; i++ {
+		b.Send(context.Background(), i)
+	}
+	b.Close()
+
+	var got []int
+	for v := range b.All(context.Background()) {
+		if v == 3 {
+			break
+		}
+		got = append(got, v)
+	}
+	if !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("got %v, want [1 2]", got)
+	}
+	if got := slices.Collect(b.All(context.Background())); !slices.Equal(got, []int{4}) {
+		t.Errorf("after break: got %v, want [4]", got)
+	}
+}
+
+func TestConcurrentSenders(t *testing.T) {
+	tests := []struct {
+		policy Policy
+	}{
+		{policy: Block},
+		{policy: DropOldest},
+		{policy: DropNewest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			b := New[int](Options{Capacity: 8, Policy: tt.policy})
+			received := make(chan int)
+			go func() {
+				n := 0
+				for range b.All(context.Background()) {
+					n++
+				}
+				received <- n
+			}()
+
+			var wg sync.WaitGroup
+			for range 4 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range 250 {
+						b.Send(context.Background(), i)
+					}
+				}()
+			}
+			wg.Wait()
+			b.Close()
+
+			// Every value was either received or counted as dropped
+			n := <-received
+			s := b.Stats()
+			if uint64(n) != s.Received || s.Received+s.Dropped != 1000 {
+				t.Errorf("got %d received and %+v, want 1000 in all", n, s)
+			}
+			if tt.policy == Block && s.Dropped != 0 {
+				t.Errorf("got %d dropped, want 0", s.Dropped)
+			}
+			if s.HighWater > 8 {
+				t.Errorf("got high-water %d, want at most 8", s.HighWater)
+			}
+		})
+	}
+}
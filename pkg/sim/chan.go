@@ -0,0 +1,265 @@
+package sim
+
+import "strings"
+
+// Chan is a simulated channel. It behaves like a built-in channel of the
+// same capacity, except that every operation lets the scheduler switch
+// goroutines first.
+type Chan[T any] struct {
+	c *core
+}
+
+// core is the untyped state of a channel, so Select can mix channels of
+// different element types
+type core struct {
+	s      *Sim
+	name   string
+	cap    int
+	buf    []any
+	closed bool
+	recvq  []*waiter
+	sendq  []*waiter
+}
+
+// waiter is a goroutine blocked on one case of a Select
+type waiter struct {
+	g     *goroutine
+	sel   *selection
+	index int  // Of the case in the Select
+	val   any  // Value to send, or the value received
+	ok    bool // Value received, or sent, rather than found closed
+}
+
+// selection is shared by the waiters of one blocked Select; the first
+// case to complete wins and the others are dropped from their queues
+type selection struct {
+	won *waiter
+}
+
+// NewChan returns a channel with room for capacity values, unbuffered if
+// 0. The name shows up in traces and deadlock reports.
+func NewChan[T any](s *Sim, name string, capacity int) *Chan[T] {
+	return &Chan[T]{c: &core{s: s, name: name, cap: capacity}}
+}
+
+// Send sends v, blocking until there is room or a receiver. It panics if
+// the channel is closed.
+func (c *Chan[T]) Send(v T) {
+	c.c.s.Select(c.OnSend(v, nil))
+}
+
+// Recv receives a value. ok is false if the channel is closed and empty.
+func (c *Chan[T]) Recv() (v T, ok bool) {
+	c.c.s.Select(c.OnRecv(func(got T, gotOK bool) { v, ok = got, gotOK }))
+	return v, ok
+}
+
+// Close closes the channel, waking every blocked receiver. It panics if
+// the channel is already closed.
+func (c *Chan[T]) Close() {
+	s := c.c.s
+	g := s.running("close " + c.c.name)
+	s.ready(g)
+	s.park(g)
+
+	if c.c.closed {
+		panic("sim: close of closed channel " + c.c.name)
+	}
+	c.c.closed = true
+	for _, q := range [][]*waiter{c.c.recvq, c.c.sendq} {
+		for _, w := range q {
+			if w.sel.won == nil {
+				c.c.complete(w, nil, false)
+			}
+		}
+	}
+	c.c.recvq, c.c.sendq = nil, nil
+}
+
+// Len returns the number of buffered values
+func (c *Chan[T]) Len() int {
+	return len(c.c.buf)
+}
+
+// OnRecv returns a Select case receiving from the channel, which calls fn,
+// if not nil, with what it received
+func (c *Chan[T]) OnRecv(fn func(v T, ok bool)) Case {
+	return Case{c: c.c, handle: func(v any, ok bool) {
+		if fn == nil {
+			return
+		}
+		var t T
+		if ok {
+			t = v.(T)
+		}
+		fn(t, ok)
+	}}
+}
+
+// OnSend returns a Select case sending v, which calls fn, if not nil,
+// once v is sent
+func (c *Chan[T]) OnSend(v T, fn func()) Case {
+	return Case{c: c.c, send: true, val: v, handle: func(any, bool) {
+		if fn != nil {
+			fn()
+		}
+	}}
+}
+
+// Case is one case of a Select, made by Chan.OnRecv, Chan.OnSend or
+// Default
+type Case struct {
+	c      *core // nil for the default case
+	send   bool
+	val    any
+	handle func(v any, ok bool)
+}
+
+// Default returns the Select case taken when no other case is ready
+func Default(fn func()) Case {
+	return Case{handle: func(any, bool) {
+		if fn != nil {
+			fn()
+		}
+	}}
+}
+
+// Select waits until one of the cases can proceed, completes it and calls
+// its function, like a select statement, and returns the index of the
+// case. If several are ready, the seeded scheduler picks one.
+func (s *Sim) Select(cases ...Case) int {
+	g := s.running(describe(cases))
+	s.ready(g)
+	s.park(g)
+
+	var ready []int
+	def := -1
+	for i, c := range cases {
+		switch {
+		case c.c == nil:
+			def = i
+		case c.send && c.c.canSend(), !c.send && c.c.canRecv():
+			ready = append(ready, i)
+		}
+	}
+	if len(ready) > 0 {
+		i := ready[s.rng.IntN(len(ready))]
+		c := cases[i]
+		if c.send {
+			c.c.send(c.val)
+			c.handle(nil, true)
+		} else {
+			c.handle(c.c.recv())
+		}
+		return i
+	}
+	if def >= 0 {
+		cases[def].handle(nil, false)
+		return def
+	}
+
+	sel := &selection{}
+	for i, c := range cases {
+		w := &waiter{g: g, sel: sel, index: i, val: c.val}
+		if c.send {
+			c.c.sendq = append(c.c.sendq, w)
+		} else {
+			c.c.recvq = append(c.c.recvq, w)
+		}
+	}
+	s.park(g)
+
+	w := sel.won
+	c := cases[w.index]
+	if c.send && !w.ok {
+		panic("sim: send on closed channel " + c.c.name)
+	}
+	c.handle(w.val, w.ok)
+	return w.index
+}
+
+// describe names the operation of a Select for traces and deadlock reports
+func describe(cases []Case) string {
+	var names []string
+	for _, c := range cases {
+		if c.c != nil {
+			names = append(names, c.c.name)
+		}
+	}
+	switch {
+	case len(cases) == 1 && cases[0].c != nil && cases[0].send:
+		return "send on " + cases[0].c.name
+	case len(cases) == 1 && cases[0].c != nil:
+		return "recv on " + cases[0].c.name
+	}
+	return "select on " + strings.Join(names, ", ")
+}
+
+func (c *core) canSend() bool {
+	return c.closed || len(c.buf) < c.cap || c.waiting(&c.recvq) != nil
+}
+
+func (c *core) canRecv() bool {
+	return c.closed || len(c.buf) > 0 || c.waiting(&c.sendq) != nil
+}
+
+// send completes a send that canSend allowed
+func (c *core) send(v any) {
+	if c.closed {
+		panic("sim: send on closed channel " + c.name)
+	}
+	if w := c.waiting(&c.recvq); w != nil {
+		c.complete(w, v, true)
+		return
+	}
+	c.buf = append(c.buf, v)
+}
+
+// trySend sends v if it can without blocking, for timers
+func (c *core) trySend(v any) {
+	switch {
+	case c.closed:
+	case c.waiting(&c.recvq) != nil:
+		c.complete(c.waiting(&c.recvq), v, true)
+	case len(c.buf) < c.cap:
+		c.buf = append(c.buf, v)
+	}
+}
+
+// recv completes a receive that canRecv allowed
+func (c *core) recv() (any, bool) {
+	if len(c.buf) > 0 {
+		v := c.buf[0]
+		c.buf = c.buf[1:]
+		if w := c.waiting(&c.sendq); w != nil {
+			c.buf = append(c.buf, w.val)
+			c.complete(w, w.val, true)
+		}
+		return v, true
+	}
+	if w := c.waiting(&c.sendq); w != nil {
+		c.complete(w, w.val, true)
+		return w.val, true
+	}
+	return nil, false
+}
+
+// waiting returns the first waiter in q whose Select is still blocked,
+// dropping those that another case already won
+func (c *core) waiting(q *[]*waiter) *waiter {
+	for len(*q) > 0 && (*q)[0].sel.won != nil {
+		*q = (*q)[1:]
+	}
+	if len(*q) == 0 {
+		return nil
+	}
+	return (*q)[0]
+}
+
+// complete finishes the blocked Select of w on this case and wakes it. The
+// waiter stays queued until waiting drops it.
+func (c *core) complete(w *waiter, v any, ok bool) {
+	w.sel.won = w
+	w.val, w.ok = v, ok
+	c.s.ready(w.g)
+}
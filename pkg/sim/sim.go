@@ -0,0 +1,286 @@
+// Package sim runs concurrent code deterministically, so that bugs which
+// depend on scheduling can be found and then reproduced.
+//
+// Code under simulation uses the channels, Select, timers and tickers of a
+// Sim instead of the built-in ones, and starts goroutines with Sim.Go. Only
+// one simulated goroutine runs at a time. Every channel operation is a
+// scheduling point where a scheduler, seeded by Options.Seed, picks which
+// goroutine continues, and Select picks among ready cases with the same
+// seed. Time is virtual: it stands still while any goroutine can run, and
+// jumps to the next timer once they are all blocked, so a one-hour timeout
+// takes no time at all. Timers that are due fire at a point the scheduler
+// picks too, before or after the goroutines that are ready to run.
+//
+// The same seed always gives the same schedule. Explore runs a function
+// under many seeds and reports the first one that fails, which can be
+// replayed with Options.Trace to see what happened:
+//
+//	err := sim.Explore(sim.Options{}, 1000, func(s *sim.Sim) error {
+//		data := sim.NewChan[int](s, "data", 0)
+//		s.Go("producer", func() { ... data.Send(1) ... })
+//		...
+//		return nil // Or an error if the outcome is wrong
+//	})
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrDeadlock is returned by Run when every goroutine is blocked and no
+	// timer is left to wake one of them
+	ErrDeadlock = errors.New("sim: deadlock")
+	// ErrStepLimit is returned by Run after Options.MaxSteps scheduling
+	// steps, which usually means goroutines or tickers that never stop
+	ErrStepLimit = errors.New("sim: step limit reached")
+)
+
+// Options configures a Sim
+type Options struct {
+	Seed     int64     // Seeds the scheduler; the same seed gives the same schedule
+	Start    time.Time // Virtual time when Run starts, the Unix epoch if zero
+	MaxSteps int       // Scheduling steps before Run gives up, 100000 if 0
+
+	// Trace, if set, is called each time a goroutine is scheduled, with
+	// the operation it is about to do, such as "recv on data"
+	Trace func(now time.Time, goroutine, op string)
+}
+
+// PanicError is returned by Run when a simulated goroutine panicked
+type PanicError struct {
+	Goroutine string // Name passed to Go, or "main"
+	Value     any    // What was passed to panic
+	Stack     []byte // Stack trace of the goroutine at the panic
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("sim: goroutine %s panicked: %v", e.Goroutine, e.Value)
+}
+
+// Unwrap returns the panic value if it is an error, so errors.Is and
+// errors.As see through a panic(err)
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Failure is returned by Explore for the first seed whose run failed
+type Failure struct {
+	Seed int64
+	Err  error
+}
+
+func (f *Failure) Error() string {
+	return fmt.Sprintf("sim: seed %d: %v", f.Seed, f.Err)
+}
+
+func (f *Failure) Unwrap() error {
+	return f.Err
+}
+
+// Sim is one simulated run. Its methods, and those of its channels and
+// timers, may only be called from the goroutines it runs.
+type Sim struct {
+	opts Options
+	rng  *rand.Rand
+	now  time.Time
+
+	all      []*goroutine // Started and not yet returned, in start order
+	runnable []*goroutine
+	current  *goroutine
+	timers   []*timer
+	steps    int
+	yield    chan struct{} // The current goroutine blocked or returned
+	failure  error         // The first panic
+}
+
+// goroutine is a simulated goroutine, run by a real one that only runs
+// while the scheduler waits for it
+type goroutine struct {
+	name   string
+	op     string        // What it is doing or waiting for
+	wake   chan struct{} // Hands control from the scheduler to the goroutine
+	killed bool          // Run returned; exit instead of continuing
+}
+
+// New returns a Sim for a single call to Run
+func New(opts Options) *Sim {
+	if opts.Start.IsZero() {
+		opts.Start = time.Unix(0, 0).UTC()
+	}
+	if opts.MaxSteps <= 0 {
+		opts.MaxSteps = 100000
+	}
+	seed := uint64(opts.Seed)
+	return &Sim{
+		opts:  opts,
+		rng:   rand.New(rand.NewPCG(seed, seed)),
+		now:   opts.Start,
+		yield: make(chan struct{}),
+	}
+}
+
+// Explore calls fn in a new Sim for each seed from 1 to runs, with opts
+// otherwise unchanged, and returns a *Failure for the first run that
+// returned an error, or nil if none did
+func Explore(opts Options, runs int, fn func(s *Sim) error) error {
+	for seed := int64(1); seed <= int64(runs); seed++ {
+		opts.Seed = seed
+		s := New(opts)
+		if err := s.Run(func() error { return fn(s) }); err != nil {
+			return &Failure{Seed: seed, Err: err}
+		}
+	}
+	return nil
+}
+
+// Run runs main as the simulated goroutine "main" and returns its error
+// once it returns. Like a Go program, the simulation ends with main:
+// goroutines still blocked are abandoned. Run returns ErrDeadlock if main
+// can never continue, a *PanicError if any goroutine panicked, and
+// ErrStepLimit if the simulation doesn't end within Options.MaxSteps.
+func (s *Sim) Run(main func() error) error {
+	var result error
+	finished := false
+	s.Go("main", func() {
+		result = main()
+		finished = true
+	})
+
+	var err error
+	for !finished && s.failure == nil {
+		s.steps++
+		if s.steps > s.opts.MaxSteps {
+			err = fmt.Errorf("%w: %d steps", ErrStepLimit, s.opts.MaxSteps)
+			break
+		}
+		due := s.due()
+		if len(s.runnable) == 0 && len(due) == 0 {
+			if !s.advance() {
+				err = fmt.Errorf("%w: %s", ErrDeadlock, s.blocked())
+				break
+			}
+			continue
+		}
+
+		// Firing a due timer is one more choice next to the goroutines
+		i := s.rng.IntN(len(s.runnable) + len(due))
+		if i >= len(s.runnable) {
+			s.fire(due[i-len(s.runnable)])
+			continue
+		}
+		g := s.runnable[i]
+		s.runnable = append(s.runnable[:i], s.runnable[i+1:]...)
+		if s.opts.Trace != nil {
+			s.opts.Trace(s.now, g.name, g.op)
+		}
+		s.current = g
+		g.wake <- struct{}{}
+		<-s.yield
+		s.current = nil
+	}
+
+	// Unblock the abandoned goroutines so their real goroutines exit
+	for len(s.all) > 0 {
+		g := s.all[0]
+		g.killed = true
+		s.current = g
+		g.wake <- struct{}{}
+		<-s.yield
+	}
+	s.current = nil
+	switch {
+	case s.failure != nil:
+		return s.failure
+	case err != nil:
+		return err
+	}
+	return result
+}
+
+// Go starts fn in a new simulated goroutine. The name shows up in traces,
+// deadlock reports and panics.
+func (s *Sim) Go(name string, fn func()) {
+	g := &goroutine{name: name, op: "start", wake: make(chan struct{})}
+	s.all = append(s.all, g)
+	s.runnable = append(s.runnable, g)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil && !g.killed && s.failure == nil {
+				s.failure = &PanicError{Goroutine: name, Value: r, Stack: debug.Stack()}
+			}
+			for i, other := range s.all {
+				if other == g {
+					s.all = append(s.all[:i], s.all[i+1:]...)
+					break
+				}
+			}
+			s.yield <- struct{}{}
+		}()
+		<-g.wake
+		if g.killed {
+			return
+		}
+		fn()
+	}()
+}
+
+// Now returns the virtual time
+func (s *Sim) Now() time.Time {
+	return s.now
+}
+
+// Yield lets the scheduler run another goroutine, like runtime.Gosched
+func (s *Sim) Yield() {
+	g := s.running("yield")
+	s.runnable = append(s.runnable, g)
+	s.park(g)
+}
+
+// running returns the current goroutine, which is about to do op
+func (s *Sim) running(op string) *goroutine {
+	g := s.current
+	if g == nil {
+		panic("sim: " + op + " outside a simulated goroutine")
+	}
+	if g.killed {
+		runtime.Goexit() // A deferred call of an abandoned goroutine
+	}
+	g.op = op
+	return g
+}
+
+// park hands control back to the scheduler until g is woken, which it is
+// only if something made it runnable first
+func (s *Sim) park(g *goroutine) {
+	s.yield <- struct{}{}
+	<-g.wake
+	if g.killed {
+		runtime.Goexit()
+	}
+}
+
+// ready makes a blocked goroutine runnable again
+func (s *Sim) ready(g *goroutine) {
+	s.runnable = append(s.runnable, g)
+}
+
+// blocked describes what each goroutine is waiting for
+func (s *Sim) blocked() string {
+	var b strings.Builder
+	for i, g := range s.all {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s blocked in %s", g.name, g.op)
+	}
+	return b.String()
+}
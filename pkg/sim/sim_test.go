@@ -0,0 +1,381 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go-by-example/internal/leaktest"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+var errBoom = errors.New("boom")
+
+// run runs main in a new Sim with the given seed
+func run(seed int64, main func(s *Sim) error) error {
+	s := New(Options{Seed: seed, Start: epoch})
+	return s.Run(func() error { return main(s) })
+}
+
+func TestChannels(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		close    bool
+		expected []string
+	}{
+		{name: "unbuffered", capacity: 0, expected: []string{"1 true", "2 true", "3 true"}},
+		{name: "buffered", capacity: 2, expected: []string{"1 true", "2 true", "3 true"}},
+		{name: "closed", capacity: 2, close: true, expected: []string{"1 true", "2 true", "3 true", "0 false"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaktest.Check(t)
+			for seed := range int64(20) {
+				var got []string
+				err := run(seed, func(s *Sim) error {
+					ch := NewChan[int](s, "ch", tt.capacity)
+					s.Go("sender", func() {
+						for i := 1; i <= 3; i++ {
+							ch.Send(i)
+						}
+						if tt.close {
+							ch.Close()
+						}
+					})
+					for range tt.expected {
+						v, ok := ch.Recv()
+						got = append(got, fmt.Sprint(v, ok))
+					}
+					return nil
+				})
+				if err != nil {
+					t.Fatalf("seed %d: %v", seed, err)
+				}
+				if !slices.Equal(got, tt.expected) {
+					t.Errorf("seed %d: got %v, want %v", seed, got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestVirtualTime(t *testing.T) {
+	leaktest.Check(t)
+	start := time.Now()
+	var woke []time.Duration
+	err := run(1, func(s *Sim) error {
+		done := NewChan[bool](s, "done", 0)
+		for _, d := range []time.Duration{time.Hour, time.Minute} {
+			s.Go("sleeper", func() {
+				s.Sleep(d)
+				woke = append(woke, s.Now().Sub(epoch))
+				done.Send(true)
+			})
+		}
+		done.Recv()
+		done.Recv()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []time.Duration{time.Minute, time.Hour}; !slices.Equal(woke, want) {
+		t.Errorf("got %v, want %v", woke, want)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("an hour of virtual time took %v", elapsed)
+	}
+}
+
+func TestTimerStopAndReset(t *testing.T) {
+	leaktest.Check(t)
+	err := run(1, func(s *Sim) error {
+		timer := s.NewTimer(time.Second)
+		if !timer.Reset(500 * time.Millisecond) {
+			return errors.New("Reset of a pending timer should report true")
+		}
+		if got, _ := timer.C.Recv(); !got.Equal(epoch.Add(500 * time.Millisecond)) {
+			return fmt.Errorf("fired at %v, want 500ms after the start", got)
+		}
+		if timer.Stop() {
+			return errors.New("Stop of a fired timer should report false")
+		}
+
+		// A stopped timer never fires, so only the second one wakes main
+		stopped := s.NewTimer(time.Second)
+		stopped.Stop()
+		var fired int
+		s.Select(
+			stopped.C.OnRecv(func(time.Time, bool) { fired = 1 }),
+			s.After(2*time.Second).OnRecv(func(time.Time, bool) { fired = 2 }),
+		)
+		if fired != 2 {
+			return errors.New("stopped timer fired")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		main     func(s *Sim) error
+		expected error
+		message  string
+	}{
+		{
+			name: "deadlock",
+			main: func(s *Sim) error {
+				results := NewChan[int](s, "results", 0)
+				s.Go("worker", func() { NewChan[int](s, "jobs", 0).Recv() })
+				results.Recv()
+				return nil
+			},
+			expected: ErrDeadlock,
+			message:  "sim: deadlock: main blocked in recv on results, worker blocked in recv on jobs",
+		},
+		{
+			name: "panic",
+			main: func(s *Sim) error {
+				ch := NewChan[int](s, "ch", 0)
+				ch.Close()
+				ch.Close()
+				return nil
+			},
+			message: "sim: goroutine main panicked: sim: close of closed channel ch",
+		},
+		{
+			name: "step limit",
+			main: func(s *Sim) error {
+				ticker := s.NewTicker(time.Second)
+				defer ticker.Stop()
+				NewChan[int](s, "never", 0).Recv()
+				return nil
+			},
+			expected: ErrStepLimit,
+			message:  "sim: step limit reached: 100000 steps",
+		},
+		{
+			name:     "main's error",
+			main:     func(s *Sim) error { return errBoom },
+			expected: errBoom,
+			message:  "boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaktest.Check(t)
+			err := run(1, tt.main)
+			if tt.expected != nil && !errors.Is(err, tt.expected) {
+				t.Errorf("got %v, want %v", err, tt.expected)
+			}
+			if err == nil || err.Error() != tt.message {
+				t.Errorf("got %v, want %q", err, tt.message)
+			}
+		})
+	}
+}
+
+func TestSameSeedSameSchedule(t *testing.T) {
+	leaktest.Check(t)
+	trace := func(seed int64) string {
+		var b strings.Builder
+		s := New(Options{Seed: seed, Start: epoch, Trace: func(now time.Time, g, op string) {
+			fmt.Fprintf(&b, "%v %s: %s\n", now.Sub(epoch), g, op)
+		}})
+		err := s.Run(func() error { return multipleTickers(s, nil) })
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b.String()
+	}
+
+	first := trace(7)
+	if again := trace(7); again != first {
+		t.Errorf("seed 7 scheduled differently the second time:\n%s\nthen\n%s", first, again)
+	}
+	if other := trace(8); other == first {
+		t.Error("seeds 7 and 8 gave the same schedule")
+	}
+}
+
+// multipleTickers is the loop of the multiple tickers example in
+// 14-timers-tickers, reporting how often each ticker fired before done
+func multipleTickers(s *Sim, counts *[2]int) error {
+	ticker1 := s.NewTicker(500 * time.Millisecond)
+	ticker2 := s.NewTicker(800 * time.Millisecond)
+	defer ticker1.Stop()
+	defer ticker2.Stop()
+
+	done := NewChan[bool](s, "done", 0)
+	s.Go("timeout", func() {
+		s.Sleep(2 * time.Second)
+		done.Send(true)
+	})
+
+	var n [2]int
+	for {
+		stop := false
+		s.Select(
+			ticker1.C.OnRecv(func(time.Time, bool) { n[0]++ }),
+			ticker2.C.OnRecv(func(time.Time, bool) { n[1]++ }),
+			done.OnRecv(func(bool, bool) { stop = true }),
+		)
+		if stop {
+			if counts != nil {
+				*counts = n
+			}
+			return nil
+		}
+	}
+}
+
+func TestExploreInterleavings(t *testing.T) {
+	leaktest.Check(t)
+
+	// Ticker 1 and the 2s sleep are both due at 2s, so the fourth tick
+	// counts only in some schedules. Exploring finds both.
+	seen := map[[2]int]bool{}
+	err := Explore(Options{Start: epoch}, 100, func(s *Sim) error {
+		var counts [2]int
+		err := multipleTickers(s, &counts)
+		seen[counts] = true
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[[2]int]bool{{3, 2}: true, {4, 2}: true}
+	if !maps.Equal(seen, want) {
+		t.Errorf("got outcomes %v, want %v", seen, want)
+	}
+}
+
+// How the worker model handles quit
+const (
+	selectQuit   = iota // Like the example: select picks data or quit
+	priorityQuit        // Check for data first, then select
+	drainOnQuit         // Receive what is left in data after quit
+)
+
+// worker is the select loop of the worker in 12-channels, returning the
+// values it received before quit or the timeout
+func worker(s *Sim, data *Chan[int], quit *Chan[bool], mode int) []int {
+	var got []int
+	received := data.OnRecv(func(v int, _ bool) { got = append(got, v) })
+	for {
+		if mode == priorityQuit && s.Select(received, Default(nil)) == 0 {
+			continue
+		}
+		done := false
+		s.Select(
+			received,
+			quit.OnRecv(func(bool, bool) { done = true }),
+			s.After(500*time.Millisecond).OnRecv(func(time.Time, bool) { done = true }),
+		)
+		if !done {
+			continue
+		}
+		for mode == drainOnQuit && s.Select(received, Default(nil)) == 0 {
+		}
+		return got
+	}
+}
+
+// sendThenQuit sends values on a buffered channel, then quit, and checks
+// the worker received them all
+func sendThenQuit(mode int) func(s *Sim) error {
+	return func(s *Sim) error {
+		data := NewChan[int](s, "data", 3)
+		quit := NewChan[bool](s, "quit", 0)
+		result := NewChan[[]int](s, "result", 1)
+		s.Go("worker", func() { result.Send(worker(s, data, quit, mode)) })
+
+		for i := 1; i <= 3; i++ {
+			data.Send(i)
+		}
+		quit.Send(true)
+		if got, _ := result.Recv(); !slices.Equal(got, []int{1, 2, 3}) {
+			return fmt.Errorf("worker quit after receiving %v", got)
+		}
+		return nil
+	}
+}
+
+func TestExploreFindsOrderingBug(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  int
+		fails bool
+	}{
+		// With a buffered data channel, quit can be ready while data still
+		// holds values, and select picks either
+		{name: "select", mode: selectQuit, fails: true},
+		// Data can arrive between the check and the select
+		{name: "priority", mode: priorityQuit, fails: true},
+		{name: "drain on quit", mode: drainOnQuit, fails: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaktest.Check(t)
+			err := Explore(Options{Start: epoch}, 500, sendThenQuit(tt.mode))
+			if !tt.fails {
+				if err != nil {
+					t.Errorf("got %v, want nil", err)
+				}
+				return
+			}
+
+			var f *Failure
+			if !errors.As(err, &f) {
+				t.Fatalf("got %v, want a *Failure", err)
+			}
+			// The seed reproduces the failure, every time
+			for range 3 {
+				again := run(f.Seed, sendThenQuit(tt.mode))
+				if again == nil || again.Error() != f.Err.Error() {
+					t.Errorf("seed %d: got %v, want %v", f.Seed, again, f.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestWorkerTimeout(t *testing.T) {
+	leaktest.Check(t)
+	err := Explore(Options{Start: epoch}, 50, func(s *Sim) error {
+		data := NewChan[int](s, "data", 0)
+		quit := NewChan[bool](s, "quit", 0)
+		s.Go("producer", func() {
+			data.Send(1)
+			s.Sleep(400 * time.Millisecond)
+			data.Send(2)
+			s.Sleep(600 * time.Millisecond) // Longer than the worker waits
+			data.Send(3)
+		})
+
+		got := worker(s, data, quit, selectQuit)
+		if !slices.Equal(got, []int{1, 2}) {
+			return fmt.Errorf("got %v, want [1 2]", got)
+		}
+		if want := epoch.Add(900 * time.Millisecond); !s.Now().Equal(want) {
+			return fmt.Errorf("timed out at %v, want %v", s.Now(), want)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,142 @@
+package sim
+
+import (
+	"slices"
+	"time"
+)
+
+// timer is a pending timer or ticker, fired by the scheduler once every
+// goroutine is blocked and it is the next one due
+type timer struct {
+	when   time.Time
+	period time.Duration // 0 for a timer, the interval of a ticker
+	ch     *core
+}
+
+// Timer is a simulated time.Timer
+type Timer struct {
+	C *Chan[time.Time]
+	t *timer
+}
+
+// Ticker is a simulated time.Ticker
+type Ticker struct {
+	C *Chan[time.Time]
+	t *timer
+}
+
+// NewTimer returns a timer that sends the virtual time on C after d
+func (s *Sim) NewTimer(d time.Duration) *Timer {
+	c := NewChan[time.Time](s, "timer", 1)
+	t := &timer{ch: c.c}
+	s.schedule(t, d)
+	return &Timer{C: c, t: t}
+}
+
+// After is like time.After
+func (s *Sim) After(d time.Duration) *Chan[time.Time] {
+	return s.NewTimer(d).C
+}
+
+// Sleep blocks the current goroutine for d of virtual time
+func (s *Sim) Sleep(d time.Duration) {
+	t := s.NewTimer(d)
+	t.C.c.name = "sleep"
+	t.C.Recv()
+}
+
+// Stop stops the timer and reports whether it was still pending. As with
+// time.Timer since Go 1.23, no stale value is received after Stop.
+func (t *Timer) Stop() bool {
+	t.C.c.buf = nil
+	return t.C.c.s.unschedule(t.t)
+}
+
+// Reset makes the timer fire after d, reporting whether it was still
+// pending
+func (t *Timer) Reset(d time.Duration) bool {
+	pending := t.Stop()
+	t.C.c.s.schedule(t.t, d)
+	return pending
+}
+
+// NewTicker returns a ticker that sends the virtual time on C every d,
+// dropping ticks while C is full like time.Ticker. It panics if d <= 0.
+func (s *Sim) NewTicker(d time.Duration) *Ticker {
+	if d <= 0 {
+		panic("sim: non-positive interval for NewTicker")
+	}
+	c := NewChan[time.Time](s, "ticker", 1)
+	t := &timer{period: d, ch: c.c}
+	s.schedule(t, d)
+	return &Ticker{C: c, t: t}
+}
+
+// Stop turns off the ticker
+func (t *Ticker) Stop() {
+	t.C.c.buf = nil
+	t.C.c.s.unschedule(t.t)
+}
+
+// Reset stops the ticker and restarts it with the interval d
+func (t *Ticker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("sim: non-positive interval for Ticker.Reset")
+	}
+	t.Stop()
+	t.t.period = d
+	t.C.c.s.schedule(t.t, d)
+}
+
+func (s *Sim) schedule(t *timer, d time.Duration) {
+	t.when = s.now.Add(max(d, 0))
+	s.timers = append(s.timers, t)
+}
+
+// unschedule removes t, reporting whether it was pending
+func (s *Sim) unschedule(t *timer) bool {
+	i := slices.Index(s.timers, t)
+	if i < 0 {
+		return false
+	}
+	s.timers = slices.Delete(s.timers, i, i+1)
+	return true
+}
+
+// due returns the timers that are due at the current virtual time
+func (s *Sim) due() []*timer {
+	var due []*timer
+	for _, t := range s.timers {
+		if !t.when.After(s.now) {
+			due = append(due, t)
+		}
+	}
+	return due
+}
+
+// advance moves the virtual time to the next timer, reporting false if
+// there are no timers
+func (s *Sim) advance() bool {
+	if len(s.timers) == 0 {
+		return false
+	}
+	next := s.timers[0].when
+	for _, t := range s.timers {
+		if t.when.Before(next) {
+			next = t.when
+		}
+	}
+	s.now = next
+	return true
+}
+
+// fire sends the current time on the channel of a due timer, and
+// reschedules it if it is a ticker
+func (s *Sim) fire(t *timer) {
+	if t.period > 0 {
+		t.when = t.when.Add(t.period)
+	} else {
+		s.unschedule(t)
+	}
+	t.ch.trySend(s.now)
+}
@@ -0,0 +1,275 @@
+// Package supervisor keeps long-running goroutines alive, in the style of
+// Erlang/OTP supervisors.
+//
+// A Supervisor starts its children in order and restarts them when they
+// fail. Its Strategy decides which children restart along with a failed
+// one, and a restart intensity limit makes it give up on children that
+// keep failing instead of restarting them forever. A Supervisor's Run is a
+// child like any other, so supervisors nest into trees: when one gives up,
+// its parent sees it fail and applies its own strategy.
+//
+//	workers := supervisor.New(supervisor.Options{Strategy: supervisor.RestForOne},
+//		supervisor.Child{Name: "queue", Run: queue.Serve},
+//		supervisor.Child{Name: "worker", Run: worker.Run}, // Restarted with the queue it reads
+//	)
+//	root := supervisor.New(supervisor.Options{},
+//		supervisor.Child{Name: "metrics", Run: metrics.Export},
+//		supervisor.Child{Name: "workers", Run: workers.Run},
+//	)
+//	err := root.Run(ctx) // Until ctx is done, or too many restarts
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/group"
+)
+
+// ErrTooManyRestarts is returned by Run when children failed more often
+// than the restart intensity allows
+var ErrTooManyRestarts = errors.New("supervisor: too many restarts")
+
+// Strategy decides which children restart when one fails
+type Strategy int
+
+const (
+	// OneForOne restarts only the failed child
+	OneForOne Strategy = iota
+	// OneForAll stops every other child and restarts them all, for
+	// children that can't work without each other
+	OneForAll
+	// RestForOne stops the children started after the failed one and
+	// restarts them with it, for children that depend on earlier ones
+	RestForOne
+)
+
+func (s Strategy) String() string {
+	switch s {
+	case OneForOne:
+		return "one for one"
+	case OneForAll:
+		return "one for all"
+	case RestForOne:
+		return "rest for one"
+	}
+	return "unknown"
+}
+
+// Restart decides whether a child is restarted after it returns
+type Restart int
+
+const (
+	// Permanent children are always restarted
+	Permanent Restart = iota
+	// Transient children are restarted only after an error or a panic
+	Transient
+	// Temporary children are never restarted, not even along with others
+	Temporary
+)
+
+// Child is a goroutine run by a Supervisor
+type Child struct {
+	Name    string
+	Run     func(ctx context.Context) error // Must return soon after ctx is done
+	Restart Restart
+}
+
+// Options configures a Supervisor
+type Options struct {
+	Strategy    Strategy
+	MaxRestarts int           // Restarts allowed within Period before giving up, 3 if 0
+	Period      time.Duration // Window of the restart intensity, 5s if 0
+	Clock       clock.Clock   // Times restarts for the intensity limit, clock.New() if nil
+
+	// Callbacks run on the supervisor's goroutine and should return quickly
+	OnStart     func(child string)            // A child is started, the first time or again
+	OnRestart   func(child string, err error) // A child is about to restart because of err
+	OnTerminate func(child string, err error) // A child returned err, or stopped when asked to
+}
+
+// Supervisor runs children and restarts them according to its Options
+type Supervisor struct {
+	opts     Options
+	clk      clock.Clock
+	children []Child
+
+	// Only used by the goroutine in Run
+	running  []*instance // Per child, nil while it isn't running
+	restarts []time.Time // Recent restarts, for the intensity limit
+	exits    chan *instance
+}
+
+// instance is one run of a child
+type instance struct {
+	index    int
+	cancel   context.CancelFunc
+	stopping chan struct{} // Closed when the supervisor stops the child itself
+	done     chan struct{} // Closed once the child returned and err is set
+	err      error
+}
+
+// New returns a Supervisor for children, which start in the order given
+func New(opts Options, children ...Child) *Supervisor {
+	if opts.MaxRestarts <= 0 {
+		opts.MaxRestarts = 3
+	}
+	if opts.Period <= 0 {
+		opts.Period = 5 * time.Second
+	}
+	clk := opts.Clock
+	if clk == nil {
+		clk = clock.New()
+	}
+	return &Supervisor{opts: opts, clk: clk, children: children}
+}
+
+// Run starts the children in order and supervises them until ctx is done,
+// then stops them in reverse order and returns nil. If children fail more
+// than MaxRestarts times within Period, Run stops the others the same way
+// and returns an error wrapping ErrTooManyRestarts and the last failure.
+//
+// Run may be called again once it has returned, starting afresh, which is
+// how a parent supervisor restarts it. It must not run twice at once.
+func (s *Supervisor) Run(ctx context.Context) error {
+	s.running = make([]*instance, len(s.children))
+	s.restarts = nil
+	s.exits = make(chan *instance)
+
+	for i := range s.children {
+		s.start(ctx, i)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			s.stopFrom(0)
+			return nil
+		case inst := <-s.exits:
+			if err := s.handle(ctx, inst); err != nil {
+				s.stopFrom(0)
+				return err
+			}
+		}
+	}
+}
+
+// handle reacts to a child that returned on its own
+func (s *Supervisor) handle(ctx context.Context, inst *instance) error {
+	i := inst.index
+	child := s.children[i]
+	s.running[i] = nil
+	if s.opts.OnTerminate != nil {
+		s.opts.OnTerminate(child.Name, inst.err)
+	}
+	switch {
+	case ctx.Err() != nil:
+		return nil // Shutting down, it only returned before being asked to
+	case child.Restart == Temporary:
+		return nil
+	case child.Restart == Transient && inst.err == nil:
+		return nil
+	}
+
+	if !s.allowRestart() {
+		if inst.err == nil {
+			return fmt.Errorf("%w: %s returned", ErrTooManyRestarts, child.Name)
+		}
+		return fmt.Errorf("%w: %s: %w", ErrTooManyRestarts, child.Name, inst.err)
+	}
+
+	restart := []int{i}
+	switch s.opts.Strategy {
+	case OneForAll:
+		restart = append(s.stopFrom(0), i)
+	case RestForOne:
+		restart = append(s.stopFrom(i+1), i)
+	}
+	for j := range s.children {
+		// In start order, whatever order they stopped in
+		if slices.Contains(restart, j) && s.children[j].Restart != Temporary {
+			if s.opts.OnRestart != nil {
+				s.opts.OnRestart(s.children[j].Name, inst.err)
+			}
+			s.start(ctx, j)
+		}
+	}
+	return nil
+}
+
+// allowRestart records a restart, reporting whether the intensity limit
+// still allows it
+func (s *Supervisor) allowRestart() bool {
+	now := s.clk.Now()
+	recent := s.restarts[:0]
+	for _, t := range s.restarts {
+		if now.Sub(t) < s.opts.Period {
+			recent = append(recent, t)
+		}
+	}
+	s.restarts = append(recent, now)
+	return len(s.restarts) <= s.opts.MaxRestarts
+}
+
+// start runs child i in a new goroutine
+func (s *Supervisor) start(ctx context.Context, i int) {
+	ctx, cancel := context.WithCancel(ctx)
+	inst := &instance{
+		index:    i,
+		cancel:   cancel,
+		stopping: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	s.running[i] = inst
+	if s.opts.OnStart != nil {
+		s.opts.OnStart(s.children[i].Name)
+	}
+
+	exits, run := s.exits, s.children[i].Run
+	go func() {
+		inst.err = call(ctx, run)
+		cancel()
+		close(inst.done)
+		// Nobody receives while the supervisor is stopping this child
+		select {
+		case exits <- inst:
+		case <-inst.stopping:
+		}
+	}()
+}
+
+// stopFrom stops the running children from index i on, last first, and
+// returns the indexes of those it stopped
+func (s *Supervisor) stopFrom(i int) []int {
+	var stopped []int
+	for j := len(s.running) - 1; j >= i; j-- {
+		inst := s.running[j]
+		if inst == nil {
+			continue
+		}
+		close(inst.stopping)
+		inst.cancel()
+		<-inst.done
+		s.running[j] = nil
+		stopped = append(stopped, j)
+		if s.opts.OnTerminate != nil {
+			s.opts.OnTerminate(s.children[j].Name, inst.err)
+		}
+	}
+	return stopped
+}
+
+// call runs fn, turning a panic into a *group.PanicError, so the
+// supervisor treats it like any other failure
+func call(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &group.PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn(ctx)
+}
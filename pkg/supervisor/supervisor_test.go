@@ -0,0 +1,281 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-by-example/internal/leaktest"
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/group"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+var errBoom = errors.New("boom")
+
+// events returns options whose callbacks report "start a", "restart a:
+// <err>" and "stop a: <err>" on the returned channel
+func events(opts Options) (Options, <-chan string) {
+	ch := make(chan string, 100)
+	opts.OnStart = func(child string) { ch <- "start " + child }
+	opts.OnRestart = func(child string, err error) { ch <- fmt.Sprintf("restart %s: %v", child, err) }
+	opts.OnTerminate = func(child string, err error) { ch <- fmt.Sprintf("stop %s: %v", child, err) }
+	return opts, ch
+}
+
+// expect fails the test unless the next events are want
+func expect(t *testing.T, ch <-chan string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		select {
+		case got := <-ch:
+			if got != w {
+				t.Fatalf("got event %q, want %q", got, w)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event %q", w)
+		}
+	}
+}
+
+// idle runs until its context is done
+func idle(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// failFirst returns a Run that fails with err on its first run, then idles
+func failFirst(err error) func(ctx context.Context) error {
+	var runs atomic.Int32
+	return func(ctx context.Context) error {
+		if runs.Add(1) == 1 {
+			return err
+		}
+		return idle(ctx)
+	}
+}
+
+// run starts s in the background and returns a func that cancels it and
+// returns what Run returned
+func run(s *Supervisor) (stop func() error, done <-chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- s.Run(ctx) }()
+	return func() error {
+		cancel()
+		return <-errc
+	}, errc
+}
+
+func TestStrategies(t *testing.T) {
+	const canceled = "context canceled"
+	tests := []struct {
+		strategy Strategy
+		expected []string // Events after the initial starts, until shutdown
+	}{
+		{
+			strategy: OneForOne,
+			expected: []string{"stop b: boom", "restart b: boom", "start b"},
+		},
+		{
+			strategy: OneForAll,
+			expected: []string{
+				"stop b: boom", "stop c: " + canceled, "stop a: " + canceled,
+				"restart a: boom", "start a", "restart b: boom", "start b", "restart c: boom", "start c",
+			},
+		},
+		{
+			strategy: RestForOne,
+			expected: []string{
+				"stop b: boom", "stop c: " + canceled,
+				"restart b: boom", "start b", "restart c: boom", "start c",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy.String(), func(t *testing.T) {
+			leaktest.Check(t)
+			opts, ch := events(Options{Strategy: tt.strategy})
+			s := New(opts,
+				Child{Name: "a", Run: idle},
+				Child{Name: "b", Run: failFirst(errBoom)},
+				Child{Name: "c", Run: idle},
+			)
+
+			stop, _ := run(s)
+			expect(t, ch, "start a", "start b", "start c")
+			expect(t, ch, tt.expected...)
+
+			// Shutdown goes in reverse start order
+			if err := stop(); err != nil {
+				t.Errorf("got %v, want nil", err)
+			}
+			expect(t, ch, "stop c: "+canceled, "stop b: "+canceled, "stop a: "+canceled)
+		})
+	}
+}
+
+func TestRestartTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		restart  Restart
+		err      error
+		expected bool
+	}{
+		{name: "permanent after an error", restart: Permanent, err: errBoom, expected: true},
+		{name: "permanent after returning", restart: Permanent, err: nil, expected: true},
+		{name: "transient after an error", restart: Transient, err: errBoom, expected: true},
+		{name: "transient after returning", restart: Transient, err: nil, expected: false},
+		{name: "temporary after an error", restart: Temporary, err: errBoom, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaktest.Check(t)
+			opts, ch := events(Options{})
+			s := New(opts, Child{Name: "x", Run: failFirst(tt.err), Restart: tt.restart})
+
+			stop, _ := run(s)
+			expect(t, ch, "start x", fmt.Sprintf("stop x: %v", tt.err))
+			if tt.expected {
+				expect(t, ch, fmt.Sprintf("restart x: %v", tt.err), "start x")
+			}
+			stop()
+			if tt.expected {
+				expect(t, ch, "stop x: context canceled")
+			}
+			select {
+			case e := <-ch:
+				t.Errorf("unexpected event %q", e)
+			default:
+			}
+		})
+	}
+}
+
+func TestTemporaryNotRestartedWithOthers(t *testing.T) {
+	leaktest.Check(t)
+	opts, ch := events(Options{Strategy: OneForAll})
+	s := New(opts,
+		Child{Name: "temp", Run: idle, Restart: Temporary},
+		Child{Name: "b", Run: failFirst(errBoom)},
+	)
+
+	stop, _ := run(s)
+	expect(t, ch, "start temp", "start b", "stop b: boom", "stop temp: context canceled", "restart b: boom", "start b")
+	stop()
+	expect(t, ch, "stop b: context canceled")
+}
+
+func TestIntensity(t *testing.T) {
+	t.Run("gives up", func(t *testing.T) {
+		leaktest.Check(t)
+		var runs atomic.Int32
+		s := New(Options{MaxRestarts: 2, Clock: clock.NewFake(epoch)},
+			Child{Name: "a", Run: idle},
+			Child{Name: "flaky", Run: func(context.Context) error {
+				runs.Add(1)
+				return errBoom
+			}},
+		)
+
+		err := s.Run(context.Background())
+		if !errors.Is(err, ErrTooManyRestarts) || !errors.Is(err, errBoom) {
+			t.Errorf("got %v, want %v and %v", err, ErrTooManyRestarts, errBoom)
+		}
+		if n := runs.Load(); n != 3 {
+			t.Errorf("got %d runs, want 3", n)
+		}
+	})
+
+	t.Run("forgets restarts older than the period", func(t *testing.T) {
+		leaktest.Check(t)
+		clk := clock.NewFake(epoch)
+		fail := make(chan struct{})
+		opts, ch := events(Options{MaxRestarts: 1, Period: time.Minute, Clock: clk})
+		s := New(opts, Child{Name: "flaky", Run: func(ctx context.Context) error {
+			select {
+			case <-fail:
+				return errBoom
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}})
+
+		stop, done := run(s)
+		expect(t, ch, "start flaky")
+		for range 3 {
+			fail <- struct{}{}
+			expect(t, ch, "stop flaky: boom", "restart flaky: boom", "start flaky")
+			clk.Advance(time.Minute)
+		}
+		select {
+		case err := <-done:
+			t.Fatalf("gave up with %v", err)
+		default:
+		}
+		if err := stop(); err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+	})
+}
+
+func TestNested(t *testing.T) {
+	leaktest.Check(t)
+	var runs atomic.Int32
+	inner := New(Options{MaxRestarts: 1},
+		Child{Name: "flaky", Run: func(context.Context) error {
+			runs.Add(1)
+			return errBoom
+		}},
+	)
+	opts, ch := events(Options{MaxRestarts: 1})
+	outer := New(opts,
+		Child{Name: "a", Run: idle},
+		Child{Name: "inner", Run: inner.Run},
+	)
+
+	// The inner supervisor gives up twice; the outer one restarts it once,
+	// then gives up too
+	err := outer.Run(context.Background())
+	if !errors.Is(err, ErrTooManyRestarts) || !errors.Is(err, errBoom) {
+		t.Errorf("got %v, want %v and %v", err, ErrTooManyRestarts, errBoom)
+	}
+	if n := runs.Load(); n != 4 {
+		t.Errorf("got %d runs of the inner child, want 4", n)
+	}
+
+	gaveUp := fmt.Sprintf("%v: flaky: %v", ErrTooManyRestarts, errBoom)
+	expect(t, ch,
+		"start a", "start inner",
+		"stop inner: "+gaveUp, "restart inner: "+gaveUp, "start inner",
+		"stop inner: "+gaveUp, "stop a: context canceled",
+	)
+}
+
+func TestPanic(t *testing.T) {
+	leaktest.Check(t)
+	var runs atomic.Int32
+	opts, ch := events(Options{})
+	restarted := make(chan error, 1)
+	opts.OnRestart = func(child string, err error) { restarted <- err }
+	s := New(opts, Child{Name: "x", Run: func(ctx context.Context) error {
+		if runs.Add(1) == 1 {
+			panic("oops")
+		}
+		return idle(ctx)
+	}})
+
+	stop, _ := run(s)
+	var p *group.PanicError
+	if err := <-restarted; !errors.As(err, &p) || p.Value != "oops" {
+		t.Fatalf("got %v, want a *group.PanicError of oops", err)
+	}
+	expect(t, ch, "start x", "stop x: goroutine panicked: oops", "start x")
+	stop()
+}
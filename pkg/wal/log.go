@@ -0,0 +1,162 @@
+// Package wal keeps jobs in an append-only write-ahead log, so work that was
+// queued survives a crash.
+//
+// The log is a sequence of records, each framed as
+//
+//	length  uint32, little endian, of the payload
+//	crc     uint32, little endian, CRC-32C of the payload
+//	payload length bytes
+//
+// A crash in the middle of a write leaves a truncated record at the end of
+// the file, and a damaged disk leaves a record whose checksum doesn't match.
+// Reader reports both. If no good record follows the bad one, Open cuts the
+// log back to the last good record before appending to it again. A good
+// record after it means the log was damaged in the middle rather than cut
+// short by a crash, and Open fails instead of throwing the rest away.
+//
+// Queue builds a durable job queue on the log: Enqueue appends a job, Ack
+// appends its removal, and jobs that are taken but not acknowledged within
+// the visibility timeout are handed out again. Opening the queue replays the
+// log, and Compact rewrites it with only the jobs still pending.
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	headerSize = 8
+
+	// MaxRecordSize bounds the payload of a record. A larger length in a
+	// header is taken as corruption rather than an allocation request.
+	MaxRecordSize = 16 << 20
+)
+
+var (
+	// ErrTruncated is returned by Reader.Next for a record cut short by the
+	// end of the log, typically by a crash during the write
+	ErrTruncated = errors.New("wal: truncated record")
+	// ErrCorrupt is returned by Reader.Next for a record whose checksum or
+	// length is wrong
+	ErrCorrupt = errors.New("wal: corrupt record")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// RecordError locates a bad record in the log
+type RecordError struct {
+	Offset int64 // Start of the record
+	Err    error // ErrTruncated or ErrCorrupt
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("%v at offset %d", e.Err, e.Offset)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// AppendRecord appends the framed record for payload to buf
+func AppendRecord(buf, payload []byte) []byte {
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(payload)))
+	buf = binary.LittleEndian.AppendUint32(buf, crc32.Checksum(payload, crcTable))
+	return append(buf, payload...)
+}
+
+// Writer appends records to an io.Writer
+type Writer struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewWriter returns a Writer appending to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write appends one record with a single Write call to the underlying
+// writer, so a record is never interleaved with another
+func (w *Writer) Write(payload []byte) error {
+	if len(payload) > MaxRecordSize {
+		return fmt.Errorf("wal: record of %d bytes exceeds %d", len(payload), MaxRecordSize)
+	}
+	w.buf = AppendRecord(w.buf[:0], payload)
+	_, err := w.w.Write(w.buf)
+	return err
+}
+
+// Reader reads records from an io.Reader
+type Reader struct {
+	r   *bufio.Reader
+	off int64
+}
+
+// NewReader returns a Reader reading from r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next returns the payload of the next record. It returns io.EOF at a clean
+// end of the log and a *RecordError wrapping ErrTruncated or ErrCorrupt for
+// a bad record, after which the Reader can't go on.
+func (r *Reader) Next() ([]byte, error) {
+	var header [headerSize]byte
+	n, err := io.ReadFull(r.r, header[:])
+	switch {
+	case err == io.EOF:
+		return nil, io.EOF
+	case err == io.ErrUnexpectedEOF:
+		return nil, &RecordError{r.off, ErrTruncated}
+	case err != nil:
+		return nil, err
+	}
+
+	length := binary.LittleEndian.Uint32(header[:4])
+	sum := binary.LittleEndian.Uint32(header[4:])
+	if length > MaxRecordSize {
+		return nil, &RecordError{r.off, ErrCorrupt}
+	}
+	payload := make([]byte, length)
+	m, err := io.ReadFull(r.r, payload)
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return nil, &RecordError{r.off, ErrTruncated}
+	case err != nil:
+		return nil, err
+	}
+	if crc32.Checksum(payload, crcTable) != sum {
+		return nil, &RecordError{r.off, ErrCorrupt}
+	}
+
+	r.off += int64(n + m)
+	return payload, nil
+}
+
+// Offset returns the end of the last good record, which is where the log
+// should be cut after a bad one
+func (r *Reader) Offset() int64 {
+	return r.off
+}
+
+// resync returns the offset in data of the first whole record with a
+// matching checksum, or -1 if there is none. Records with an empty payload
+// are skipped, as a run of zero bytes would pass for a sequence of them.
+func resync(data []byte) int {
+	for i := 0; i+headerSize <= len(data); i++ {
+		length := binary.LittleEndian.Uint32(data[i:])
+		if length == 0 || length > MaxRecordSize || int64(length) > int64(len(data)-i-headerSize) {
+			continue
+		}
+		payload := data[i+headerSize : i+headerSize+int(length)]
+		if crc32.Checksum(payload, crcTable) == binary.LittleEndian.Uint32(data[i+4:]) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,94 @@
+package wal
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+)
+
+// logOf returns a log holding records with the given payloads
+func logOf(payloads ...string) []byte {
+	var buf []byte
+	for _, p := range payloads {
+		buf = AppendRecord(buf, []byte(p))
+	}
+	return buf
+}
+
+// readAll reads records until the first error
+func readAll(data []byte) ([]string, int64, error) {
+	r := NewReader(bytes.NewReader(data))
+	var out []string
+	for {
+		payload, err := r.Next()
+		if err != nil {
+			return out, r.Offset(), err
+		}
+		out = append(out, string(payload))
+	}
+}
+
+func TestWriterReader(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	want := []string{"first", "", "third"}
+	for _, p := range want {
+		if err := w.Write([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, off, err := readAll(buf.Bytes())
+	if err != io.EOF {
+		t.Fatalf("got error %v, want %v", err, io.EOF)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if off != int64(buf.Len()) {
+		t.Errorf("got offset %d, want %d", off, buf.Len())
+	}
+
+	if err := w.Write(make([]byte, MaxRecordSize+1)); err == nil {
+		t.Error("got no error for an oversized record")
+	}
+}
+
+func TestReaderBadRecords(t *testing.T) {
+	good := logOf("one", "two")
+	hugeLength := append(logOf("one", "two"), 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0)
+	flipped := logOf("one", "two", "three")
+	flipped[len(flipped)-2] ^= 0x01
+
+	tests := []struct {
+		name     string
+		data     []byte
+		records  []string
+		expected error
+	}{
+		{name: "torn header", data: good[:len(good)-len("two")-3], records: []string{"one"}, expected: ErrTruncated},
+		{name: "torn payload", data: good[:len(good)-1], records: []string{"one"}, expected: ErrTruncated},
+		{name: "flipped bit", data: append(flipped, logOf("four")...), records: []string{"one", "two"}, expected: ErrCorrupt},
+		{name: "huge length", data: hugeLength, records: []string{"one", "two"}, expected: ErrCorrupt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, off, err := readAll(tt.data)
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("got error %v, want %v", err, tt.expected)
+			}
+			var recErr *RecordError
+			if !errors.As(err, &recErr) || recErr.Offset != off {
+				t.Errorf("got %v, want a *RecordError at offset %d", err, off)
+			}
+
+			// Everything before the bad record is still read
+			if !slices.Equal(got, tt.records) || off != int64(len(logOf(tt.records...))) {
+				t.Errorf("got %q up to offset %d, want %q", got, off, tt.records)
+			}
+		})
+	}
+}
@@ -0,0 +1,650 @@
+package wal
+
+import (
+	"container/heap"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"go-by-example/internal/broadcast"
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/pool"
+)
+
+var (
+	// ErrClosed is returned by a Queue after Close
+	ErrClosed = errors.New("wal: queue closed")
+	// ErrUnknownJob is returned by Ack and Release for a job that isn't
+	// pending, usually because it was already acknowledged
+	ErrUnknownJob = errors.New("wal: unknown job")
+)
+
+// Operations recorded in the log
+const (
+	opPut  = 'P' // id, enqueue time, JSON value
+	opAck  = 'A' // id
+	opNext = 'N' // next id, written first by compaction so ids are never reused
+)
+
+// Options configures a Queue
+type Options struct {
+	Visibility      time.Duration // How long a taken job stays hidden before it is handed out again, default 30s
+	CompactInterval time.Duration // How often to compact the log if jobs were acknowledged; 0 compacts only on request
+	NoSync          bool          // Skip fsync after each write: faster, but a crash may lose the latest writes
+	Clock           clock.Clock   // Times visibility and compaction, clock.New() if nil
+}
+
+// Job is a queued value with its bookkeeping
+type Job[T any] struct {
+	ID         uint64
+	Value      T
+	Enqueued   time.Time
+	Deliveries int // Times handed out since the queue was opened, counting this one
+}
+
+// Recovery describes what Open found in the log
+type Recovery struct {
+	Records   int   // Good records replayed
+	Pending   int   // Jobs not yet acknowledged
+	Discarded int64 // Bytes cut from the end of the log
+	Err       error // The *RecordError that ended the replay, or nil
+}
+
+// Stats counts what a Queue has done since it was opened
+type Stats struct {
+	Ready       int    // Jobs waiting to be taken
+	InFlight    int    // Jobs taken but not acknowledged
+	Enqueued    uint64 // Jobs added
+	Acked       uint64 // Jobs acknowledged
+	Redelivered uint64 // Jobs handed out again after their visibility timeout
+	LogSize     int64  // Bytes in the log
+	Compactions int
+	CompactErr  error // Error of the last periodic compaction, nil once one succeeds
+}
+
+// Queue is a durable FIFO job queue backed by a write-ahead log. Jobs are
+// stored as JSON, so T must survive a round trip through encoding/json.
+//
+// Delivery is at least once: a job taken with Dequeue is hidden for the
+// visibility timeout and handed out again unless it is acknowledged with Ack
+// before then, and jobs that were taken but not acknowledged when the
+// process stopped are handed out again after Open.
+type Queue[T any] struct {
+	mu      sync.Mutex
+	path    string
+	opts    Options
+	clk     clock.Clock
+	f       *os.File
+	w       *Writer
+	size    int64
+	garbage int // Records that compaction would drop
+	jobs    map[uint64]*job[T]
+	ready   idHeap // Pending jobs not leased, oldest first
+	nextID  uint64
+	closed  bool
+	changed broadcast.Signal
+
+	recovery Recovery
+	stats    Stats
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+type job[T any] struct {
+	Job[T]
+	data        []byte    // Encoded value, kept for compaction
+	leasedUntil time.Time // Zero unless taken
+}
+
+// Open opens or creates the queue stored at path and replays its log. A
+// truncated or corrupt record at the end of the log, as a crash during a
+// write leaves, ends the replay; the log is cut back to the last good record,
+// and Recovery reports what was lost. A bad record followed by good ones
+// makes Open fail with an error wrapping ErrCorrupt or ErrTruncated, and
+// leaves the log as it is. A temporary file left by a compaction that
+// didn't finish is removed.
+func Open[T any](path string, opts Options) (*Queue[T], error) {
+	if opts.Visibility <= 0 {
+		opts.Visibility = 30 * time.Second
+	}
+	clk := opts.Clock
+	if clk == nil {
+		clk = clock.New()
+	}
+
+	// The rename is what commits a compaction, so until then the log is
+	// complete and the temporary file can go
+	if err := os.Remove(path + ".compact"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	q := &Queue[T]{
+		path:   path,
+		opts:   opts,
+		clk:    clk,
+		f:      f,
+		w:      NewWriter(f),
+		jobs:   make(map[uint64]*job[T]),
+		nextID: 1,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if err := q.replay(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("wal: replay %s: %w", path, err)
+	}
+
+	if opts.CompactInterval > 0 {
+		go q.compactLoop()
+	} else {
+		close(q.done)
+	}
+	return q, nil
+}
+
+// replay rebuilds the pending jobs from the log and positions the file for
+// appending after the last good record. It fails rather than cut the log if
+// a good record follows a bad one.
+func (q *Queue[T]) replay() error {
+	info, err := q.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	r := NewReader(q.f)
+	for {
+		payload, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		var recErr *RecordError
+		if errors.As(err, &recErr) {
+			next, err := q.nextRecord(recErr.Offset+1, info.Size())
+			if err != nil {
+				return err
+			}
+			if next >= 0 {
+				return fmt.Errorf("%w, but a good record follows at offset %d", recErr, next)
+			}
+			q.recovery.Err = recErr
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := q.apply(payload); err != nil {
+			return fmt.Errorf("record at offset %d: %w", r.Offset()-int64(headerSize+len(payload)), err)
+		}
+		q.recovery.Records++
+	}
+
+	q.size = r.Offset()
+	q.recovery.Discarded = info.Size() - q.size
+	if q.recovery.Discarded > 0 {
+		if err := q.f.Truncate(q.size); err != nil {
+			return err
+		}
+	}
+	if _, err := q.f.Seek(q.size, io.SeekStart); err != nil {
+		return err
+	}
+
+	for id := range q.jobs {
+		q.ready = append(q.ready, id)
+	}
+	heap.Init(&q.ready)
+	q.recovery.Pending = len(q.jobs)
+	return nil
+}
+
+// nextRecord returns the offset of the first good record in the log
+// between from and size, or -1 if there is none
+func (q *Queue[T]) nextRecord(from, size int64) (int64, error) {
+	if from >= size {
+		return -1, nil
+	}
+	data := make([]byte, size-from)
+	if _, err := q.f.ReadAt(data, from); err != nil {
+		return 0, err
+	}
+	if i := resync(data); i >= 0 {
+		return from + int64(i), nil
+	}
+	return -1, nil
+}
+
+// apply replays one record
+func (q *Queue[T]) apply(payload []byte) error {
+	if len(payload) == 0 {
+		return errors.New("empty record")
+	}
+	op, rest := payload[0], payload[1:]
+	id, n := binary.Uvarint(rest)
+	if n <= 0 {
+		return errors.New("bad id")
+	}
+	rest = rest[n:]
+
+	switch op {
+	case opPut:
+		nanos, n := binary.Varint(rest)
+		if n <= 0 {
+			return errors.New("bad enqueue time")
+		}
+		j := &job[T]{data: rest[n:]}
+		if err := json.Unmarshal(j.data, &j.Value); err != nil {
+			return err
+		}
+		j.ID = id
+		j.Enqueued = time.Unix(0, nanos)
+		q.jobs[id] = j
+		q.nextID = max(q.nextID, id+1)
+	case opAck:
+		delete(q.jobs, id)
+		q.garbage += 2 // The ack and the put it cancels
+	case opNext:
+		q.nextID = max(q.nextID, id)
+	default:
+		return fmt.Errorf("unknown operation %q", op)
+	}
+	return nil
+}
+
+// Recovery returns what Open found in the log
+func (q *Queue[T]) Recovery() Recovery {
+	return q.recovery
+}
+
+// Enqueue appends a job and returns its ID. Unless Options.NoSync is set,
+// the job is on disk when Enqueue returns.
+func (q *Queue[T]) Enqueue(v T) (uint64, error) {
+	return q.enqueue(v, q.clk.Now())
+}
+
+func (q *Queue[T]) enqueue(v T, at time.Time) (uint64, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return 0, ErrClosed
+	}
+	id := q.nextID
+	if err := q.write(encodePut(id, at, data)); err != nil {
+		return 0, err
+	}
+	q.nextID++
+	q.jobs[id] = &job[T]{Job: Job[T]{ID: id, Value: v, Enqueued: at}, data: data}
+	heap.Push(&q.ready, id)
+	q.stats.Enqueued++
+	q.changed.Notify()
+	return id, nil
+}
+
+// Dequeue takes the oldest visible job, blocking until there is one or ctx
+// is done. The job is hidden from other callers until it is acknowledged
+// with Ack, released with Release, or its visibility timeout passes.
+func (q *Queue[T]) Dequeue(ctx context.Context) (Job[T], error) {
+	return q.take(ctx, nil)
+}
+
+// take implements Dequeue; a closed source makes it give up once no job
+// is ready
+func (q *Queue[T]) take(ctx context.Context, src *source[T]) (Job[T], error) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return Job[T]{}, ErrClosed
+		}
+		now := q.clk.Now()
+		q.expire(now)
+		if len(q.ready) > 0 {
+			id := heap.Pop(&q.ready).(uint64)
+			j := q.jobs[id]
+			j.leasedUntil = now.Add(q.opts.Visibility)
+			j.Deliveries++
+			q.mu.Unlock()
+			return j.Job, nil
+		}
+		if src != nil && src.closed {
+			q.mu.Unlock()
+			return Job[T]{}, pool.ErrClosed
+		}
+
+		// Wait for a change, or for the first lease to run out
+		changed := q.changed.Chan()
+		var timer clock.Timer
+		var expiry <-chan time.Time
+		if next, ok := q.nextExpiry(); ok {
+			timer = q.clk.NewTimer(next.Sub(now))
+			expiry = timer.C()
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-expiry:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return Job[T]{}, err
+		}
+	}
+}
+
+// expire makes jobs whose lease has run out visible again. q.mu must be held.
+func (q *Queue[T]) expire(now time.Time) {
+	for id, j := range q.jobs {
+		if !j.leasedUntil.IsZero() && !now.Before(j.leasedUntil) {
+			j.leasedUntil = time.Time{}
+			heap.Push(&q.ready, id)
+			q.stats.Redelivered++
+		}
+	}
+}
+
+// nextExpiry returns when the first lease runs out. q.mu must be held.
+func (q *Queue[T]) nextExpiry() (time.Time, bool) {
+	var next time.Time
+	for _, j := range q.jobs {
+		if !j.leasedUntil.IsZero() && (next.IsZero() || j.leasedUntil.Before(next)) {
+			next = j.leasedUntil
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// Ack removes a job for good. It fails with ErrUnknownJob if the job was
+// already acknowledged.
+func (q *Queue[T]) Ack(id uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if _, ok := q.jobs[id]; !ok {
+		return ErrUnknownJob
+	}
+	if err := q.write(encodeAck(id)); err != nil {
+		return err
+	}
+	q.remove(id)
+	q.garbage += 2
+	q.stats.Acked++
+	q.changed.Notify()
+	return nil
+}
+
+// Release makes a taken job visible again right away, for a consumer that
+// knows it won't finish it
+func (q *Queue[T]) Release(id uint64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	j, ok := q.jobs[id]
+	if !ok {
+		return ErrUnknownJob
+	}
+	if !j.leasedUntil.IsZero() {
+		j.leasedUntil = time.Time{}
+		heap.Push(&q.ready, id)
+		q.changed.Notify()
+	}
+	return nil
+}
+
+// remove forgets a pending job. q.mu must be held.
+func (q *Queue[T]) remove(id uint64) {
+	if q.jobs[id].leasedUntil.IsZero() {
+		q.ready.remove(id)
+	}
+	delete(q.jobs, id)
+}
+
+// Len returns the number of jobs waiting to be taken
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.expire(q.clk.Now())
+	return len(q.ready)
+}
+
+// Stats returns counters since the queue was opened
+func (q *Queue[T]) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.expire(q.clk.Now())
+	s := q.stats
+	s.Ready = len(q.ready)
+	s.InFlight = len(q.jobs) - len(q.ready)
+	s.LogSize = q.size
+	return s
+}
+
+// Compact rewrites the log with only the pending jobs, dropping everything
+// that was acknowledged. The new log is written to a temporary file and
+// renamed over the old one, so a crash during compaction leaves either the
+// old log or the new one, and Open cleans up the temporary file.
+func (q *Queue[T]) Compact() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+
+	tmp := q.path + ".compact"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	size, err := q.writeCompacted(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, q.path)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("wal: compact %s: %w", q.path, err)
+	}
+
+	// The old log is unlinked now, so switch to the new one before anything
+	// else can fail; writes to the old file would be lost
+	q.f.Close()
+	q.f, q.w, q.size = f, NewWriter(f), size
+	q.garbage = 0
+	q.stats.Compactions++
+	if err := syncDir(filepath.Dir(q.path)); err != nil {
+		return fmt.Errorf("wal: compact %s: %w", q.path, err)
+	}
+	return nil
+}
+
+// writeCompacted writes the next ID and the pending jobs to f, oldest
+// first, and returns the number of bytes written
+func (q *Queue[T]) writeCompacted(f *os.File) (int64, error) {
+	buf := AppendRecord(nil, encodeNext(q.nextID))
+	ids := make([]uint64, 0, len(q.jobs))
+	for id := range q.jobs {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		j := q.jobs[id]
+		buf = AppendRecord(buf, encodePut(id, j.Enqueued, j.data))
+	}
+	_, err := f.Write(buf)
+	return int64(len(buf)), err
+}
+
+func (q *Queue[T]) compactLoop() {
+	defer close(q.done)
+	ticker := q.clk.NewTicker(q.opts.CompactInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			q.mu.Lock()
+			garbage := q.garbage
+			q.mu.Unlock()
+			if garbage == 0 {
+				continue
+			}
+			err := q.Compact()
+			q.mu.Lock()
+			q.stats.CompactErr = err
+			q.mu.Unlock()
+		case <-q.stop:
+			return
+		}
+	}
+}
+
+// Close stops periodic compaction and closes the log. Jobs that are still
+// pending are handed out again by the next Open.
+func (q *Queue[T]) Close() error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	close(q.stop)
+	q.mu.Unlock()
+	<-q.done // Compaction takes q.mu, so wait without holding it
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.changed.Notify()
+	return q.f.Close()
+}
+
+// write appends a record and syncs it to disk. If the write fails part way,
+// the log is cut back so the torn record doesn't hide later ones. q.mu must
+// be held.
+func (q *Queue[T]) write(payload []byte) error {
+	err := q.w.Write(payload)
+	if err == nil && !q.opts.NoSync {
+		err = q.f.Sync()
+	}
+	if err != nil {
+		q.f.Truncate(q.size)
+		q.f.Seek(q.size, io.SeekStart)
+		return fmt.Errorf("wal: write %s: %w", q.path, err)
+	}
+	q.size += int64(headerSize + len(payload))
+	return nil
+}
+
+// Source returns a view of the queue for pool.NewWithQueue, so the pool's
+// workers take jobs from the log. Submit enqueues the Value of the job it is
+// given. The pool only gets to see jobs: acknowledge each one with Ack once
+// its result is handled. Closing the pool closes the view, not the queue,
+// and jobs still pending stay in the log for the next run.
+func (q *Queue[T]) Source() pool.Queue[Job[T]] {
+	return &source[T]{q: q}
+}
+
+type source[T any] struct {
+	q      *Queue[T]
+	closed bool // Guarded by q.mu
+}
+
+func (s *source[T]) Push(ctx context.Context, e pool.Entry[Job[T]]) error {
+	_, err := s.q.enqueue(e.Value.Value, e.Enqueued)
+	return err
+}
+
+func (s *source[T]) TryPush(e pool.Entry[Job[T]]) error {
+	return s.Push(context.Background(), e)
+}
+
+func (s *source[T]) Pop(ctx context.Context) (pool.Entry[Job[T]], error) {
+	j, err := s.q.take(ctx, s)
+	if err != nil {
+		return pool.Entry[Job[T]]{}, err
+	}
+	return pool.Entry[Job[T]]{Value: j, Enqueued: j.Enqueued}, nil
+}
+
+func (s *source[T]) Close() {
+	s.q.mu.Lock()
+	defer s.q.mu.Unlock()
+	s.closed = true
+	s.q.changed.Notify()
+}
+
+func (s *source[T]) Len() int {
+	return s.q.Len()
+}
+
+func encodePut(id uint64, at time.Time, data []byte) []byte {
+	buf := []byte{opPut}
+	buf = binary.AppendUvarint(buf, id)
+	buf = binary.AppendVarint(buf, at.UnixNano())
+	return append(buf, data...)
+}
+
+func encodeAck(id uint64) []byte {
+	return binary.AppendUvarint([]byte{opAck}, id)
+}
+
+func encodeNext(id uint64) []byte {
+	return binary.AppendUvarint([]byte{opNext}, id)
+}
+
+// syncDir makes a rename in dir durable. Tests replace it to fail.
+var syncDir = func(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// idHeap implements heap.Interface for job IDs, smallest first
+type idHeap []uint64
+
+func (h idHeap) Len() int           { return len(h) }
+func (h idHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h idHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *idHeap) Push(x any)        { *h = append(*h, x.(uint64)) }
+
+func (h *idHeap) Pop() any {
+	old := *h
+	id := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return id
+}
+
+func (h *idHeap) remove(id uint64) {
+	if i := slices.Index(*h, id); i >= 0 {
+		heap.Remove(h, i)
+	}
+}
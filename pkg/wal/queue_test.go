@@ -0,0 +1,440 @@
+package wal
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"go-by-example/pkg/clock"
+	"go-by-example/pkg/pool"
+)
+
+var epoch = time.Date(2024, time.March, 15, 14, 30, 0, 0, time.UTC)
+
+// open opens the queue at path, failing the test on error
+func open(t *testing.T, path string, opts Options) *Queue[string] {
+	t.Helper()
+	q, err := Open[string](path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { q.Close() })
+	return q
+}
+
+// enqueue adds values to q
+func enqueue(t *testing.T, q *Queue[string], values ...string) {
+	t.Helper()
+	for _, v := range values {
+		if _, err := q.Enqueue(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// pending takes every visible job from q and returns their values
+func pending(t *testing.T, q *Queue[string]) []string {
+	t.Helper()
+	var out []string
+	for q.Len() > 0 {
+		j, err := q.Dequeue(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, j.Value)
+	}
+	return out
+}
+
+func TestQueuePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.wal")
+	q := open(t, path, Options{})
+	enqueue(t, q, "a", "b", "c")
+	j, _ := q.Dequeue(context.Background())
+	if err := q.Ack(j.ID); err != nil {
+		t.Fatal(err)
+	}
+	q.Dequeue(context.Background()) // Taken but never acknowledged
+	q.Close()
+
+	q = open(t, path, Options{})
+	if r := q.Recovery(); r.Records != 4 || r.Pending != 2 || r.Err != nil {
+		t.Errorf("got recovery %+v, want 4 records and 2 pending", r)
+	}
+	if id, _ := q.Enqueue("d"); id != 4 {
+		t.Errorf("got ID %d, want 4", id)
+	}
+	want := []string{"b", "c", "d"}
+	if got := pending(t, q); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestQueueVisibility(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	q := open(t, filepath.Join(t.TempDir(), "jobs.wal"), Options{Visibility: 10 * time.Second, Clock: clk})
+	enqueue(t, q, "x")
+
+	first, _ := q.Dequeue(context.Background())
+	taken := make(chan Job[string])
+	go func() {
+		j, _ := q.Dequeue(context.Background())
+		taken <- j
+	}()
+
+	// The second Dequeue waits for the first lease to run out
+	clk.BlockUntil(1)
+	clk.Advance(10 * time.Second)
+	second := <-taken
+	if second.ID != first.ID || second.Deliveries != 2 {
+		t.Errorf("got job %d on delivery %d, want job %d on delivery 2", second.ID, second.Deliveries, first.ID)
+	}
+	if s := q.Stats(); s.Redelivered != 1 || s.InFlight != 1 || s.Ready != 0 {
+		t.Errorf("got %+v, want 1 redelivered and 1 in flight", s)
+	}
+
+	// Release hands it out again without waiting
+	if err := q.Release(second.ID); err != nil {
+		t.Fatal(err)
+	}
+	third, _ := q.Dequeue(context.Background())
+	if third.Deliveries != 3 {
+		t.Errorf("got delivery %d, want 3", third.Deliveries)
+	}
+
+	if err := q.Ack(third.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Ack(third.ID); !errors.Is(err, ErrUnknownJob) {
+		t.Errorf("second Ack: got %v, want %v", err, ErrUnknownJob)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := q.Dequeue(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Dequeue on empty queue: got %v, want %v", err, context.Canceled)
+	}
+	q.Close()
+	if _, err := q.Dequeue(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("Dequeue after Close: got %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestQueueRecovery(t *testing.T) {
+	tests := []struct {
+		name     string
+		damage   func(data []byte) []byte
+		expected error
+	}{
+		{
+			name:     "torn write",
+			damage:   func(data []byte) []byte { return data[:len(data)-2] },
+			expected: ErrTruncated,
+		},
+		{
+			name: "corrupt record",
+			damage: func(data []byte) []byte {
+				data[len(data)-2] ^= 0xff
+				return data
+			},
+			expected: ErrCorrupt,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "jobs.wal")
+			q := open(t, path, Options{})
+			enqueue(t, q, "a", "b", "c")
+			q.Close()
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tt.damage(data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			// The damaged last job is dropped and the log cut back
+			q = open(t, path, Options{})
+			r := q.Recovery()
+			if !errors.Is(r.Err, tt.expected) || r.Records != 2 || r.Discarded == 0 {
+				t.Errorf("got recovery %+v, want 2 records and %v", r, tt.expected)
+			}
+			enqueue(t, q, "d")
+			q.Close()
+
+			// Appending after the cut leaves a clean log
+			q = open(t, path, Options{})
+			if r := q.Recovery(); r.Err != nil || r.Records != 3 {
+				t.Errorf("got recovery %+v after reopening, want 3 clean records", r)
+			}
+			want := []string{"a", "b", "d"}
+			if got := pending(t, q); !slices.Equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestQueueDamagedMiddle(t *testing.T) {
+	// second returns the offset and payload length of the second record
+	second := func(data []byte) (int, int) {
+		off := headerSize + int(binary.LittleEndian.Uint32(data))
+		return off, int(binary.LittleEndian.Uint32(data[off:]))
+	}
+	tests := []struct {
+		name     string
+		damage   func(data []byte)
+		expected error
+	}{
+		{
+			name: "bad checksum",
+			damage: func(data []byte) {
+				off, n := second(data)
+				data[off+headerSize+n-1] ^= 0xff
+			},
+			expected: ErrCorrupt,
+		},
+		{
+			name: "bad length",
+			damage: func(data []byte) {
+				off, _ := second(data)
+				binary.LittleEndian.PutUint32(data[off:], MaxRecordSize+1)
+			},
+			expected: ErrCorrupt,
+		},
+		{
+			name: "length past the end",
+			damage: func(data []byte) {
+				off, _ := second(data)
+				binary.LittleEndian.PutUint32(data[off:], uint32(len(data)))
+			},
+			expected: ErrTruncated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "jobs.wal")
+			q := open(t, path, Options{})
+			enqueue(t, q, "a", "b", "c")
+			q.Close()
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.damage(data)
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			// The third job is still there, so the log must not be cut
+			q, err = Open[string](path, Options{})
+			if err == nil {
+				q.Close()
+				t.Fatalf("got recovery %+v, want an error", q.Recovery())
+			}
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("got %v, want %v", err, tt.expected)
+			}
+			after, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(after, data) {
+				t.Errorf("log changed from %d to %d bytes", len(data), len(after))
+			}
+		})
+	}
+}
+
+func TestQueueLeftoverCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.wal")
+	q := open(t, path, Options{})
+	enqueue(t, q, "a", "b")
+	q.Close()
+
+	// A crash before the rename leaves a partly written temporary file
+	partial := AppendRecord(nil, encodeNext(10))[:headerSize+1]
+	if err := os.WriteFile(path+".compact", partial, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	q = open(t, path, Options{})
+	want := []string{"a", "b"}
+	if got := pending(t, q); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, err := os.Stat(path + ".compact"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+	if err := q.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := q.Enqueue("c"); id != 3 {
+		t.Errorf("got ID %d, want 3", id)
+	}
+}
+
+func TestQueueCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.wal")
+	q := open(t, path, Options{NoSync: true})
+	enqueue(t, q, "a", "b", "c", "d", "e", "f")
+	for range 4 {
+		j, _ := q.Dequeue(context.Background())
+		q.Ack(j.ID)
+	}
+	q.Dequeue(context.Background()) // Still pending while leased
+
+	before := q.Stats().LogSize
+	if err := q.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	after := q.Stats().LogSize
+	if info, _ := os.Stat(path); after >= before || info.Size() != after {
+		t.Errorf("got log of %d bytes, %d on disk, want less than %d", after, info.Size(), before)
+	}
+	if _, err := os.Stat(path + ".compact"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+	enqueue(t, q, "g")
+	q.Close()
+
+	// IDs carry on after compaction, even once everything was acknowledged
+	q = open(t, path, Options{})
+	want := []string{"e", "f", "g"}
+	if got := pending(t, q); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for _, id := range []uint64{5, 6, 7} {
+		q.Ack(id)
+	}
+	q.Compact()
+	q.Close()
+	q = open(t, path, Options{})
+	if id, _ := q.Enqueue("h"); id != 8 {
+		t.Errorf("got ID %d, want 8", id)
+	}
+}
+
+func TestQueueCompactSyncDirFails(t *testing.T) {
+	errSync := errors.New("sync failed")
+	orig := syncDir
+	syncDir = func(string) error { return errSync }
+	t.Cleanup(func() { syncDir = orig })
+
+	path := filepath.Join(t.TempDir(), "jobs.wal")
+	q := open(t, path, Options{})
+	enqueue(t, q, "a", "b")
+	j, _ := q.Dequeue(context.Background())
+	q.Ack(j.ID)
+	if err := q.Compact(); !errors.Is(err, errSync) {
+		t.Fatalf("got %v, want %v", err, errSync)
+	}
+
+	// The rename happened, so later writes must go to the new log
+	enqueue(t, q, "c")
+	q.Close()
+	q = open(t, path, Options{})
+	want := []string{"b", "c"}
+	if got := pending(t, q); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestQueueClosed(t *testing.T) {
+	q := open(t, filepath.Join(t.TempDir(), "jobs.wal"), Options{})
+	enqueue(t, q, "a")
+	j, _ := q.Dequeue(context.Background())
+	q.Close()
+
+	ops := map[string]func() error{
+		"Ack":     func() error { return q.Ack(j.ID) },
+		"Release": func() error { return q.Release(j.ID) },
+		"Compact": q.Compact,
+	}
+	for name, op := range ops {
+		if err := op(); !errors.Is(err, ErrClosed) {
+			t.Errorf("%s: got %v, want %v", name, err, ErrClosed)
+		}
+	}
+}
+
+func TestQueuePeriodicCompaction(t *testing.T) {
+	clk := clock.NewFake(epoch)
+	q := open(t, filepath.Join(t.TempDir(), "jobs.wal"), Options{CompactInterval: time.Minute, Clock: clk})
+	enqueue(t, q, "a", "b")
+	j, _ := q.Dequeue(context.Background())
+	q.Ack(j.ID)
+
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute)
+
+	// Compaction runs in the background; wait for it with a real deadline
+	deadline := time.Now().Add(time.Second)
+	for q.Stats().Compactions == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if s := q.Stats(); s.Compactions != 1 || s.CompactErr != nil {
+		t.Fatalf("got %d compactions, error %v, want 1", s.Compactions, s.CompactErr)
+	}
+
+	// Nothing was acknowledged since, so the next tick leaves the log alone
+	clk.Advance(time.Minute)
+	q.Close()
+	if s := q.Stats(); s.Compactions != 1 {
+		t.Errorf("got %d compactions, want 1", s.Compactions)
+	}
+}
+
+func TestQueueAsPoolSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.wal")
+	q := open(t, path, Options{})
+	enqueue(t, q, "a", "b", "bad", "c")
+
+	fn := func(ctx context.Context, j Job[string]) (string, error) {
+		if j.Value == "bad" {
+			return "", errors.New("bad job")
+		}
+		return j.Value + "!", nil
+	}
+	p := pool.NewWithQueue(context.Background(), fn, q.Source(), pool.Options{Workers: 2})
+	if err := p.Submit(context.Background(), Job[string]{Value: "d"}); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	var done []string
+	for r := range p.Results() {
+		if r.Err != nil {
+			continue // Left for the next run
+		}
+		if err := q.Ack(r.Input.ID); err != nil {
+			t.Fatal(err)
+		}
+		done = append(done, r.Value)
+	}
+	slices.Sort(done)
+	want := []string{"a!", "b!", "c!", "d!"}
+	if !slices.Equal(done, want) {
+		t.Errorf("got %v, want %v", done, want)
+	}
+
+	// Closing the pool leaves the queue open, with the failed job pending
+	if s := q.Stats(); s.Acked != 4 || s.InFlight != 1 {
+		t.Errorf("got %+v, want 4 acknowledged and 1 in flight", s)
+	}
+	q.Close()
+	q = open(t, path, Options{})
+	if got := pending(t, q); !slices.Equal(got, []string{"bad"}) {
+		t.Errorf("got %v after reopening, want [bad]", got)
+	}
+}
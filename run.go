@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"time"
+)
+
+// logTimestamp matches the "2006/01/02 15:04:05 " prefix the log package
+// adds to every line the examples print
+var logTimestamp = regexp.MustCompile(`(?m)^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(\.\d+)? `)
+
+// errTimeout is returned by runExample when an example outlives its timeout
+var errTimeout = errors.New("timed out")
+
+// runOptions controls how an example is executed
+type runOptions struct {
+	Timeout time.Duration // Wall-clock limit per example, 0 means no limit
+	Grace   time.Duration // Time between the interrupt and the hard kill
+	Stdout  io.Writer
+	Stderr  io.Writer
+}
+
+// buildExample compiles the example into dir and returns the binary path.
+// Building separately from running means a timeout only ever has to stop the
+// example itself, not a "go run" wrapper that leaves its child behind.
+func buildExample(ctx context.Context, ex Example, dir string, stderr io.Writer) (string, error) {
+	bin, err := filepath.Abs(filepath.Join(dir, ex.Name))
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+
+	cmd := exec.CommandContext(ctx, "go", "build", "-o", bin, ".")
+	cmd.Dir = ex.Dir
+	cmd.Stdout = stderr
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("build: %w", err)
+	}
+	return bin, nil
+}
+
+// runExample builds and runs a single example from inside its own directory,
+// the same way "go run ." would. When the timeout expires the example first
+// receives an interrupt, so signal handlers like the one in
+// 20-process-management can clean up, and is killed after opts.Grace.
+func runExample(ctx context.Context, ex Example, opts runOptions) error {
+	tmp, err := os.MkdirTemp("", "go-by-example-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	bin, err := buildExample(ctx, ex, tmp, opts.Stderr)
+	if err != nil {
+		return err
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, bin)
+	cmd.Dir = ex.Dir
+	cmd.Stdout = opts.Stdout
+	cmd.Stderr = opts.Stderr
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = opts.Grace
+
+	err = cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %v", errTimeout, opts.Timeout)
+	}
+	return err
+}
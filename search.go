@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-by-example/internal/catalog"
+)
+
+// buildCatalog indexes every example's main.go
+func buildCatalog(examples []Example) (*catalog.Catalog, error) {
+	c := &catalog.Catalog{}
+	for _, ex := range examples {
+		src, err := os.ReadFile(filepath.Join(ex.Dir, "main.go"))
+		if err != nil {
+			return nil, err
+		}
+		entry, err := catalog.ParseEntry(ex.Path, ex.Title(), src)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ex.Path, err)
+		}
+		c.Examples = append(c.Examples, entry)
+	}
+	return c, nil
+}
+
+func catalogCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("catalog", flag.ContinueOnError)
+	out := fs.String("o", "", "Write the catalog to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	examples, err := discoverExamples(examplesRoot)
+	if err != nil {
+		return err
+	}
+	c, err := buildCatalog(examples)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*out, data, 0644)
+}
+
+func searchCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	pkg := fs.String("pkg", "", "Find examples importing this standard library package, e.g. sync/atomic")
+	asJSON := fs.Bool("json", false, "Print results as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	query := strings.Join(fs.Args(), " ")
+	if query == "" && *pkg == "" {
+		return errors.New("search requires a query or -pkg")
+	}
+
+	examples, err := discoverExamples(examplesRoot)
+	if err != nil {
+		return err
+	}
+	c, err := buildCatalog(examples)
+	if err != nil {
+		return err
+	}
+
+	var results []catalog.Result
+	if *pkg != "" {
+		for _, entry := range c.ImportedBy(*pkg) {
+			results = append(results, catalog.Result{Entry: entry, Matches: []string{*pkg}})
+		}
+		if query != "" {
+			results = filterResults(results, c.Search(query))
+		}
+	} else {
+		results = c.Search(query)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No examples found")
+		return nil
+	}
+	for _, res := range results {
+		fmt.Printf("%-50s %s\n", res.Entry.Path, res.Entry.Title)
+		for _, m := range res.Matches {
+			fmt.Printf("    %s\n", m)
+		}
+	}
+	return nil
+}
+
+// filterResults keeps the search hits whose example is also in byPkg
+func filterResults(byPkg, hits []catalog.Result) []catalog.Result {
+	keep := make(map[string]bool)
+	for _, res := range byPkg {
+		keep[res.Entry.Path] = true
+	}
+
+	var results []catalog.Result
+	for _, res := range hits {
+		if keep[res.Entry.Path] {
+			results = append(results, res)
+		}
+	}
+	return results
+}
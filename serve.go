@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"go-by-example/internal/sandbox"
+)
+
+func serveCommand(ctx context.Context, args []string) error {
+	lim := sandbox.DefaultLimits()
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8080", "Address to listen on")
+	fs.DurationVar(&lim.CPUTime, "cpu", lim.CPUTime, "CPU time limit per program")
+	fs.DurationVar(&lim.WallTime, "wall", lim.WallTime, "Wall-clock limit per program")
+	fs.Int64Var(&lim.Memory, "mem", lim.Memory, "Address space limit per program in bytes")
+	fs.IntVar(&lim.Output, "output", lim.Output, "Bytes of stdout and stderr kept per program")
+	concurrency := fs.Int("concurrency", 4, "Programs allowed to run at the same time")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	examples, err := discoverExamples(examplesRoot)
+	if err != nil {
+		return err
+	}
+	list := make([]sandbox.Example, 0, len(examples))
+	for _, ex := range examples {
+		list = append(list, sandbox.Example{Path: ex.Path, Title: ex.Title(), Dir: ex.Dir})
+	}
+
+	runner := &sandbox.Runner{Limits: lim, ModulePath: "go-by-example", ModuleDir: "."}
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           sandbox.NewServer(runner, list, *concurrency).Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		// A run may build and then use all of its wall time
+		WriteTimeout: lim.BuildTime + lim.WallTime + 10*time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Serving %d examples on http://%s\n", len(list), *addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}